@@ -0,0 +1,277 @@
+// Package client is a Go client for the friendbot API, which funds accounts
+// and contracts on Stellar test networks.
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	mathrand "math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultURL is the friendbot of the Stellar Test Network.
+const DefaultURL = "https://friendbot.stellar.org"
+
+// acceptHeader asks for version 2 of the funding response, which servers
+// that predate it ignore.
+const acceptHeader = "application/vnd.stellar.friendbot.v2+json, application/json;q=0.9"
+
+const (
+	defaultMaxRetries    = 3
+	defaultMinRetryDelay = 500 * time.Millisecond
+	defaultMaxRetryDelay = 10 * time.Second
+	// maxResponseSize bounds the responses read from friendbot.
+	maxResponseSize = 1 << 20
+)
+
+// Client funds addresses using a friendbot server. A Client is safe for
+// concurrent use.
+type Client struct {
+	// URL is the friendbot server, for example DefaultURL.
+	URL string
+	// HTTP is the client used for requests, http.DefaultClient if nil.
+	HTTP *http.Client
+	// MaxRetries is the number of times a request failing with a server
+	// error or a 429 response, or without a response, is retried. Negative
+	// values disable retries.
+	MaxRetries int
+	// MinRetryDelay and MaxRetryDelay bound the exponential backoff between
+	// retries. A Retry-After header from the server takes precedence.
+	MinRetryDelay time.Duration
+	MaxRetryDelay time.Duration
+	// UserAgent, if set, is sent in the User-Agent header.
+	UserAgent string
+}
+
+// New returns a client for the friendbot server at url, retrying failed
+// requests with the default backoff.
+func New(url string) *Client {
+	return &Client{
+		URL:           url,
+		MaxRetries:    defaultMaxRetries,
+		MinRetryDelay: defaultMinRetryDelay,
+		MaxRetryDelay: defaultMaxRetryDelay,
+	}
+}
+
+// Actions reported in TransactionResult.Action.
+const (
+	// ActionCreated means the funding created the account.
+	ActionCreated = "created"
+	// ActionToppedUp means the funding paid an existing account or contract.
+	ActionToppedUp = "topped_up"
+)
+
+// TransactionResult is the response to a successful funding. Servers that
+// predate version 2 of the response only return Successful, Hash and
+// EnvelopeXDR.
+type TransactionResult struct {
+	Successful  bool   `json:"successful"`
+	Hash        string `json:"hash"`
+	EnvelopeXDR string `json:"envelope_xdr"`
+	// Address is the funded address, and AddressType either "account" or
+	// "contract".
+	Address     string `json:"addr"`
+	AddressType string `json:"addr_type"`
+	// Amount is the amount of XLM sent to the address.
+	Amount string `json:"amount"`
+	// Action is ActionCreated or ActionToppedUp.
+	Action string `json:"action"`
+	// Ledger is the sequence of the ledger the transaction was included in,
+	// LedgerCloseTime the time the ledger closed, ResultXDR the result of the
+	// transaction, and FeeCharged the fee in stroops it was charged, if the
+	// server reported them. Waiting for Ledger to be ingested ensures the
+	// funded account can be queried.
+	Ledger          uint32    `json:"ledger"`
+	LedgerCloseTime time.Time `json:"ledger_close_time"`
+	ResultXDR       string    `json:"result_xdr"`
+	FeeCharged      int64     `json:"fee_charged"`
+	// Memo is the memo of the transaction, or nil if it has none.
+	Memo *Memo `json:"memo"`
+}
+
+// Memo is the memo of a funding transaction.
+type Memo struct {
+	// Type is "text", "id" or "hash", and Value the text, the id in decimal
+	// or the hash in hex.
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// FundResult is the outcome of funding a single address with FundBatch or
+// FundAsync.
+type FundResult struct {
+	Address string
+	Result  *TransactionResult
+	Err     error
+}
+
+// Fund funds the account or contract at addr. Failed requests are retried
+// with the same Idempotency-Key, so a retry of a funding whose response was
+// lost replays it. The server does not store server errors, so a retry after
+// an upstream_timeout problem is a new attempt, which fails as already funded
+// if the timed out one was applied. Fund treats that as success, and
+// returns a result with only Successful and Address set.
+//
+// Errors returned by the server are *Problem, which can be matched against
+// ErrAlreadyFunded, ErrInvalidAddress, ErrContractUnsupported and
+// ErrRateLimited with errors.Is. If ctx is done while waiting to retry, its
+// error is returned.
+func (c *Client) Fund(ctx context.Context, addr string) (*TransactionResult, error) {
+	idempotencyKey, err := newIdempotencyKey()
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{"addr": {addr}}.Encode()
+
+	timedOut := false
+	for attempt := 0; ; attempt++ {
+		result, retryAfter, err := c.fund(ctx, form, idempotencyKey)
+		if timedOut && errors.Is(err, ErrAlreadyFunded) {
+			return &TransactionResult{Successful: true, Address: addr}, nil
+		}
+		if err == nil || retryAfter < 0 || attempt >= c.MaxRetries {
+			return result, err
+		}
+		var p *Problem
+		if errors.As(err, &p) && p.Kind() == "upstream_timeout" {
+			timedOut = true
+		}
+		delay := max(retryAfter, c.backoff(attempt))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// fund makes a single funding request. If the request may be retried, the
+// returned duration is the minimum delay requested by the server, otherwise
+// it is negative.
+func (c *Client) fund(ctx context.Context, form, idempotencyKey string) (*TransactionResult, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, strings.NewReader(form))
+	if err != nil {
+		return nil, -1, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", acceptHeader)
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, -1, err
+		}
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		var result TransactionResult
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, -1, fmt.Errorf("parsing friendbot response: %w", err)
+		}
+		return &result, -1, nil
+	}
+
+	p := parseProblem(resp.StatusCode, body)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		return nil, retryAfter(resp.Header), p
+	}
+	return nil, -1, p
+}
+
+// backoff returns the delay before retry attempt+1, growing exponentially
+// with jitter from MinRetryDelay up to MaxRetryDelay.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := float64(c.MinRetryDelay) * math.Pow(2, float64(attempt))
+	if c.MaxRetryDelay > 0 {
+		delay = min(delay, float64(c.MaxRetryDelay))
+	}
+	// Spread retries from concurrent callers over [delay/2, delay).
+	return time.Duration(delay/2 + mathrand.Float64()*delay/2)
+}
+
+// retryAfter returns the delay requested by the Retry-After header, or 0 if
+// there is none.
+func retryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+// FundBatch funds every address in addrs, making at most concurrency requests
+// at once, and returns the results in the same order as addrs. A concurrency
+// below 1 is treated as 1.
+func (c *Client) FundBatch(ctx context.Context, addrs []string, concurrency int) []FundResult {
+	results := make([]FundResult, len(addrs))
+	sem := make(chan struct{}, max(concurrency, 1))
+	var wg sync.WaitGroup
+	for i, addr := range addrs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result, err := c.Fund(ctx, addr)
+			results[i] = FundResult{Address: addr, Result: result, Err: err}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// FundAsync funds addr in the background, sending the result on the returned
+// channel once the funding completes.
+func (c *Client) FundAsync(ctx context.Context, addr string) <-chan FundResult {
+	results := make(chan FundResult, 1)
+	go func() {
+		result, err := c.Fund(ctx, addr)
+		results <- FundResult{Address: addr, Result: result, Err: err}
+		close(results)
+	}()
+	return results
+}
+
+// newIdempotencyKey returns a random key identifying a single funding across
+// its retries.
+func newIdempotencyKey() (string, error) {
+	var key [16]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return "", fmt.Errorf("generating idempotency key: %w", err)
+	}
+	return hex.EncodeToString(key[:]), nil
+}
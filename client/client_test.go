@@ -0,0 +1,259 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAddress = "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+
+func newTestClient(url string) *Client {
+	c := New(url)
+	c.MinRetryDelay = time.Millisecond
+	c.MaxRetryDelay = 5 * time.Millisecond
+	return c
+}
+
+func writeProblem(w http.ResponseWriter, status int, problem map[string]any) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+func TestClient_Fund(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, testAddress, r.FormValue("addr"))
+		assert.NotEmpty(t, r.Header.Get("Idempotency-Key"))
+		assert.Contains(t, r.Header.Get("Accept"), "application/vnd.stellar.friendbot.v2+json")
+		w.Write([]byte(`{"successful": true, "hash": "abc", "envelope_xdr": "AAAA", "addr": "` + testAddress + `",
+			"addr_type": "account", "amount": "10000.0000000", "action": "created", "ledger": 12,
+			"ledger_close_time": "2025-01-02T03:04:05Z", "result_xdr": "AAAAAAAAAGQAAAAAAAAAAAAAAAA=", "fee_charged": 100,
+			"memo": {"type": "text", "value": "quickstart"}}`))
+	}))
+	defer server.Close()
+
+	result, err := newTestClient(server.URL).Fund(context.Background(), testAddress)
+	require.NoError(t, err)
+	assert.Equal(t, &TransactionResult{
+		Successful:      true,
+		Hash:            "abc",
+		EnvelopeXDR:     "AAAA",
+		Address:         testAddress,
+		AddressType:     "account",
+		Amount:          "10000.0000000",
+		Action:          ActionCreated,
+		Ledger:          12,
+		LedgerCloseTime: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		ResultXDR:       "AAAAAAAAAGQAAAAAAAAAAAAAAAA=",
+		FeeCharged:      100,
+		Memo:            &Memo{Type: "text", Value: "quickstart"},
+	}, result)
+}
+
+func TestClient_Fund_errors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		status  int
+		problem map[string]any
+		kind    string
+		err     error
+	}{
+		{
+			name:   "account exists",
+			status: http.StatusBadRequest,
+			problem: map[string]any{
+				"type": "https://stellar.org/friendbot-errors/bad_request", "title": "Bad Request", "status": 400,
+				"detail": "createAccountAlreadyExist (AAAAAAAAAGT/////AAAAAQAAAAAAAAAA/////AAAAAA==)",
+				"extras": map[string]any{"already_funded": true, "retryable": false},
+			},
+			kind: "bad_request",
+			err:  ErrAlreadyFunded,
+		},
+		{
+			name:   "account funded",
+			status: http.StatusBadRequest,
+			problem: map[string]any{
+				"type": "https://stellar.org/friendbot-errors/bad_request", "title": "Bad Request", "status": 400,
+				"detail": "account already funded to starting balance",
+				"extras": map[string]any{"already_funded": true, "retryable": false},
+			},
+			kind: "bad_request",
+			err:  ErrAlreadyFunded,
+		},
+		{
+			name:   "invalid address",
+			status: http.StatusBadRequest,
+			problem: map[string]any{
+				"type": "https://stellar.org/friendbot-errors/bad_request", "title": "Bad Request", "status": 400,
+				"extras": map[string]any{"invalid_field": "addr", "reason": "invalid address: must be a valid G or C address"},
+			},
+			kind: "bad_request",
+			err:  ErrInvalidAddress,
+		},
+		{
+			name:   "contract funding disabled",
+			status: http.StatusBadRequest,
+			problem: map[string]any{
+				"type": "https://stellar.org/friendbot-errors/contract_funding_disabled", "title": "Contract Funding Disabled", "status": 400,
+				"detail": "This friendbot does not fund contract (C) addresses.",
+				"extras": map[string]any{"invalid_field": "addr", "reason": "contract addresses are not supported or enabled", "retryable": false},
+			},
+			kind: "contract_funding_disabled",
+			err:  ErrContractUnsupported,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeProblem(w, tc.status, tc.problem)
+			}))
+			defer server.Close()
+
+			_, err := newTestClient(server.URL).Fund(context.Background(), testAddress)
+			assert.ErrorIs(t, err, tc.err)
+			var p *Problem
+			require.ErrorAs(t, err, &p)
+			assert.Equal(t, tc.kind, p.Kind())
+		})
+	}
+}
+
+func TestClient_Fund_retries(t *testing.T) {
+	var attempts atomic.Int32
+	var keys sync.Map
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys.Store(r.Header.Get("Idempotency-Key"), true)
+		switch attempts.Add(1) {
+		case 1:
+			writeProblem(w, http.StatusServiceUnavailable, map[string]any{"type": "queue_full", "title": "Service Unavailable", "status": 503})
+		case 2:
+			writeProblem(w, http.StatusTooManyRequests, map[string]any{"type": "rate_limited", "title": "Too Many Requests", "status": 429})
+		default:
+			w.Write([]byte(`{"successful": true, "hash": "abc"}`))
+		}
+	}))
+	defer server.Close()
+
+	result, err := newTestClient(server.URL).Fund(context.Background(), testAddress)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", result.Hash)
+	assert.Equal(t, int32(3), attempts.Load())
+
+	// Every attempt carries the same idempotency key.
+	numKeys := 0
+	keys.Range(func(any, any) bool { numKeys++; return true })
+	assert.Equal(t, 1, numKeys)
+}
+
+func TestClient_Fund_retriesExhausted(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		writeProblem(w, http.StatusTooManyRequests, map[string]any{"type": "rate_limited", "title": "Too Many Requests", "status": 429})
+	}))
+	defer server.Close()
+
+	c := newTestClient(server.URL)
+	c.MaxRetries = 2
+	_, err := c.Fund(context.Background(), testAddress)
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestClient_Fund_alreadyFundedAfterTimeout(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			writeProblem(w, http.StatusGatewayTimeout, map[string]any{
+				"type": "https://stellar.org/friendbot-errors/upstream_timeout", "title": "Gateway Timeout", "status": 504,
+				"extras": map[string]any{"retryable": true},
+			})
+			return
+		}
+		writeProblem(w, http.StatusBadRequest, map[string]any{
+			"type": "https://stellar.org/friendbot-errors/bad_request", "title": "Bad Request", "status": 400,
+			"detail": "account already funded to starting balance",
+			"extras": map[string]any{"already_funded": true, "retryable": false},
+		})
+	}))
+	defer server.Close()
+
+	// The timed out request funded the address, so the retry succeeds.
+	result, err := newTestClient(server.URL).Fund(context.Background(), testAddress)
+	require.NoError(t, err)
+	assert.Equal(t, &TransactionResult{Successful: true, Address: testAddress}, result)
+	assert.Equal(t, int32(2), attempts.Load())
+}
+
+func TestClient_Fund_canceledWhileWaiting(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		writeProblem(w, http.StatusServiceUnavailable, map[string]any{"type": "queue_full", "title": "Service Unavailable", "status": 503})
+	}))
+	defer server.Close()
+
+	c := newTestClient(server.URL)
+	c.MinRetryDelay = time.Minute
+	c.MaxRetryDelay = time.Minute
+	_, err := c.Fund(ctx, testAddress)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestClient_Fund_doesNotRetryClientErrors(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL).Fund(context.Background(), testAddress)
+	var p *Problem
+	require.ErrorAs(t, err, &p)
+	assert.Equal(t, http.StatusNotFound, p.Status)
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestClient_FundBatch(t *testing.T) {
+	const other = "GA5ZSEJYB37JRC5AVCIA5MOP4RHTM335X2KGX3IHOJAPP5RE34K4KZVN"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("addr") == other {
+			writeProblem(w, http.StatusBadRequest, map[string]any{
+				"type": "bad_request", "title": "Bad Request", "status": 400,
+				"detail": "account already funded to starting balance",
+				"extras": map[string]any{"already_funded": true, "retryable": false},
+			})
+			return
+		}
+		w.Write([]byte(`{"successful": true, "hash": "abc"}`))
+	}))
+	defer server.Close()
+
+	results := newTestClient(server.URL).FundBatch(context.Background(), []string{testAddress, other}, 2)
+	require.Len(t, results, 2)
+	assert.Equal(t, testAddress, results[0].Address)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, other, results[1].Address)
+	assert.ErrorIs(t, results[1].Err, ErrAlreadyFunded)
+}
+
+func TestClient_FundAsync(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"successful": true, "hash": "abc"}`))
+	}))
+	defer server.Close()
+
+	result := <-newTestClient(server.URL).FundAsync(context.Background(), testAddress)
+	require.NoError(t, result.Err)
+	assert.Equal(t, "abc", result.Result.Hash)
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrAlreadyFunded is matched by problems returned for addresses that
+	// already exist or hold the starting balance.
+	ErrAlreadyFunded = errors.New("address already funded")
+	// ErrInvalidAddress is matched by problems returned for addresses that
+	// are neither valid account (G) nor contract (C) addresses.
+	ErrInvalidAddress = errors.New("invalid address")
+	// ErrContractUnsupported is matched by problems returned for contract
+	// addresses when the server does not fund them.
+	ErrContractUnsupported = errors.New("contract addresses are not supported")
+	// ErrRateLimited is matched by problems returned when the server is
+	// limiting the rate of requests.
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// Problem is an error response from friendbot, in the RFC 7807 problem
+// details format.
+type Problem struct {
+	// Type is the URI identifying the kind of problem. Its last path segment,
+	// returned by Kind, is stable.
+	Type   string         `json:"type"`
+	Title  string         `json:"title"`
+	Status int            `json:"status"`
+	Detail string         `json:"detail,omitempty"`
+	Extras map[string]any `json:"extras,omitempty"`
+
+	// err is the sentinel error the problem matches, if any.
+	err error
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return fmt.Sprintf("friendbot: %s (%d): %s", p.Kind(), p.Status, p.Detail)
+	}
+	return fmt.Sprintf("friendbot: %s (%d)", p.Kind(), p.Status)
+}
+
+// Unwrap returns the sentinel error matching the problem, so that it can be
+// checked with errors.Is.
+func (p *Problem) Unwrap() error {
+	return p.err
+}
+
+// Kind returns the last path segment of the problem type, such as
+// "bad_request" for "https://stellar.org/friendbot-errors/bad_request".
+func (p *Problem) Kind() string {
+	return p.Type[strings.LastIndex(p.Type, "/")+1:]
+}
+
+// parseProblem returns the problem in the body of a response with the given
+// status. Bodies that are not problems, such as those from proxies, become a
+// problem with the status alone.
+func parseProblem(status int, body []byte) *Problem {
+	p := &Problem{}
+	if err := json.Unmarshal(body, p); err != nil || p.Type == "" {
+		p = &Problem{Type: "about:blank", Title: http.StatusText(status), Detail: strings.TrimSpace(string(body))}
+	}
+	p.Status = status
+	p.err = classify(p)
+	return p
+}
+
+// classify returns the sentinel error matching p, if any.
+func classify(p *Problem) error {
+	if p.Status == http.StatusTooManyRequests {
+		return ErrRateLimited
+	}
+	if p.Status != http.StatusBadRequest {
+		return nil
+	}
+	if p.Kind() == "contract_funding_disabled" {
+		return ErrContractUnsupported
+	}
+	if funded, _ := p.Extras["already_funded"].(bool); funded {
+		return ErrAlreadyFunded
+	}
+	if field, _ := p.Extras["invalid_field"].(string); field == "addr" {
+		return ErrInvalidAddress
+	}
+	return nil
+}
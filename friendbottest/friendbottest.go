@@ -0,0 +1,141 @@
+// Package friendbottest runs an embedded friendbot for Go integration tests,
+// so that tests can fund accounts without a separately deployed friendbot.
+//
+//	server, shutdown, err := friendbottest.NewServer(friendbottest.Options{
+//		RPCURL: "http://localhost:8000/rpc",
+//		Secret: botSecret,
+//	})
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer shutdown()
+//	_, err = client.New(server.URL).Fund(ctx, address)
+package friendbottest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/stellar/friendbot/internal"
+	"github.com/stellar/friendbot/internal/app"
+	"github.com/stellar/go-stellar-sdk/network"
+	"github.com/stellar/go-stellar-sdk/txnbuild"
+)
+
+// NetworkClient is the interface friendbot uses to talk to the network. It
+// can be implemented by tests to run friendbot without a network.
+type NetworkClient = internal.NetworkClient
+
+// NetworkError is implemented by errors returned from a NetworkClient that
+// friendbot handles specially, such as accounts that are not found.
+type NetworkError = internal.NetworkError
+
+// AccountDetails is returned by NetworkClient.GetAccountDetails.
+type AccountDetails = internal.AccountDetails
+
+// SubmitTransactionResult is returned by NetworkClient.SubmitTransaction.
+type SubmitTransactionResult = internal.SubmitTransactionResult
+
+// SimulateTransactionResult is returned by
+// NetworkClient.SimulateTransaction.
+type SimulateTransactionResult = internal.SimulateTransactionResult
+
+// NetworkInfo is returned by NetworkClient.NetworkInfo.
+type NetworkInfo = internal.NetworkInfo
+
+// DefaultNumMinions is the number of minions created when Options.NumMinions
+// is zero. It is kept low so that friendbot starts quickly.
+const DefaultNumMinions = 2
+
+// Options configures an embedded friendbot.
+type Options struct {
+	// NetworkClient, if set, is used to submit transactions and look up
+	// accounts. Otherwise one of HorizonURL or RPCURL must be set.
+	NetworkClient NetworkClient
+	// HorizonURL is the Horizon server friendbot uses if NetworkClient is
+	// not set.
+	HorizonURL string
+	// RPCURL is the RPC server friendbot uses if NetworkClient is not set.
+	// Contract addresses can only be funded through RPC.
+	RPCURL string
+	// NetworkPassphrase is the passphrase of the network, the Test Network's
+	// if empty.
+	NetworkPassphrase string
+	// Secret is the secret key of the funded account friendbot pays from.
+	Secret string
+	// StartingBalance is the amount of XLM sent to each funded address,
+	// "10000.00" if empty.
+	StartingBalance string
+	// NumMinions is the number of minion accounts funding addresses
+	// concurrently, DefaultNumMinions if zero.
+	NumMinions int
+	// BaseFee is the base fee of friendbot's transactions, in stroops,
+	// txnbuild.MinBaseFee if zero.
+	BaseFee int64
+	// FundContractAddresses enables funding contract (C) addresses.
+	FundContractAddresses bool
+}
+
+// NewHandler starts a friendbot configured by opts and returns its API. It
+// returns once the minions are ready to fund addresses. The returned func
+// stops friendbot.
+//
+// Nothing is written to disk: the minions are recreated every time, and the
+// funding history is not recorded.
+func NewHandler(opts Options) (http.Handler, func(), error) {
+	if opts.Secret == "" {
+		return nil, nil, errors.New("friendbottest: Secret is required")
+	}
+	if opts.NetworkClient == nil && opts.HorizonURL == "" && opts.RPCURL == "" {
+		return nil, nil, errors.New("friendbottest: one of NetworkClient, HorizonURL or RPCURL is required")
+	}
+
+	cfg := app.Config{
+		NetworkPassphrase:     opts.NetworkPassphrase,
+		HorizonURL:            opts.HorizonURL,
+		RPCURL:                opts.RPCURL,
+		StartingBalance:       opts.StartingBalance,
+		NumMinions:            opts.NumMinions,
+		BaseFee:               opts.BaseFee,
+		FundContractAddresses: opts.FundContractAddresses,
+		MinionStore:           "none",
+		HistoryStore:          "none",
+	}
+	if cfg.NetworkPassphrase == "" {
+		cfg.NetworkPassphrase = network.TestNetworkPassphrase
+	}
+	if cfg.StartingBalance == "" {
+		cfg.StartingBalance = "10000.00"
+	}
+	if cfg.NumMinions == 0 {
+		cfg.NumMinions = DefaultNumMinions
+	}
+	if cfg.BaseFee == 0 {
+		cfg.BaseFee = txnbuild.MinBaseFee
+	}
+	// Create every minion before returning, so that tests do not race the
+	// minions created in the background.
+	cfg.MinReadyMinions = cfg.NumMinions
+
+	handler, shutdown, err := app.NewHandler(cfg, app.Secrets{FriendbotSecret: opts.Secret}, opts.NetworkClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	return handler, shutdown, nil
+}
+
+// NewServer starts a friendbot configured by opts, as NewHandler does, and
+// serves its API from an httptest.Server. The returned func stops friendbot
+// and closes the server.
+func NewServer(opts Options) (*httptest.Server, func(), error) {
+	handler, shutdown, err := NewHandler(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	server := httptest.NewServer(handler)
+	return server, func() {
+		server.Close()
+		shutdown()
+	}, nil
+}
@@ -0,0 +1,103 @@
+package friendbottest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stellar/friendbot/client"
+	"github.com/stellar/go-stellar-sdk/amount"
+	"github.com/stellar/go-stellar-sdk/keypair"
+	"github.com/stellar/go-stellar-sdk/network"
+	"github.com/stellar/go-stellar-sdk/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type notFoundError struct{}
+
+func (notFoundError) Error() string                    { return "not found" }
+func (notFoundError) IsNotFound() bool                 { return true }
+func (notFoundError) IsBadSequence() bool              { return false }
+func (notFoundError) IsTimeout() bool                  { return false }
+func (notFoundError) ResultString() (string, error)    { return "", nil }
+func (notFoundError) DiagnosticEventStrings() []string { return nil }
+
+// ledger is a network client keeping the accounts created by the
+// transactions submitted to it.
+type ledger struct {
+	mu       sync.Mutex
+	balances map[string]string
+}
+
+func newLedger(botAccountID string) *ledger {
+	return &ledger{balances: map[string]string{botAccountID: "1000000.0000000"}}
+}
+
+func (l *ledger) SubmitTransaction(ctx context.Context, txXDR string) (*SubmitTransactionResult, error) {
+	var envelope xdr.TransactionEnvelope
+	if err := xdr.SafeUnmarshalBase64(txXDR, &envelope); err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, op := range envelope.Operations() {
+		if create, ok := op.Body.GetCreateAccountOp(); ok {
+			l.balances[create.Destination.Address()] = amount.String(create.StartingBalance)
+		}
+	}
+	return &SubmitTransactionResult{}, nil
+}
+
+func (l *ledger) GetAccountDetails(ctx context.Context, accountID string) (*AccountDetails, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	balance, ok := l.balances[accountID]
+	if !ok {
+		return nil, notFoundError{}
+	}
+	return &AccountDetails{Sequence: 1, Balance: balance, Signers: map[string]int32{accountID: 1}}, nil
+}
+
+func (l *ledger) numAccounts() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.balances)
+}
+
+func (l *ledger) SimulateTransaction(ctx context.Context, txXDR string) (*SimulateTransactionResult, error) {
+	return nil, nil
+}
+
+func (l *ledger) SupportsContractAddresses() bool {
+	return false
+}
+
+func (l *ledger) NetworkInfo(ctx context.Context) (*NetworkInfo, error) {
+	return &NetworkInfo{Passphrase: network.TestNetworkPassphrase, ProtocolVersion: 22}, nil
+}
+
+func TestNewServer(t *testing.T) {
+	bot := keypair.MustRandom()
+	networkClient := newLedger(bot.Address())
+	server, shutdown, err := NewServer(Options{NetworkClient: networkClient, Secret: bot.Seed()})
+	require.NoError(t, err)
+	defer shutdown()
+
+	// The bot creates the minions before the server starts.
+	assert.Equal(t, 1+DefaultNumMinions, networkClient.numAccounts())
+
+	address := keypair.MustRandom().Address()
+	result, err := client.New(server.URL).Fund(context.Background(), address)
+	require.NoError(t, err)
+	assert.True(t, result.Successful)
+	assert.Equal(t, client.ActionCreated, result.Action)
+	details, err := networkClient.GetAccountDetails(context.Background(), address)
+	require.NoError(t, err)
+	assert.Equal(t, "10000.0000000", details.Balance)
+}
+
+func TestNewHandler_requiresNetwork(t *testing.T) {
+	_, _, err := NewHandler(Options{Secret: keypair.MustRandom().Seed()})
+	assert.EqualError(t, err, "friendbottest: one of NetworkClient, HorizonURL or RPCURL is required")
+}
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stellar/go-stellar-sdk/support/render/hal"
+	"github.com/stellar/go-stellar-sdk/support/render/problem"
+)
+
+// maxAddMinions is the maximum number of minions that can be added by a single
+// admin request.
+const maxAddMinions = 1000
+
+// AdminHandler serves operator endpoints for inspecting and controlling a
+// running Bot. It must only be served on the admin port, which is never
+// exposed publicly.
+type AdminHandler struct {
+	Bot *Bot
+}
+
+// NewAdminHandler returns a handler serving operator endpoints for bot.
+func NewAdminHandler(bot *Bot) *AdminHandler {
+	return &AdminHandler{Bot: bot}
+}
+
+// MinionList is the response listing the minions in the pool.
+type MinionList struct {
+	Minions []MinionStatus `json:"minions"`
+}
+
+// AddMinionsResult is the response to adding minions to the pool.
+type AddMinionsResult struct {
+	Added int `json:"added"`
+	Total int `json:"total"`
+}
+
+// FundingStatus is the response describing whether funding is paused.
+type FundingStatus struct {
+	Paused bool `json:"paused"`
+}
+
+// Budget serves the current spending and remaining budget.
+func (handler *AdminHandler) Budget(w http.ResponseWriter, r *http.Request) {
+	if handler.Bot.Budget == nil {
+		problem.Render(r.Context(), w, problem.NotFound)
+		return
+	}
+	hal.Render(w, handler.Bot.Budget.Status())
+}
+
+// ListMinions serves the status of every minion in the pool.
+func (handler *AdminHandler) ListMinions(w http.ResponseWriter, r *http.Request) {
+	hal.Render(w, MinionList{Minions: handler.Bot.MinionStatuses(r.Context())})
+}
+
+// AddMinions creates the number of minions in the `count` parameter and adds
+// them to the pool.
+func (handler *AdminHandler) AddMinions(w http.ResponseWriter, r *http.Request) {
+	count, err := strconv.Atoi(r.FormValue("count"))
+	if err != nil || count < 1 || count > maxAddMinions {
+		problem.Render(r.Context(), w, problem.MakeInvalidFieldProblem("count", errors.New("must be between 1 and 1000")))
+		return
+	}
+	added, err := handler.Bot.AddMinions(r.Context(), count)
+	if err != nil && added == 0 {
+		problem.Render(r.Context(), w, err)
+		return
+	}
+	hal.Render(w, AddMinionsResult{Added: added, Total: handler.Bot.NumMinions()})
+}
+
+// QuarantineMinion stops the minion with the `address` URL parameter from
+// being used for new payments.
+func (handler *AdminHandler) QuarantineMinion(w http.ResponseWriter, r *http.Request) {
+	handler.renderMinion(w, r, handler.Bot.QuarantineMinion)
+}
+
+// ReleaseMinion returns the quarantined minion with the `address` URL
+// parameter to the pool.
+func (handler *AdminHandler) ReleaseMinion(w http.ResponseWriter, r *http.Request) {
+	handler.renderMinion(w, r, handler.Bot.ReleaseMinion)
+}
+
+// RefreshMinionSequence makes the minion with the `address` URL parameter
+// refresh its sequence number before its next payment.
+func (handler *AdminHandler) RefreshMinionSequence(w http.ResponseWriter, r *http.Request) {
+	handler.renderMinion(w, r, handler.Bot.RefreshMinionSequence)
+}
+
+func (handler *AdminHandler) renderMinion(w http.ResponseWriter, r *http.Request, action func(address string) (MinionStatus, error)) {
+	status, err := action(chi.URLParam(r, "address"))
+	if err != nil {
+		problem.Render(r.Context(), w, err)
+		return
+	}
+	hal.Render(w, status)
+}
+
+// Funding serves whether funding is paused.
+func (handler *AdminHandler) Funding(w http.ResponseWriter, r *http.Request) {
+	hal.Render(w, FundingStatus{Paused: handler.Bot.Paused()})
+}
+
+// PauseFunding stops all funding until it is resumed.
+func (handler *AdminHandler) PauseFunding(w http.ResponseWriter, r *http.Request) {
+	handler.Bot.SetPaused(true)
+	hal.Render(w, FundingStatus{Paused: true})
+}
+
+// ResumeFunding resumes funding after it was paused.
+func (handler *AdminHandler) ResumeFunding(w http.ResponseWriter, r *http.Request) {
+	handler.Bot.SetPaused(false)
+	hal.Render(w, FundingStatus{Paused: false})
+}
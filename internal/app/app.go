@@ -0,0 +1,581 @@
+// Package app is the friendbot server: its configuration, initialization and
+// command line.
+package app
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"fmt"
+	stdhttp "net/http"
+	"os"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/riandyrn/otelchi"
+	"github.com/spf13/cobra"
+
+	"github.com/stellar/friendbot/internal"
+	"github.com/stellar/go-stellar-sdk/strkey"
+	"github.com/stellar/go-stellar-sdk/support/app"
+	"github.com/stellar/go-stellar-sdk/support/config"
+	"github.com/stellar/go-stellar-sdk/support/errors"
+	"github.com/stellar/go-stellar-sdk/support/http"
+	"github.com/stellar/go-stellar-sdk/support/log"
+	"github.com/stellar/go-stellar-sdk/support/render/problem"
+	"github.com/stellar/go-stellar-sdk/utils/tracer"
+)
+
+const (
+	serviceName    = "stellar-friendbot"
+	serviceVersion = "1.0.0"
+)
+
+// Config represents the non-secret configuration.
+type Config struct {
+	Port                      int         `toml:"port" valid:"optional"`
+	NetworkPassphrase         string      `toml:"network_passphrase" valid:"optional"`
+	HorizonURL                string      `toml:"horizon_url" valid:"optional"`
+	RPCURL                    string      `toml:"rpc_url" valid:"optional"`
+	StartingBalance           string      `toml:"starting_balance" valid:"optional"`
+	TLS                       *config.TLS `valid:"optional"`
+	NumMinions                int         `toml:"num_minions" valid:"optional"`
+	BaseFee                   int64       `toml:"base_fee" valid:"optional"`
+	MinionBatchSize           int         `toml:"minion_batch_size" valid:"optional"`
+	SubmitTxRetriesAllowed    int         `toml:"submit_tx_retries_allowed" valid:"optional"`
+	FundContractAddresses     bool        `toml:"fund_contract_addresses" valid:"optional"`
+	SigningThreshold          int         `toml:"signing_threshold" valid:"optional"`
+	FriendbotAccountID        string      `toml:"friendbot_account_id" valid:"optional"`
+	RemoteSignerURL           string      `toml:"remote_signer_url" valid:"optional"`
+	UseCloudflareIP           bool        `toml:"use_cloudflare_ip" valid:"optional"`
+	CORSAllowedOrigins        []string    `toml:"cors_allowed_origins" valid:"optional"`
+	CORSAllowedOriginPatterns []string    `toml:"cors_allowed_origin_patterns" valid:"optional"`
+	CORSAllowedMethods        []string    `toml:"cors_allowed_methods" valid:"optional"`
+	CORSAllowedHeaders        []string    `toml:"cors_allowed_headers" valid:"optional"`
+	CORSMaxAgeSeconds         int         `toml:"cors_max_age_seconds" valid:"optional"`
+	OtelEndpoint              string      `toml:"otel_endpoint" valid:"optional"`
+	OtelEnabled               bool        `toml:"otel_enabled" valid:"optional"`
+	QueueMaxDepth             int         `toml:"queue_max_depth" valid:"optional"`
+	QueueMaxWaitMs            int         `toml:"queue_max_wait_ms" valid:"optional"`
+	AdminPort                 int         `toml:"admin_port" valid:"optional"`
+	ReplayTTLMs               int         `toml:"replay_ttl_ms" valid:"optional"`
+	Memo                      string      `toml:"memo" valid:"optional"`
+	MemoType                  string      `toml:"memo_type" valid:"optional"`
+	IdempotencyStore          string      `toml:"idempotency_store" valid:"optional"`
+	IdempotencyStorePath      string      `toml:"idempotency_store_path" valid:"optional"`
+	IdempotencyTTLSeconds     int         `toml:"idempotency_ttl_seconds" valid:"optional"`
+	IdempotencyMaxRecords     int         `toml:"idempotency_max_records" valid:"optional"`
+	HistoryStore              string      `toml:"history_store" valid:"optional"`
+	HistoryStorePath          string      `toml:"history_store_path" valid:"optional"`
+	HistoryRetentionDays      int         `toml:"history_retention_days" valid:"optional"`
+	HourlyBudget              string      `toml:"hourly_budget" valid:"optional"`
+	DailyBudget               string      `toml:"daily_budget" valid:"optional"`
+	TreasuryWatermark         string      `toml:"treasury_watermark" valid:"optional"`
+	TreasuryTarget            string      `toml:"treasury_target" valid:"optional"`
+	TreasuryMaxDailyRefill    string      `toml:"treasury_max_daily_refill" valid:"optional"`
+	TreasuryCheckSeconds      int         `toml:"treasury_check_interval_seconds" valid:"optional"`
+	MinReadyMinions           int         `toml:"min_ready_minions" valid:"optional"`
+	MinionCreationConcurrency int         `toml:"minion_creation_concurrency" valid:"optional"`
+	MinionStore               string      `toml:"minion_store" valid:"optional"`
+	MinionStorePath           string      `toml:"minion_store_path" valid:"optional"`
+	AutoscaleMinMinions       int         `toml:"autoscale_min_minions" valid:"optional"`
+	AutoscaleMaxMinions       int         `toml:"autoscale_max_minions" valid:"optional"`
+	AutoscaleStep             int         `toml:"autoscale_step" valid:"optional"`
+	AutoscaleIntervalSecs     int         `toml:"autoscale_interval_seconds" valid:"optional"`
+	AutoscaleScaleUpWaitMs    int         `toml:"autoscale_scale_up_wait_ms" valid:"optional"`
+	AutoscaleScaleDownSecs    int         `toml:"autoscale_scale_down_after_seconds" valid:"optional"`
+}
+
+// ConfigWithSecrets is used for parsing --conf files that may contain the
+// secrets for backwards compatibility with earlier versions that contained
+// secrets in the config file.
+type ConfigWithSecrets struct {
+	Config   `valid:"required"`
+	*Secrets `valid:"optional"`
+}
+
+// Secrets represents the secret configuration loaded from --secret.
+type Secrets struct {
+	FriendbotSecret string `toml:"friendbot_secret" valid:"optional"`
+	// FriendbotSignerSecrets are additional keys signing for the friendbot
+	// account, when it requires more than one signature.
+	FriendbotSignerSecrets []string `toml:"friendbot_signer_secrets" valid:"optional"`
+	// TreasurySecret, if set, enables refilling the friendbot account from
+	// the treasury account it is the secret key of.
+	TreasurySecret string `toml:"treasury_secret" valid:"optional"`
+	// AdminToken is the bearer token required by admin endpoints that
+	// inspect or control the minion pool.
+	AdminToken string `toml:"admin_token" valid:"optional"`
+	// RemoteSignerToken is the bearer token sent to the remote signer.
+	RemoteSignerToken string `toml:"remote_signer_token" valid:"optional"`
+}
+
+// signerSecretFile is a file, passed with --signer-secret, holding additional
+// keys signing for the friendbot account.
+type signerSecretFile struct {
+	FriendbotSignerSecrets []string `toml:"friendbot_signer_secrets" valid:"required"`
+}
+
+// serviceUnavailableProblem is the base for problems returned when friendbot
+// is temporarily unable to process a request. The retryable extra tells
+// clients that the same request may succeed later.
+var serviceUnavailableProblem = problem.P{
+	Type:   "service_unavailable",
+	Title:  "Service Unavailable",
+	Status: stdhttp.StatusServiceUnavailable,
+	Extras: map[string]interface{}{"retryable": true},
+}
+
+// unauthorizedProblem is returned by admin endpoints when the request does not
+// carry the admin token.
+var unauthorizedProblem = problem.P{
+	Type:   "unauthorized",
+	Title:  "Unauthorized",
+	Status: stdhttp.StatusUnauthorized,
+	Detail: "The request must include the admin token in an Authorization: Bearer header.",
+}
+
+// NewRootCmd returns the friendbot command, which runs the friendbot server
+// and has subcommands for the tools around it.
+func NewRootCmd() *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:   "friendbot",
+		Short: "friendbot for the Stellar Test Network",
+		Long:  "Client-facing API server for the friendbot service on the Stellar Test Network",
+		Run:   run,
+	}
+
+	rootCmd.PersistentFlags().String("conf", "./friendbot.cfg", "config file path")
+	rootCmd.PersistentFlags().String("secret", "", "secret config file path (optional, overrides friendbot_secret from conf)")
+	rootCmd.PersistentFlags().StringArray("signer-secret", nil, "file with additional friendbot_signer_secrets (optional, repeatable)")
+	rootCmd.AddCommand(newSigningServerCmd())
+	rootCmd.AddCommand(newConfigCmd())
+	return rootCmd
+}
+
+func run(cmd *cobra.Command, args []string) {
+	cfgPath := configPath(cmd)
+	secretPath := flagOrEnv(cmd, "secret", "FRIENDBOT_SECRET_FILE")
+	signerSecretPaths, _ := cmd.PersistentFlags().GetStringArray("signer-secret")
+	log.SetLevel(log.InfoLevel)
+
+	cfg, secrets, err := loadConfig(cfgPath, secretPath, signerSecretPaths...)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+
+	//Setup and initialize tracer
+	tracer, err := tracer.InitializeTracer(cfg.OtelEnabled, cfg.OtelEndpoint, serviceName, serviceVersion)
+	if err != nil {
+		log.Error("Failed to initialize tracer:", err)
+	}
+	log.Infof("Tracer initialized")
+	defer tracer()
+
+	metricsHandler, err := initMetrics()
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+
+	fb, err := initFriendbot(cfg, secrets)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	router := initRouter(cfg, fb)
+	registerProblems()
+
+	treasury, err := initTreasury(context.Background(), cfg, secrets, fb.NetworkClient, fb.MinionFactory.BotAccount.AccountID, fb.History)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	if treasury != nil {
+		go treasury.Run(context.Background(), treasuryCheckInterval(cfg))
+	}
+	if autoscaler := initAutoscaler(cfg, fb); autoscaler != nil {
+		go autoscaler.Run(context.Background(), autoscaleInterval(cfg))
+	}
+
+	reloader := newConfigReloader(cfg, fb, cfgPath, secretPath, signerSecretPaths)
+	go reloader.ReloadOnSignal()
+
+	if cfg.AdminPort != 0 {
+		go runAdminServer(cfg, initAdminRouter(fb, secrets.AdminToken, metricsHandler, reloader))
+	}
+
+	addr := fmt.Sprintf("0.0.0.0:%d", cfg.Port)
+
+	http.Run(http.Config{
+		ListenAddr: addr,
+		Handler:    router,
+		TLS:        cfg.TLS,
+		OnStarting: func() {
+			log.Infof("starting friendbot server - %s", app.Version())
+			log.Infof("listening on %s", addr)
+		},
+	})
+}
+
+// flagOrEnv returns the value of the flag with the given name if it was set on
+// the command line, or else the environment variable env if it is set, or else
+// the flag's default.
+func flagOrEnv(cmd *cobra.Command, name, env string) string {
+	flag := cmd.Flags().Lookup(name)
+	if !flag.Changed {
+		if value, ok := os.LookupEnv(env); ok {
+			return value
+		}
+	}
+	return flag.Value.String()
+}
+
+// configPath returns the config file to read, or "" to configure friendbot
+// from the environment alone when neither --conf nor FRIENDBOT_CONF is set and
+// the default config file does not exist.
+func configPath(cmd *cobra.Command) string {
+	path := flagOrEnv(cmd, "conf", "FRIENDBOT_CONF")
+	_, envSet := os.LookupEnv("FRIENDBOT_CONF")
+	if !cmd.Flags().Lookup("conf").Changed && !envSet {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return ""
+		}
+	}
+	return path
+}
+
+// loadConfig loads configuration from the config file, an optional separate
+// secret file, and FRIENDBOT_* environment variables. If cfgPath is empty, no
+// config file is read. If secretPath is provided, it overrides any secret in
+// the config file. Each of signerSecretPaths is a file whose
+// friendbot_signer_secrets are added to those already loaded. Environment
+// variables override settings from any file.
+func loadConfig(cfgPath, secretPath string, signerSecretPaths ...string) (Config, Secrets, error) {
+	var cfgWithSecrets ConfigWithSecrets
+	sources := configSources{}
+	if cfgPath != "" {
+		err := config.Read(cfgPath, &cfgWithSecrets)
+		if err != nil {
+			return Config{}, Secrets{}, errors.Wrap(err, "reading config file")
+		}
+		if err := sources.recordFile(cfgPath); err != nil {
+			return Config{}, Secrets{}, errors.Wrap(err, "reading config file")
+		}
+	}
+
+	// Extract config and secret separately
+	cfg := cfgWithSecrets.Config
+	secrets := Secrets{}
+	if cfgWithSecrets.Secrets != nil {
+		secrets = *cfgWithSecrets.Secrets
+	}
+
+	// If --secret is provided, load the secret from the separate file and override
+	if secretPath != "" {
+		err := config.Read(secretPath, &secrets)
+		if err != nil {
+			return Config{}, Secrets{}, errors.Wrap(err, "reading secret file")
+		}
+		if err := sources.recordFile(secretPath); err != nil {
+			return Config{}, Secrets{}, errors.Wrap(err, "reading secret file")
+		}
+	}
+
+	for _, path := range signerSecretPaths {
+		var signerSecrets signerSecretFile
+		err := config.Read(path, &signerSecrets)
+		if err != nil {
+			return Config{}, Secrets{}, errors.Wrapf(err, "reading signer secret file %s", path)
+		}
+		secrets.FriendbotSignerSecrets = append(secrets.FriendbotSignerSecrets, signerSecrets.FriendbotSignerSecrets...)
+	}
+
+	if err := applyEnv(&cfg, sources); err != nil {
+		return Config{}, Secrets{}, err
+	}
+	if err := applyEnv(&secrets, sources); err != nil {
+		return Config{}, Secrets{}, err
+	}
+
+	for _, required := range []struct {
+		key     string
+		missing bool
+	}{
+		{"port", cfg.Port == 0},
+		{"network_passphrase", cfg.NetworkPassphrase == ""},
+		{"starting_balance", cfg.StartingBalance == ""},
+	} {
+		if required.missing {
+			return Config{}, Secrets{}, errors.Errorf("%s is required: provide it in --conf or set %s", required.key, envName(required.key))
+		}
+	}
+
+	// Validate that we have a secret, or a remote signer holding it
+	if cfg.RemoteSignerURL != "" {
+		if secrets.FriendbotSecret != "" || len(secrets.FriendbotSignerSecrets) > 0 {
+			return Config{}, Secrets{}, errors.New("friendbot_secret and friendbot_signer_secrets must not be set when remote_signer_url is set")
+		}
+		if !strkey.IsValidEd25519PublicKey(cfg.FriendbotAccountID) {
+			return Config{}, Secrets{}, errors.Errorf("friendbot_account_id%s must be a valid account address when remote_signer_url is set",
+				sources.describe("friendbot_account_id"))
+		}
+	} else if secrets.FriendbotSecret == "" {
+		return Config{}, Secrets{}, errors.Errorf("friendbot_secret is required: provide it in --conf, use --secret or set %s", envName("friendbot_secret"))
+	}
+
+	if cfg.SigningThreshold < 0 || cfg.SigningThreshold > 255 {
+		return Config{}, Secrets{}, errors.Errorf("signing_threshold%s must be between 0 and 255", sources.describe("signing_threshold"))
+	}
+
+	switch cfg.IdempotencyStore {
+	case "", "memory":
+	case "file":
+		if cfg.IdempotencyStorePath == "" {
+			return Config{}, Secrets{}, errors.New("idempotency_store_path is required when idempotency_store is \"file\"")
+		}
+	default:
+		return Config{}, Secrets{}, errors.Errorf("invalid idempotency_store %q%s: must be \"memory\" or \"file\"",
+			cfg.IdempotencyStore, sources.describe("idempotency_store"))
+	}
+
+	if cfg.IdempotencyTTLSeconds < 0 || cfg.IdempotencyMaxRecords < 0 {
+		return Config{}, Secrets{}, errors.New("idempotency_ttl_seconds and idempotency_max_records must not be negative")
+	}
+
+	if cfg.AutoscaleMaxMinions != 0 && (cfg.AutoscaleMinMinions < 1 || cfg.AutoscaleMinMinions > cfg.AutoscaleMaxMinions) {
+		return Config{}, Secrets{}, errors.New("autoscale_min_minions must be at least 1 and no more than autoscale_max_minions")
+	}
+
+	switch cfg.MinionStore {
+	case "", "none":
+	case "file":
+		if cfg.MinionStorePath == "" {
+			return Config{}, Secrets{}, errors.Errorf("minion_store is \"file\"%s but minion_store_path is not set",
+				sources.describe("minion_store"))
+		}
+	default:
+		return Config{}, Secrets{}, errors.Errorf("invalid minion_store %q%s: must be \"file\" or \"none\"",
+			cfg.MinionStore, sources.describe("minion_store"))
+	}
+
+	if _, err := internal.ParseMemo(cfg.MemoType, cfg.Memo); err != nil {
+		return Config{}, Secrets{}, errors.Errorf("invalid memo%s: %v", sources.describe("memo"), err)
+	}
+
+	if err := checkCORS(cfg, sources); err != nil {
+		return Config{}, Secrets{}, err
+	}
+
+	switch cfg.HistoryStore {
+	case "", "sqlite", "none":
+	default:
+		return Config{}, Secrets{}, errors.Errorf("invalid history_store %q%s: must be \"sqlite\", the default, or \"none\"",
+			cfg.HistoryStore, sources.describe("history_store"))
+	}
+	if cfg.HistoryRetentionDays < 0 {
+		return Config{}, Secrets{}, errors.Errorf("history_retention_days%s must not be negative", sources.describe("history_retention_days"))
+	}
+
+	return cfg, secrets, nil
+}
+
+func initRouter(cfg Config, fb *internal.Bot) *chi.Mux {
+	mux := newMux(cfg)
+	handler := internal.NewFriendbotHandler(fb)
+	handler.IdempotencyStore = newIdempotencyStore(cfg)
+	mux.Get("/", handler.Handle)
+	mux.Post("/", handler.Handle)
+	mux.Post("/v2/fund", handler.HandleJSON)
+	mux.Get("/openapi.json", serveOpenAPI(openAPIDocument))
+	mux.NotFound(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		problem.Render(r.Context(), w, problem.NotFound)
+	}))
+
+	return mux
+}
+
+func newMux(cfg Config) *chi.Mux {
+	mux := chi.NewRouter()
+	// first apply XFFMiddleware so we can have the real ip in the subsequent
+	// middlewares
+	mux.Use(http.XFFMiddleware(http.XFFMiddlewareConfig{BehindCloudflare: cfg.UseCloudflareIP}))
+	mux.Use(http.NewMux(log.DefaultLogger).Middlewares()...)
+	mux.Use(newCORS(cfg).Handler)
+	mux.Use(otelchi.Middleware(serviceName, otelchi.WithChiRoutes(mux)))
+
+	return mux
+}
+
+// newIdempotencyStore returns the store configured by idempotency_store,
+// which defaults to an in-memory store.
+func newIdempotencyStore(cfg Config) internal.IdempotencyStore {
+	ttl := time.Duration(cfg.IdempotencyTTLSeconds) * time.Second
+	if ttl == 0 {
+		ttl = time.Hour
+	}
+	maxRecords := cfg.IdempotencyMaxRecords
+	if maxRecords == 0 {
+		maxRecords = 10000
+	}
+	if cfg.IdempotencyStore == "file" {
+		return internal.NewFileIdempotencyStore(cfg.IdempotencyStorePath, ttl, maxRecords)
+	}
+	return internal.NewMemoryIdempotencyStore(ttl, maxRecords)
+}
+
+// initAdminRouter returns the router for operator endpoints, which is served
+// on admin_port and must not be exposed publicly. The endpoint reloading the
+// config is only served if reloader is set.
+func initAdminRouter(fb *internal.Bot, adminToken string, metricsHandler stdhttp.Handler, reloader *configReloader) *chi.Mux {
+	mux := chi.NewRouter()
+	mux.Use(http.NewMux(log.DefaultLogger).Middlewares()...)
+	mux.Method(stdhttp.MethodGet, "/metrics", metricsHandler)
+	mux.Get("/openapi.json", serveOpenAPI(adminOpenAPIDocument))
+	adminHandler := internal.NewAdminHandler(fb)
+	if adminToken != "" {
+		mux.Group(func(mux chi.Router) {
+			mux.Use(requireBearerToken(adminToken))
+			mux.Get("/budget", adminHandler.Budget)
+			mux.Get("/minions", adminHandler.ListMinions)
+			mux.Post("/minions", adminHandler.AddMinions)
+			mux.Post("/minions/{address}/quarantine", adminHandler.QuarantineMinion)
+			mux.Post("/minions/{address}/release", adminHandler.ReleaseMinion)
+			mux.Post("/minions/{address}/refresh_sequence", adminHandler.RefreshMinionSequence)
+			mux.Get("/funding", adminHandler.Funding)
+			mux.Post("/funding/pause", adminHandler.PauseFunding)
+			mux.Post("/funding/resume", adminHandler.ResumeFunding)
+			if reloader != nil {
+				mux.Post("/config/reload", reloader.HandleReload)
+			}
+			if fb.History != nil {
+				historyHandler := internal.NewHistoryHandler(fb.History)
+				mux.Get("/fundings", historyHandler.ListFundings)
+				mux.Get("/fundings/{hash}", historyHandler.GetFunding)
+			}
+		})
+	} else {
+		log.Warn("admin_token is not set, so the admin endpoints other than /metrics are disabled")
+	}
+	mux.NotFound(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		problem.Render(r.Context(), w, problem.NotFound)
+	}))
+
+	return mux
+}
+
+// requireBearerToken rejects requests that do not carry token in their
+// Authorization header.
+func requireBearerToken(token string) func(stdhttp.Handler) stdhttp.Handler {
+	expected := []byte("Bearer " + token)
+	return func(next stdhttp.Handler) stdhttp.Handler {
+		return stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				problem.Render(r.Context(), w, unauthorizedProblem)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func runAdminServer(cfg Config, handler stdhttp.Handler) {
+	addr := fmt.Sprintf("0.0.0.0:%d", cfg.AdminPort)
+	log.Infof("admin server listening on %s", addr)
+	server := &stdhttp.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	if err := server.ListenAndServe(); err != nil {
+		log.Error(errors.Wrap(err, "admin server failed"))
+	}
+}
+
+func registerProblems() {
+	problem.RegisterHost("https://stellar.org/friendbot-errors/")
+	problem.RegisterError(sql.ErrNoRows, problem.NotFound)
+
+	accountExistsProblem := problem.BadRequest
+	accountExistsProblem.Detail = internal.ErrAccountExists.Error()
+	accountExistsProblem.Extras = map[string]interface{}{"already_funded": true, "retryable": false}
+	problem.RegisterError(internal.ErrAccountExists, accountExistsProblem)
+
+	accountFundedProblem := problem.BadRequest
+	accountFundedProblem.Detail = internal.ErrAccountFunded.Error()
+	accountFundedProblem.Extras = map[string]interface{}{"already_funded": true, "retryable": false}
+	problem.RegisterError(internal.ErrAccountFunded, accountFundedProblem)
+
+	idempotencyKeyConflictProblem := problem.P{
+		Type:   "idempotency_key_conflict",
+		Title:  "Conflict",
+		Status: stdhttp.StatusConflict,
+		Detail: "The Idempotency-Key header was already used for a request to fund a different address.",
+	}
+	problem.RegisterError(internal.ErrIdempotencyKeyConflict, idempotencyKeyConflictProblem)
+
+	idempotencyKeyVersionConflictProblem := idempotencyKeyConflictProblem
+	idempotencyKeyVersionConflictProblem.Detail = "The Idempotency-Key header was already used for a request for a different version of the response."
+	problem.RegisterError(internal.ErrIdempotencyKeyVersionConflict, idempotencyKeyVersionConflictProblem)
+
+	queueFullProblem := serviceUnavailableProblem
+	queueFullProblem.Type = "queue_full"
+	queueFullProblem.Detail = "Friendbot is receiving more requests than it can currently process. Please try again later."
+	problem.RegisterError(internal.ErrQueueFull, queueFullProblem)
+
+	queueTimeoutProblem := serviceUnavailableProblem
+	queueTimeoutProblem.Type = "queue_timeout"
+	queueTimeoutProblem.Detail = "Friendbot could not start processing the request in time. Please try again later."
+	problem.RegisterError(internal.ErrQueueTimeout, queueTimeoutProblem)
+
+	fundingPausedProblem := serviceUnavailableProblem
+	fundingPausedProblem.Type = "funding_paused"
+	fundingPausedProblem.Detail = "Funding has been paused by the friendbot operators. Please try again later."
+	problem.RegisterError(internal.ErrFundingPaused, fundingPausedProblem)
+
+	noAvailableMinionsProblem := serviceUnavailableProblem
+	noAvailableMinionsProblem.Type = "no_available_minions"
+	noAvailableMinionsProblem.Detail = "Friendbot has no minions available to process the request. Please try again later."
+	problem.RegisterError(internal.ErrNoAvailableMinions, noAvailableMinionsProblem)
+
+	problem.RegisterError(internal.ErrMinionNotFound, problem.NotFound)
+
+	budgetExhaustedProblem := serviceUnavailableProblem
+	budgetExhaustedProblem.Type = "budget_exhausted"
+	budgetExhaustedProblem.Detail = "Friendbot has reached its spending limit for now. Please try again later."
+	problem.RegisterError(internal.ErrBudgetExhausted, budgetExhaustedProblem)
+
+	upstreamUnavailableProblem := serviceUnavailableProblem
+	upstreamUnavailableProblem.Type = "upstream_unavailable"
+	upstreamUnavailableProblem.Detail = "Friendbot could not reach the Stellar network. Please try again later."
+	problem.RegisterError(internal.ErrUpstreamUnavailable, upstreamUnavailableProblem)
+
+	upstreamTimeoutProblem := problem.P{
+		Type:   internal.ProblemTypeUpstreamTimeout,
+		Title:  "Gateway Timeout",
+		Status: stdhttp.StatusGatewayTimeout,
+		Detail: "The Stellar network did not respond in time. The address may still be funded, so check it before trying again.",
+		Extras: map[string]interface{}{"retryable": true},
+	}
+	problem.RegisterError(internal.ErrUpstreamTimeout, upstreamTimeoutProblem)
+
+	insufficientMinionBalanceProblem := serviceUnavailableProblem
+	insufficientMinionBalanceProblem.Type = "insufficient_minion_balance"
+	insufficientMinionBalanceProblem.Detail = "Friendbot does not have enough XLM to fund the address until it is refilled. Please try again later."
+	problem.RegisterError(internal.ErrInsufficientMinionBalance, insufficientMinionBalanceProblem)
+
+	contractFundingDisabledProblem := problem.P{
+		Type:   "contract_funding_disabled",
+		Title:  "Contract Funding Disabled",
+		Status: stdhttp.StatusBadRequest,
+		Detail: "This friendbot does not fund contract (C) addresses.",
+		Extras: map[string]interface{}{
+			"invalid_field": "addr",
+			"reason":        internal.ErrContractFundingDisabled.Error(),
+			"retryable":     false,
+		},
+	}
+	problem.RegisterError(internal.ErrContractFundingDisabled, contractFundingDisabledProblem)
+}
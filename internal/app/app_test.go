@@ -0,0 +1,379 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	t.Run("loads secret from conf file (backwards compatible)", func(t *testing.T) {
+		confFile := filepath.Join(tmpDir, "friendbot.cfg")
+		err := os.WriteFile(confFile, []byte(`
+port = 8000
+friendbot_secret = "SCZANGBA5YHTNYVVV3C7CAZMTQDBJHJG6C34CIRY52VDRRW3DPQUTZY2"
+network_passphrase = "Test SDF Network ; September 2015"
+starting_balance = "10000.00"
+`), 0600)
+		require.NoError(t, err)
+
+		cfg, secrets, err := loadConfig(confFile, "")
+		require.NoError(t, err)
+
+		assert.Equal(t, 8000, cfg.Port)
+		assert.Equal(t, "SCZANGBA5YHTNYVVV3C7CAZMTQDBJHJG6C34CIRY52VDRRW3DPQUTZY2", secrets.FriendbotSecret)
+		assert.Equal(t, "Test SDF Network ; September 2015", cfg.NetworkPassphrase)
+		assert.Equal(t, "10000.00", cfg.StartingBalance)
+	})
+
+	t.Run("loads secret from separate secret file", func(t *testing.T) {
+		confFile := filepath.Join(tmpDir, "config.cfg")
+		err := os.WriteFile(confFile, []byte(`
+port = 8001
+network_passphrase = "Test SDF Network ; September 2015"
+starting_balance = "5000.00"
+`), 0600)
+		require.NoError(t, err)
+
+		secretFile := filepath.Join(tmpDir, "secret.cfg")
+		err = os.WriteFile(secretFile, []byte(`
+friendbot_secret = "SBKGCMBY56GZQ4ZTQ4BXDPXG3MFAMZ6FMZQHGC3APMZ6AXRY5VZL7FRA"
+`), 0600)
+		require.NoError(t, err)
+
+		cfg, secrets, err := loadConfig(confFile, secretFile)
+		require.NoError(t, err)
+
+		assert.Equal(t, 8001, cfg.Port)
+		assert.Equal(t, "SBKGCMBY56GZQ4ZTQ4BXDPXG3MFAMZ6FMZQHGC3APMZ6AXRY5VZL7FRA", secrets.FriendbotSecret)
+		assert.Equal(t, "Test SDF Network ; September 2015", cfg.NetworkPassphrase)
+		assert.Equal(t, "5000.00", cfg.StartingBalance)
+	})
+
+	t.Run("secret file overrides secret in conf file", func(t *testing.T) {
+		confFile := filepath.Join(tmpDir, "config_with_secret.cfg")
+		err := os.WriteFile(confFile, []byte(`
+port = 8002
+friendbot_secret = "SCZANGBA5YHTNYVVV3C7CAZMTQDBJHJG6C34CIRY52VDRRW3DPQUTZY2"
+network_passphrase = "Test SDF Network ; September 2015"
+starting_balance = "10000.00"
+`), 0600)
+		require.NoError(t, err)
+
+		secretFile := filepath.Join(tmpDir, "override_secret.cfg")
+		err = os.WriteFile(secretFile, []byte(`
+friendbot_secret = "SBKGCMBY56GZQ4ZTQ4BXDPXG3MFAMZ6FMZQHGC3APMZ6AXRY5VZL7FRA"
+`), 0600)
+		require.NoError(t, err)
+
+		_, secrets, err := loadConfig(confFile, secretFile)
+		require.NoError(t, err)
+
+		// Secret should come from the secret file, not the conf file
+		assert.Equal(t, "SBKGCMBY56GZQ4ZTQ4BXDPXG3MFAMZ6FMZQHGC3APMZ6AXRY5VZL7FRA", secrets.FriendbotSecret)
+	})
+
+	t.Run("error when no secret provided", func(t *testing.T) {
+		confFile := filepath.Join(tmpDir, "no_secret.cfg")
+		err := os.WriteFile(confFile, []byte(`
+port = 8003
+network_passphrase = "Test SDF Network ; September 2015"
+starting_balance = "10000.00"
+`), 0600)
+		require.NoError(t, err)
+
+		_, _, err = loadConfig(confFile, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "friendbot_secret is required")
+	})
+
+	t.Run("error when config file not found", func(t *testing.T) {
+		_, _, err := loadConfig("/nonexistent/path.cfg", "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "reading config file")
+	})
+
+	t.Run("error when secret file not found", func(t *testing.T) {
+		confFile := filepath.Join(tmpDir, "valid.cfg")
+		err := os.WriteFile(confFile, []byte(`
+port = 8004
+network_passphrase = "Test SDF Network ; September 2015"
+starting_balance = "10000.00"
+`), 0600)
+		require.NoError(t, err)
+
+		_, _, err = loadConfig(confFile, "/nonexistent/secret.cfg")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "reading secret file")
+	})
+}
+
+func TestLoadConfig_IdempotencyStore(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	t.Run("error when file store has no path", func(t *testing.T) {
+		confFile := filepath.Join(tmpDir, "file_store_no_path.cfg")
+		err := os.WriteFile(confFile, []byte(`
+port = 8000
+friendbot_secret = "SCZANGBA5YHTNYVVV3C7CAZMTQDBJHJG6C34CIRY52VDRRW3DPQUTZY2"
+network_passphrase = "Test SDF Network ; September 2015"
+starting_balance = "10000.00"
+idempotency_store = "file"
+`), 0600)
+		require.NoError(t, err)
+
+		_, _, err = loadConfig(confFile, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "idempotency_store_path is required")
+	})
+
+	t.Run("error when store type is unknown", func(t *testing.T) {
+		confFile := filepath.Join(tmpDir, "unknown_store.cfg")
+		err := os.WriteFile(confFile, []byte(`
+port = 8000
+friendbot_secret = "SCZANGBA5YHTNYVVV3C7CAZMTQDBJHJG6C34CIRY52VDRRW3DPQUTZY2"
+network_passphrase = "Test SDF Network ; September 2015"
+starting_balance = "10000.00"
+idempotency_store = "redis"
+`), 0600)
+		require.NoError(t, err)
+
+		_, _, err = loadConfig(confFile, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid idempotency_store "redis"`)
+	})
+}
+
+func TestLoadConfig_CORS(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, tc := range []struct {
+		name     string
+		settings string
+		err      string
+	}{
+		{name: "two wildcards", settings: `cors_allowed_origins = ["https://*.*.stellar.org"]`, err: `invalid cors_allowed_origins entry "https://*.*.stellar.org"`},
+		{name: "invalid pattern", settings: `cors_allowed_origin_patterns = ["^https://(.*$"]`, err: `invalid cors_allowed_origin_patterns entry "^https://(.*$"`},
+		{name: "negative max age", settings: `cors_max_age_seconds = -1`, err: "must not be negative"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			confFile := filepath.Join(tmpDir, "cors.cfg")
+			err := os.WriteFile(confFile, []byte(`
+port = 8000
+friendbot_secret = "SCZANGBA5YHTNYVVV3C7CAZMTQDBJHJG6C34CIRY52VDRRW3DPQUTZY2"
+network_passphrase = "Test SDF Network ; September 2015"
+starting_balance = "10000.00"
+`+tc.settings+"\n"), 0600)
+			require.NoError(t, err)
+
+			_, _, err = loadConfig(confFile, "")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+func TestLoadConfig_Memo(t *testing.T) {
+	confFile := filepath.Join(t.TempDir(), "memo.cfg")
+	err := os.WriteFile(confFile, []byte(`
+port = 8000
+friendbot_secret = "SCZANGBA5YHTNYVVV3C7CAZMTQDBJHJG6C34CIRY52VDRRW3DPQUTZY2"
+network_passphrase = "Test SDF Network ; September 2015"
+starting_balance = "10000.00"
+memo = "quickstart"
+memo_type = "id"
+`), 0600)
+	require.NoError(t, err)
+
+	_, _, err = loadConfig(confFile, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid memo (from "+confFile+"): id memos must be an unsigned 64-bit integer")
+}
+
+func TestLoadConfig_SignerSecrets(t *testing.T) {
+	tmpDir := t.TempDir()
+	confFile := filepath.Join(tmpDir, "friendbot.cfg")
+	err := os.WriteFile(confFile, []byte(`
+port = 8000
+friendbot_secret = "SCZANGBA5YHTNYVVV3C7CAZMTQDBJHJG6C34CIRY52VDRRW3DPQUTZY2"
+friendbot_signer_secrets = ["SDLNA2YUQSFIWVEB57M6D3OOCJHFVCVQZJ33LPA656KJESVRK5DQUZOH"]
+network_passphrase = "Test SDF Network ; September 2015"
+starting_balance = "10000.00"
+signing_threshold = 3
+`), 0600)
+	require.NoError(t, err)
+
+	t.Run("signer secret files add to the config", func(t *testing.T) {
+		signerFile := filepath.Join(tmpDir, "signer.cfg")
+		err := os.WriteFile(signerFile, []byte(`
+friendbot_signer_secrets = ["SDTNSEERJPJFUE2LSDNYBFHYGVTPIWY7TU2IOJZQQGLWO2THTGB7NU5A"]
+`), 0600)
+		require.NoError(t, err)
+
+		cfg, secrets, err := loadConfig(confFile, "", signerFile)
+		require.NoError(t, err)
+		assert.Equal(t, 3, cfg.SigningThreshold)
+		assert.Equal(t, []string{
+			"SDLNA2YUQSFIWVEB57M6D3OOCJHFVCVQZJ33LPA656KJESVRK5DQUZOH",
+			"SDTNSEERJPJFUE2LSDNYBFHYGVTPIWY7TU2IOJZQQGLWO2THTGB7NU5A",
+		}, secrets.FriendbotSignerSecrets)
+	})
+
+	t.Run("error when signer secret file is missing", func(t *testing.T) {
+		_, _, err := loadConfig(confFile, "", "/nonexistent/signer.cfg")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "reading signer secret file /nonexistent/signer.cfg")
+	})
+}
+
+func TestLoadConfig_RemoteSigner(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	t.Run("remote signer without friendbot_secret", func(t *testing.T) {
+		confFile := filepath.Join(tmpDir, "remote_signer.cfg")
+		err := os.WriteFile(confFile, []byte(`
+port = 8000
+network_passphrase = "Test SDF Network ; September 2015"
+starting_balance = "10000.00"
+friendbot_account_id = "GD25B4QI6KWVDWXDW25CIM7EKR6A6PBSWE2RCNSAC4NJQDQJXZJYMMKR"
+remote_signer_url = "http://localhost:8001"
+remote_signer_token = "token"
+`), 0600)
+		require.NoError(t, err)
+
+		cfg, secrets, err := loadConfig(confFile, "")
+		require.NoError(t, err)
+		assert.Equal(t, "http://localhost:8001", cfg.RemoteSignerURL)
+		assert.Equal(t, "token", secrets.RemoteSignerToken)
+		assert.Empty(t, secrets.FriendbotSecret)
+	})
+
+	t.Run("error when remote signer is used with friendbot_secret", func(t *testing.T) {
+		confFile := filepath.Join(tmpDir, "remote_signer_with_secret.cfg")
+		err := os.WriteFile(confFile, []byte(`
+port = 8000
+friendbot_secret = "SCZANGBA5YHTNYVVV3C7CAZMTQDBJHJG6C34CIRY52VDRRW3DPQUTZY2"
+network_passphrase = "Test SDF Network ; September 2015"
+starting_balance = "10000.00"
+friendbot_account_id = "GD25B4QI6KWVDWXDW25CIM7EKR6A6PBSWE2RCNSAC4NJQDQJXZJYMMKR"
+remote_signer_url = "http://localhost:8001"
+`), 0600)
+		require.NoError(t, err)
+
+		_, _, err = loadConfig(confFile, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "must not be set when remote_signer_url is set")
+	})
+
+	t.Run("error when remote signer has no account", func(t *testing.T) {
+		confFile := filepath.Join(tmpDir, "remote_signer_no_account.cfg")
+		err := os.WriteFile(confFile, []byte(`
+port = 8000
+network_passphrase = "Test SDF Network ; September 2015"
+starting_balance = "10000.00"
+remote_signer_url = "http://localhost:8001"
+`), 0600)
+		require.NoError(t, err)
+
+		_, _, err = loadConfig(confFile, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "friendbot_account_id must be a valid account address")
+	})
+}
+
+func TestLoadConfig_Env(t *testing.T) {
+	tmpDir := t.TempDir()
+	confFile := filepath.Join(tmpDir, "friendbot.cfg")
+	err := os.WriteFile(confFile, []byte(`
+port = 8000
+friendbot_secret = "SCZANGBA5YHTNYVVV3C7CAZMTQDBJHJG6C34CIRY52VDRRW3DPQUTZY2"
+network_passphrase = "Test SDF Network ; September 2015"
+starting_balance = "10000.00"
+minion_store = "file"
+minion_store_path = "minions.json"
+`), 0600)
+	require.NoError(t, err)
+	secretFile := filepath.Join(tmpDir, "secret.cfg")
+	err = os.WriteFile(secretFile, []byte(`
+friendbot_secret = "SBKGCMBY56GZQ4ZTQ4BXDPXG3MFAMZ6FMZQHGC3APMZ6AXRY5VZL7FRA"
+`), 0600)
+	require.NoError(t, err)
+
+	t.Run("environment overrides config and secret files", func(t *testing.T) {
+		t.Setenv("FRIENDBOT_PORT", "9000")
+		t.Setenv("FRIENDBOT_RPC_URL", "http://localhost:8000/rpc")
+		t.Setenv("FRIENDBOT_FUND_CONTRACT_ADDRESSES", "true")
+		t.Setenv("FRIENDBOT_BASE_FEE", "200")
+		t.Setenv("FRIENDBOT_SECRET", "SDLNA2YUQSFIWVEB57M6D3OOCJHFVCVQZJ33LPA656KJESVRK5DQUZOH")
+		t.Setenv("FRIENDBOT_SIGNER_SECRETS", "SDTNSEERJPJFUE2LSDNYBFHYGVTPIWY7TU2IOJZQQGLWO2THTGB7NU5A, SCZANGBA5YHTNYVVV3C7CAZMTQDBJHJG6C34CIRY52VDRRW3DPQUTZY2")
+		t.Setenv("FRIENDBOT_TLS_CERTIFICATE_FILE", "cert.pem")
+		t.Setenv("FRIENDBOT_TLS_PRIVATE_KEY_FILE", "key.pem")
+
+		cfg, secrets, err := loadConfig(confFile, secretFile)
+		require.NoError(t, err)
+		assert.Equal(t, 9000, cfg.Port)
+		assert.Equal(t, "http://localhost:8000/rpc", cfg.RPCURL)
+		assert.True(t, cfg.FundContractAddresses)
+		assert.Equal(t, int64(200), cfg.BaseFee)
+		assert.Equal(t, "10000.00", cfg.StartingBalance)
+		assert.Equal(t, "SDLNA2YUQSFIWVEB57M6D3OOCJHFVCVQZJ33LPA656KJESVRK5DQUZOH", secrets.FriendbotSecret)
+		assert.Equal(t, []string{
+			"SDTNSEERJPJFUE2LSDNYBFHYGVTPIWY7TU2IOJZQQGLWO2THTGB7NU5A",
+			"SCZANGBA5YHTNYVVV3C7CAZMTQDBJHJG6C34CIRY52VDRRW3DPQUTZY2",
+		}, secrets.FriendbotSignerSecrets)
+		require.NotNil(t, cfg.TLS)
+		assert.Equal(t, "cert.pem", cfg.TLS.CertificateFile)
+		assert.Equal(t, "key.pem", cfg.TLS.PrivateKeyFile)
+	})
+
+	t.Run("environment alone", func(t *testing.T) {
+		t.Setenv("FRIENDBOT_PORT", "8000")
+		t.Setenv("FRIENDBOT_NETWORK_PASSPHRASE", "Test SDF Network ; September 2015")
+		t.Setenv("FRIENDBOT_STARTING_BALANCE", "10000.00")
+		t.Setenv("FRIENDBOT_SECRET", "SDLNA2YUQSFIWVEB57M6D3OOCJHFVCVQZJ33LPA656KJESVRK5DQUZOH")
+
+		cfg, secrets, err := loadConfig("", "")
+		require.NoError(t, err)
+		assert.Equal(t, 8000, cfg.Port)
+		assert.Nil(t, cfg.TLS)
+		assert.Equal(t, "SDLNA2YUQSFIWVEB57M6D3OOCJHFVCVQZJ33LPA656KJESVRK5DQUZOH", secrets.FriendbotSecret)
+	})
+
+	t.Run("error when required setting is missing", func(t *testing.T) {
+		t.Setenv("FRIENDBOT_PORT", "8000")
+
+		_, _, err := loadConfig("", "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "network_passphrase is required: provide it in --conf or set FRIENDBOT_NETWORK_PASSPHRASE")
+	})
+
+	t.Run("error names the invalid environment variable", func(t *testing.T) {
+		t.Setenv("FRIENDBOT_QUEUE_MAX_DEPTH", "lots")
+
+		_, _, err := loadConfig(confFile, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid queue_max_depth from environment variable FRIENDBOT_QUEUE_MAX_DEPTH: "lots" is not an integer`)
+	})
+
+	t.Run("error names the source of an invalid value", func(t *testing.T) {
+		_, _, err := loadConfig(confFile, "")
+		require.NoError(t, err)
+
+		t.Setenv("FRIENDBOT_MINION_STORE", "redis")
+		_, _, err = loadConfig(confFile, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid minion_store "redis" (from environment variable FRIENDBOT_MINION_STORE)`)
+	})
+
+	t.Run("file minion store requires a path", func(t *testing.T) {
+		t.Setenv("FRIENDBOT_MINION_STORE_PATH", "")
+		_, _, err := loadConfig(confFile, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "minion_store_path is not set")
+	})
+}
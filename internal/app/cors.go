@@ -0,0 +1,105 @@
+package app
+
+import (
+	stdhttp "net/http"
+	"regexp"
+	"strings"
+
+	"github.com/rs/cors"
+	"github.com/stellar/go-stellar-sdk/support/errors"
+)
+
+// The CORS settings used when they are not configured. They allow websites on
+// any origin, such as browser wallets and tutorials, to call friendbot, which
+// is what a test network faucet is for.
+var (
+	defaultCORSAllowedOrigins = []string{"*"}
+	defaultCORSAllowedMethods = []string{
+		stdhttp.MethodGet,
+		stdhttp.MethodPut,
+		stdhttp.MethodPost,
+		stdhttp.MethodPatch,
+		stdhttp.MethodDelete,
+		stdhttp.MethodHead,
+		stdhttp.MethodOptions,
+	}
+	defaultCORSAllowedHeaders = []string{"*"}
+)
+
+// checkCORS returns an error if the cors_* settings of cfg are invalid.
+func checkCORS(cfg Config, sources configSources) error {
+	for _, origin := range cfg.CORSAllowedOrigins {
+		if strings.Count(origin, "*") > 1 {
+			return errors.Errorf("invalid cors_allowed_origins entry %q%s: must have at most one *",
+				origin, sources.describe("cors_allowed_origins"))
+		}
+	}
+	for _, pattern := range cfg.CORSAllowedOriginPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return errors.Errorf("invalid cors_allowed_origin_patterns entry %q%s: %v",
+				pattern, sources.describe("cors_allowed_origin_patterns"), err)
+		}
+	}
+	if cfg.CORSMaxAgeSeconds < 0 {
+		return errors.Errorf("cors_max_age_seconds%s must not be negative", sources.describe("cors_max_age_seconds"))
+	}
+	return nil
+}
+
+// newCORS returns the middleware answering cross-origin requests as
+// configured by the cors_* settings of cfg, which must have been checked with
+// checkCORS. Preflight requests are answered by the middleware, and never
+// reach the handlers of the router.
+func newCORS(cfg Config) *cors.Cors {
+	options := cors.Options{
+		AllowedOrigins: defaultCORSAllowedOrigins,
+		AllowedMethods: defaultCORSAllowedMethods,
+		AllowedHeaders: defaultCORSAllowedHeaders,
+		MaxAge:         cfg.CORSMaxAgeSeconds,
+	}
+	if len(cfg.CORSAllowedOrigins) > 0 {
+		options.AllowedOrigins = cfg.CORSAllowedOrigins
+	}
+	if len(cfg.CORSAllowedMethods) > 0 {
+		options.AllowedMethods = cfg.CORSAllowedMethods
+	}
+	if len(cfg.CORSAllowedHeaders) > 0 {
+		options.AllowedHeaders = cfg.CORSAllowedHeaders
+	}
+
+	if len(cfg.CORSAllowedOriginPatterns) > 0 {
+		// AllowedOrigins is ignored once AllowOriginFunc is set, so the
+		// origins are matched here along with the patterns. Only the
+		// configured origins are, as the default allows every origin.
+		origins := cfg.CORSAllowedOrigins
+		var patterns []*regexp.Regexp
+		for _, pattern := range cfg.CORSAllowedOriginPatterns {
+			patterns = append(patterns, regexp.MustCompile(pattern))
+		}
+		options.AllowOriginFunc = func(origin string) bool {
+			for _, allowed := range origins {
+				if matchOrigin(allowed, origin) {
+					return true
+				}
+			}
+			for _, pattern := range patterns {
+				if pattern.MatchString(origin) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return cors.New(options)
+}
+
+// matchOrigin returns true if origin is allowed by allowed, an entry of
+// cors_allowed_origins that may contain a * matching any characters.
+func matchOrigin(allowed, origin string) bool {
+	allowed, origin = strings.ToLower(allowed), strings.ToLower(origin)
+	prefix, suffix, wildcard := strings.Cut(allowed, "*")
+	if !wildcard {
+		return origin == allowed
+	}
+	return len(origin) >= len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix)
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stellar/friendbot/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS_Defaults(t *testing.T) {
+	fb := setupBot(t)
+	numTxSubmits := 0
+	fb.Minions[0].SubmitTransaction = func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, txHash [32]byte, tx string) (*internal.TransactionResult, error) {
+		numTxSubmits++
+		return nil, nil
+	}
+	registerProblems()
+	router := validateResponses(t, initRouter(Config{}, fb))
+
+	req := httptest.NewRequest("OPTIONS", "/?addr=GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z", nil)
+	req.Header.Set("Origin", "https://lab.stellar.org")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "Content-Type, Idempotency-Key")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "POST", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type, Idempotency-Key", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Empty(t, w.Header().Get("Access-Control-Max-Age"))
+
+	// OPTIONS requests that are not preflight requests are not handled
+	// either.
+	req = httptest.NewRequest("OPTIONS", "/?addr=GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, 0, numTxSubmits)
+
+	req = httptest.NewRequest("GET", "/openapi.json", nil)
+	req.Header.Set("Origin", "https://lab.stellar.org")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORS_OriginPatternsOnly(t *testing.T) {
+	cfg := Config{CORSAllowedOriginPatterns: []string{`^https://[a-z0-9-]+\.example\.com$`}}
+	router := validateResponses(t, initRouter(cfg, setupBot(t)))
+
+	for _, tc := range []struct {
+		origin  string
+		allowed bool
+	}{
+		{origin: "https://docs-preview.example.com", allowed: true},
+		{origin: "https://lab.stellar.org", allowed: false},
+	} {
+		t.Run(tc.origin, func(t *testing.T) {
+			req := httptest.NewRequest("OPTIONS", "/", nil)
+			req.Header.Set("Origin", tc.origin)
+			req.Header.Set("Access-Control-Request-Method", "POST")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNoContent, w.Code)
+			if tc.allowed {
+				assert.Equal(t, tc.origin, w.Header().Get("Access-Control-Allow-Origin"))
+			} else {
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+			}
+		})
+	}
+}
+
+func TestCORS_Configured(t *testing.T) {
+	cfg := Config{
+		CORSAllowedOrigins:        []string{"https://*.stellar.org", "http://localhost:3000"},
+		CORSAllowedOriginPatterns: []string{`^https://[a-z0-9-]+\.example\.com$`},
+		CORSAllowedMethods:        []string{"GET", "POST"},
+		CORSAllowedHeaders:        []string{"Content-Type"},
+		CORSMaxAgeSeconds:         600,
+	}
+	router := validateResponses(t, initRouter(cfg, setupBot(t)))
+
+	for _, tc := range []struct {
+		origin  string
+		method  string
+		allowed bool
+	}{
+		{origin: "https://lab.stellar.org", method: "POST", allowed: true},
+		{origin: "http://localhost:3000", method: "GET", allowed: true},
+		{origin: "https://docs-preview.example.com", method: "POST", allowed: true},
+		{origin: "https://stellar.org.evil.com", method: "POST", allowed: false},
+		{origin: "https://a.b.example.com", method: "POST", allowed: false},
+		{origin: "https://lab.stellar.org", method: "DELETE", allowed: false},
+	} {
+		t.Run(tc.origin+" "+tc.method, func(t *testing.T) {
+			req := httptest.NewRequest("OPTIONS", "/", nil)
+			req.Header.Set("Origin", tc.origin)
+			req.Header.Set("Access-Control-Request-Method", tc.method)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNoContent, w.Code)
+			if tc.allowed {
+				assert.Equal(t, tc.origin, w.Header().Get("Access-Control-Allow-Origin"))
+				assert.Equal(t, tc.method, w.Header().Get("Access-Control-Allow-Methods"))
+				assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+			} else {
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stellar/go-stellar-sdk/support/errors"
+)
+
+// envPrefix is the prefix of the environment variables overriding config and
+// secret settings.
+const envPrefix = "FRIENDBOT_"
+
+// envName returns the environment variable overriding the setting with the
+// given key. Keys already starting with friendbot_ do not repeat it, so
+// friendbot_secret is set by FRIENDBOT_SECRET.
+func envName(key string) string {
+	key = strings.TrimPrefix(key, "friendbot_")
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// configSources records where each setting was loaded from, so that errors
+// about invalid values can name it.
+type configSources map[string]string
+
+// recordFile records the settings set in the TOML file at path.
+func (s configSources) recordFile(path string) error {
+	var raw map[string]any
+	if _, err := toml.DecodeFile(path, &raw); err != nil {
+		return err
+	}
+	for key, value := range raw {
+		if table, ok := value.(map[string]any); ok {
+			for nested := range table {
+				s[strings.ToLower(key)+"_"+strings.ReplaceAll(nested, "-", "_")] = path
+			}
+			continue
+		}
+		s[key] = path
+	}
+	return nil
+}
+
+// describe returns " (from <source>)" for a setting with a known source, for
+// appending to error messages.
+func (s configSources) describe(key string) string {
+	if source, ok := s[key]; ok {
+		return " (from " + source + ")"
+	}
+	return ""
+}
+
+// applyEnv overrides the fields of dest, a pointer to a struct with toml tags,
+// with the environment variables set for them. Nested structs without a toml
+// tag, such as TLS, use the lowercased field name as a key prefix.
+func applyEnv(dest any, sources configSources) error {
+	_, err := applyEnvToStruct(reflect.ValueOf(dest).Elem(), "", sources)
+	return err
+}
+
+func applyEnvToStruct(v reflect.Value, prefix string, sources configSources) (bool, error) {
+	applied := false
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		key := field.Tag.Get("toml")
+		if key == "" {
+			if field.Type.Kind() != reflect.Ptr || field.Type.Elem().Kind() != reflect.Struct {
+				continue
+			}
+			// Only allocate the nested struct if one of its settings is set.
+			nested := reflect.New(field.Type.Elem())
+			if !v.Field(i).IsNil() {
+				nested = v.Field(i)
+			}
+			ok, err := applyEnvToStruct(nested.Elem(), prefix+strings.ToLower(field.Name)+"_", sources)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				v.Field(i).Set(nested)
+				applied = true
+			}
+			continue
+		}
+
+		key = prefix + strings.ReplaceAll(key, "-", "_")
+		name := envName(key)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setFromEnv(v.Field(i), value); err != nil {
+			return false, errors.Errorf("invalid %s from environment variable %s: %v", key, name, err)
+		}
+		sources[key] = "environment variable " + name
+		applied = true
+	}
+	return applied, nil
+}
+
+// setFromEnv parses value into field according to the field's type. Lists are
+// comma separated.
+func setFromEnv(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, field.Type().Bits())
+		if err != nil {
+			return errors.Errorf("%q is not an integer", value)
+		}
+		field.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return errors.Errorf("%q is not a boolean", value)
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return errors.Errorf("unsupported type %s", field.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return errors.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
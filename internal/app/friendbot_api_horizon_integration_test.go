@@ -1,4 +1,4 @@
-package main
+package app
 
 import (
 	"encoding/json"
@@ -65,7 +65,7 @@ func setupHorizonIntegration(t *testing.T) (http.Handler, horizonclient.ClientIn
 		},
 		Keypair:              minionKeypair,
 		BotAccount:           botAccount,
-		BotKeypair:           botKeypair,
+		BotSigner:            internal.NewKeypairSigner(botKeypair),
 		NetworkClient:        networkClient,
 		Network:              networkPassphrase,
 		StartingBalance:      startingBalance,
@@ -78,7 +78,7 @@ func setupHorizonIntegration(t *testing.T) (http.Handler, horizonclient.ClientIn
 	fb := &internal.Bot{Minions: []internal.Minion{minion}, NetworkClient: networkClient}
 	registerProblems()
 	cfg := Config{}
-	router := initRouter(cfg, fb)
+	router := validateResponses(t, initRouter(cfg, fb))
 	return router, hclient
 }
 
@@ -273,7 +273,11 @@ func TestFriendbotHorizonIntegration_AccountAlreadyFunded(t *testing.T) {
           "type": "https://stellar.org/friendbot-errors/bad_request",
           "title": "Bad Request",
           "status": 400,
-          "detail": "account already funded to starting balance"
+          "detail": "account already funded to starting balance",
+          "extras": {
+            "already_funded": true,
+            "retryable": false
+          }
         }`
 	assert.JSONEq(t, expectedJSON, body)
 }
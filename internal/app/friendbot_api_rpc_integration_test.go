@@ -1,4 +1,4 @@
-package main
+package app
 
 import (
 	"context"
@@ -70,7 +70,7 @@ func setupRPCIntegration(t *testing.T) rpcIntegrationTest {
 		},
 		Keypair:              minionKeypair,
 		BotAccount:           botAccount,
-		BotKeypair:           botKeypair,
+		BotSigner:            internal.NewKeypairSigner(botKeypair),
 		NetworkClient:        rpcClient,
 		Network:              networkPassphrase,
 		StartingBalance:      startingBalance,
@@ -83,7 +83,7 @@ func setupRPCIntegration(t *testing.T) rpcIntegrationTest {
 	fb := &internal.Bot{Minions: []internal.Minion{minion}, NetworkClient: rpcClient, FundContractAddresses: true}
 	registerProblems()
 	cfg := Config{}
-	router := initRouter(cfg, fb)
+	router := validateResponses(t, initRouter(cfg, fb))
 	return rpcIntegrationTest{
 		Router:            router,
 		RPCClient:         rpcClient,
@@ -275,7 +275,11 @@ func TestFriendbotRPCIntegration_AccountAlreadyFunded(t *testing.T) {
           "type": "https://stellar.org/friendbot-errors/bad_request",
           "title": "Bad Request",
           "status": 400,
-          "detail": "account already funded to starting balance"
+          "detail": "account already funded to starting balance",
+          "extras": {
+            "already_funded": true,
+            "retryable": false
+          }
         }`
 	assert.JSONEq(t, expectedJSON, body)
 }
@@ -325,7 +329,7 @@ func TestFriendbotRPCIntegration_AccountRefundedAfterSpending(t *testing.T) {
 	bumpSeqTxXDR, err := bumpSeqTx.Base64()
 	require.NoError(t, err)
 
-	err = tt.RPCClient.SubmitTransaction(context.Background(), bumpSeqTxXDR)
+	_, err = tt.RPCClient.SubmitTransaction(context.Background(), bumpSeqTxXDR)
 	require.NoError(t, err)
 
 	// Check balance after bump seq tx - should be slightly lower due to fees
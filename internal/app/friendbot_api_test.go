@@ -0,0 +1,1503 @@
+package app
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stellar/friendbot/internal"
+	"github.com/stellar/friendbot/internal/historystore"
+	"github.com/stellar/friendbot/internal/horizonnetworkclient"
+	"github.com/stellar/go-stellar-sdk/amount"
+	"github.com/stellar/go-stellar-sdk/keypair"
+	"github.com/stellar/go-stellar-sdk/support/errors"
+	"github.com/stellar/go-stellar-sdk/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Setup creates a test friendbot with mocked horizon.
+func setup(t *testing.T) http.Handler {
+	fb := setupBot(t)
+
+	// Register problem handlers (normally done in main)
+	registerProblems()
+
+	// Create router with test config
+	cfg := Config{}
+	router := validateResponses(t, initRouter(cfg, fb))
+
+	return router
+}
+
+// setupBot creates the bot used by setup with a single mocked minion.
+func setupBot(t *testing.T) *internal.Bot {
+	mockSubmitTransaction := func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, txHash [32]byte, tx string) (*internal.TransactionResult, error) {
+		// Emulate a successful transaction
+		txSuccess := internal.TransactionResult{
+			EnvelopeXdr: tx,
+			Successful:  true,
+			Hash:        hex.EncodeToString(txHash[:]),
+		}
+		return &txSuccess, nil
+	}
+
+	mockCheckAccountExists := func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, destAddress string) (bool, string, error) {
+		// Return account doesn't exist for all test cases (will be overridden in specific tests)
+		return false, "0", nil
+	}
+
+	botSeed := "SCWNLYELENPBXN46FHYXETT5LJCYBZD5VUQQVW4KZPHFO2YTQJUWT4D5"
+	botKeypair, err := keypair.Parse(botSeed)
+	require.NoError(t, err)
+	botAccount := internal.Account{AccountID: botKeypair.Address()}
+
+	minionSeed := "SDTNSEERJPJFUE2LSDNYBFHYGVTPIWY7TU2IOJZQQGLWO2THTGB7NU5A"
+	minionKeypair, err := keypair.Parse(minionSeed)
+	require.NoError(t, err)
+
+	minion := internal.Minion{
+		Account: internal.Account{
+			AccountID: minionKeypair.Address(),
+			Sequence:  1,
+		},
+		Keypair:              minionKeypair.(*keypair.Full),
+		BotAccount:           botAccount,
+		BotSigner:            internal.NewKeypairSigner(botKeypair.(*keypair.Full)),
+		NetworkClient:        nil, // Not used in mocks
+		Network:              "Test SDF Network ; September 2015",
+		StartingBalance:      "10000.00",
+		SubmitTransaction:    mockSubmitTransaction,
+		CheckSequenceRefresh: internal.CheckSequenceRefresh,
+		CheckAccountExists:   mockCheckAccountExists,
+		BaseFee:              txnbuild.MinBaseFee,
+	}
+
+	networkClient := &mockNetworkClient{}
+	return &internal.Bot{Minions: []internal.Minion{minion}, NetworkClient: networkClient}
+}
+
+func TestFriendbotAPI_SuccessfulFunding_GET(t *testing.T) {
+	router := setup(t)
+
+	recipientAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+
+	req := httptest.NewRequest("GET", "/?addr="+url.QueryEscape(recipientAddress), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Assert the full JSON response matches expected structure
+	body := w.Body.String()
+	expectedJSON := `{
+          "successful": true,
+          "hash": "a6f2f2459152559f4a5b3cd3c8652ed3491dee7d4c7729659362408db25f731b",
+          "envelope_xdr": "AAAAAgAAAAD4Az3jKU6lbzq/L5HG9/GzBT+FYusOz71oyYMbZkP+GAAAAGQAAAAAAAAAAgAAAAEAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAEAAAABAAAAAPXQ8gjyrVHa47a6JDPkVHwPPDKxNRE2QBcamA4JvlOGAAAAAAAAAADShvreeub1LWzv6W93J+BROl6MxA6GAyXFy86/NQWGFAAAABdIdugAAAAAAAAAAAJmQ/4YAAAAQDRLEljDVYALnTk9mDceQEd5PrjQyE3LUAjstIyTWH5t/TP909F66TgEfBFKMxSKF6fka7ZuPcSs40ix4AomEgoJvlOGAAAAQPSGs88OwXubz7UT6nFhvhF47EQfaOsmiIsOkjgzUrmBoypJQTmMMbgeix0kdbfHqS75+iefJpdXLNFDreGnxgE="
+        }`
+	assert.JSONEq(t, expectedJSON, body)
+}
+
+func TestFriendbotAPI_SuccessfulFunding_POST(t *testing.T) {
+	router := setup(t)
+
+	recipientAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+
+	formData := url.Values{}
+	formData.Set("addr", recipientAddress)
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(formData.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Assert the full JSON response matches expected structure
+	body := w.Body.String()
+	expectedJSON := `{
+          "successful": true,
+          "hash": "a6f2f2459152559f4a5b3cd3c8652ed3491dee7d4c7729659362408db25f731b",
+          "envelope_xdr": "AAAAAgAAAAD4Az3jKU6lbzq/L5HG9/GzBT+FYusOz71oyYMbZkP+GAAAAGQAAAAAAAAAAgAAAAEAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAEAAAABAAAAAPXQ8gjyrVHa47a6JDPkVHwPPDKxNRE2QBcamA4JvlOGAAAAAAAAAADShvreeub1LWzv6W93J+BROl6MxA6GAyXFy86/NQWGFAAAABdIdugAAAAAAAAAAAJmQ/4YAAAAQDRLEljDVYALnTk9mDceQEd5PrjQyE3LUAjstIyTWH5t/TP909F66TgEfBFKMxSKF6fka7ZuPcSs40ix4AomEgoJvlOGAAAAQPSGs88OwXubz7UT6nFhvhF47EQfaOsmiIsOkjgzUrmBoypJQTmMMbgeix0kdbfHqS75+iefJpdXLNFDreGnxgE="
+        }`
+	assert.JSONEq(t, expectedJSON, body)
+}
+
+func TestFriendbotAPI_SuccessfulFunding_V2(t *testing.T) {
+	recipientAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+	expectedJSON := `{
+          "successful": true,
+          "hash": "a6f2f2459152559f4a5b3cd3c8652ed3491dee7d4c7729659362408db25f731b",
+          "envelope_xdr": "AAAAAgAAAAD4Az3jKU6lbzq/L5HG9/GzBT+FYusOz71oyYMbZkP+GAAAAGQAAAAAAAAAAgAAAAEAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAEAAAABAAAAAPXQ8gjyrVHa47a6JDPkVHwPPDKxNRE2QBcamA4JvlOGAAAAAAAAAADShvreeub1LWzv6W93J+BROl6MxA6GAyXFy86/NQWGFAAAABdIdugAAAAAAAAAAAJmQ/4YAAAAQDRLEljDVYALnTk9mDceQEd5PrjQyE3LUAjstIyTWH5t/TP909F66TgEfBFKMxSKF6fka7ZuPcSs40ix4AomEgoJvlOGAAAAQPSGs88OwXubz7UT6nFhvhF47EQfaOsmiIsOkjgzUrmBoypJQTmMMbgeix0kdbfHqS75+iefJpdXLNFDreGnxgE=",
+          "addr": "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z",
+          "addr_type": "account",
+          "amount": "10000.0000000",
+          "action": "created"
+        }`
+
+	for _, tc := range []struct {
+		name   string
+		query  string
+		accept string
+	}{
+		{name: "accept header", accept: "application/json, " + internal.APIVersion2MediaType + ";q=0.9"},
+		{name: "api_version parameter", query: "&api_version=2"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			router := setup(t)
+			req := httptest.NewRequest("GET", "/?addr="+url.QueryEscape(recipientAddress)+tc.query, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, internal.APIVersion2MediaType+"; charset=utf-8", w.Header().Get("Content-Type"))
+			assert.Contains(t, w.Header().Values("Vary"), "Accept")
+			assert.JSONEq(t, expectedJSON, w.Body.String())
+		})
+	}
+}
+
+func TestFriendbotAPI_SuccessfulFunding_V2ToppedUp(t *testing.T) {
+	fb := setupBot(t)
+	fb.Minions[0].CheckAccountExists = func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, destAddress string) (bool, string, error) {
+		return true, "1.00", nil
+	}
+	fb.Minions[0].SubmitTransaction = func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, txHash [32]byte, tx string) (*internal.TransactionResult, error) {
+		return &internal.TransactionResult{EnvelopeXdr: tx, Successful: true, Hash: hex.EncodeToString(txHash[:]), Ledger: 1001, FeeCharged: 100}, nil
+	}
+	registerProblems()
+	router := validateResponses(t, initRouter(Config{}, fb))
+
+	req := httptest.NewRequest("GET", "/?addr=GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z&api_version=2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response internal.FundingResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, internal.FundingActionToppedUp, response.Action)
+	assert.Equal(t, internal.AddressTypeAccount, response.AddressType)
+	assert.Equal(t, uint32(1001), response.Ledger)
+	assert.Equal(t, int64(100), response.FeeCharged)
+}
+
+func TestFriendbotAPI_InvalidAPIVersion(t *testing.T) {
+	router := setup(t)
+
+	req := httptest.NewRequest("GET", "/?addr=GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z&api_version=3", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	expectedJSON := `{
+          "type": "https://stellar.org/friendbot-errors/bad_request",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "The request you sent was invalid in some way.",
+          "extras": {
+            "invalid_field": "api_version",
+            "reason": "must be 1 or 2"
+          }
+        }`
+	assert.JSONEq(t, expectedJSON, w.Body.String())
+}
+
+func TestFriendbotAPI_MissingAddressParameter(t *testing.T) {
+	router := setup(t)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Assert the full JSON error response matches expected structure
+	body := w.Body.String()
+	expectedJSON := `{
+          "type": "https://stellar.org/friendbot-errors/bad_request",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "The request you sent was invalid in some way.",
+          "extras": {
+            "invalid_field": "addr",
+            "reason": "invalid address: must be a valid G or C address"
+          }
+        }`
+	assert.JSONEq(t, expectedJSON, body)
+}
+
+func TestFriendbotAPI_InvalidAddress(t *testing.T) {
+	router := setup(t)
+
+	invalidAddress := "invalid_address"
+
+	req := httptest.NewRequest("GET", "/?addr="+url.QueryEscape(invalidAddress), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Assert the full JSON error response matches expected structure
+	body := w.Body.String()
+	expectedJSON := `{
+          "type": "https://stellar.org/friendbot-errors/bad_request",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "The request you sent was invalid in some way.",
+          "extras": {
+            "invalid_field": "addr",
+            "reason": "invalid address: must be a valid G or C address"
+          }
+        }`
+	assert.JSONEq(t, expectedJSON, body)
+}
+
+func TestFriendbotAPI_AccountAlreadyFunded(t *testing.T) {
+	// Create friendbot with mock that returns account already exists and funded
+	mockSubmitTransaction := func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, txHash [32]byte, tx string) (*internal.TransactionResult, error) {
+		txSuccess := internal.TransactionResult{EnvelopeXdr: tx, Successful: true}
+		return &txSuccess, nil
+	}
+
+	mockCheckAccountExists := func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, destAddress string) (bool, string, error) {
+		return true, "10000.00", nil // Account exists and has balance
+	}
+
+	botSeed := "SCWNLYELENPBXN46FHYXETT5LJCYBZD5VUQQVW4KZPHFO2YTQJUWT4D5"
+	botKeypair, err := keypair.Parse(botSeed)
+	require.NoError(t, err)
+	botAccount := internal.Account{AccountID: botKeypair.Address()}
+
+	minionSeed := "SDTNSEERJPJFUE2LSDNYBFHYGVTPIWY7TU2IOJZQQGLWO2THTGB7NU5A"
+	minionKeypair, err := keypair.Parse(minionSeed)
+	require.NoError(t, err)
+
+	networkClient := &mockNetworkClient{}
+
+	minion := internal.Minion{
+		Account: internal.Account{
+			AccountID: minionKeypair.Address(),
+			Sequence:  1,
+		},
+		Keypair:              minionKeypair.(*keypair.Full),
+		BotAccount:           botAccount,
+		BotSigner:            internal.NewKeypairSigner(botKeypair.(*keypair.Full)),
+		NetworkClient:        networkClient,
+		Network:              "Test SDF Network ; September 2015",
+		StartingBalance:      "10000.00",
+		SubmitTransaction:    mockSubmitTransaction,
+		CheckSequenceRefresh: internal.CheckSequenceRefresh,
+		CheckAccountExists:   mockCheckAccountExists,
+		BaseFee:              txnbuild.MinBaseFee,
+	}
+
+	fb := &internal.Bot{Minions: []internal.Minion{minion}, NetworkClient: networkClient}
+
+	cfg := Config{UseCloudflareIP: false}
+	router := validateResponses(t, initRouter(cfg, fb))
+
+	recipientAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+
+	req := httptest.NewRequest("GET", "/?addr="+url.QueryEscape(recipientAddress), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	// The error mapping is working correctly - returns 400 Bad Request
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Assert the full JSON error response matches expected structure
+	body := w.Body.String()
+	expectedJSON := `{
+          "type": "https://stellar.org/friendbot-errors/bad_request",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "account already funded to starting balance",
+          "extras": {
+            "already_funded": true,
+            "retryable": false
+          }
+        }`
+	assert.JSONEq(t, expectedJSON, body)
+}
+
+func TestFriendbotAPI_404NotFound(t *testing.T) {
+	router := setup(t)
+
+	req := httptest.NewRequest("GET", "/nonexistent", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Assert the full JSON error response matches expected structure
+	body := w.Body.String()
+	expectedJSON := `{
+          "type": "https://stellar.org/friendbot-errors/not_found",
+          "title": "Resource Missing",
+          "status": 404,
+          "detail": "The resource at the url requested was not found.  This usually occurs for one of two reasons:  The url requested is not valid, or no data in our database could be found with the parameters provided."
+        }`
+	assert.JSONEq(t, expectedJSON, body)
+}
+
+func TestFriendbotAPI_QueueFull(t *testing.T) {
+	fb := setupBot(t)
+	fb.Queue = internal.NewMinionQueue(len(fb.Minions), 0, time.Second)
+	registerProblems()
+	router := validateResponses(t, initRouter(Config{}, fb))
+
+	// Occupy the only minion so the request has to queue.
+	_, err := fb.Queue.Acquire(context.Background(), "other-client")
+	require.NoError(t, err)
+
+	recipientAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+	req := httptest.NewRequest("GET", "/?addr="+url.QueryEscape(recipientAddress), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	body := w.Body.String()
+	expectedJSON := `{
+          "type": "https://stellar.org/friendbot-errors/queue_full",
+          "title": "Service Unavailable",
+          "status": 503,
+          "detail": "Friendbot is receiving more requests than it can currently process. Please try again later.",
+          "extras": {"retryable": true}
+        }`
+	assert.JSONEq(t, expectedJSON, body)
+}
+
+func TestFriendbotAPI_BudgetExhausted(t *testing.T) {
+	fb := setupBot(t)
+	fb.Budget = internal.NewBudgetTracker(15000*amount.One, 0)
+	registerProblems()
+	publicRouter := initRouter(Config{}, fb)
+	router := validateResponses(t, publicRouter)
+	adminRouter := validateAdminResponses(t, initAdminRouter(fb, "test-token", http.NotFoundHandler(), nil))
+
+	recipientAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+	req := httptest.NewRequest("GET", "/?addr="+url.QueryEscape(recipientAddress), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	otherAddress := "GBZX4364PEPQTDICMIQDZ56K4T75QZCR4NBEYKO6PDRJAHZKGUOJPCXB"
+	req = httptest.NewRequest("GET", "/?addr="+url.QueryEscape(otherAddress), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	expectedJSON := `{
+          "type": "https://stellar.org/friendbot-errors/budget_exhausted",
+          "title": "Service Unavailable",
+          "status": 503,
+          "detail": "Friendbot has reached its spending limit for now. Please try again later.",
+          "extras": {"retryable": true}
+        }`
+	assert.JSONEq(t, expectedJSON, w.Body.String())
+
+	req = httptest.NewRequest("GET", "/budget", nil)
+	w = httptest.NewRecorder()
+	adminRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req.Header.Set("Authorization", "Bearer test-token")
+	w = httptest.NewRecorder()
+	adminRouter.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	expectedJSON = `{
+          "hourly": {
+            "cap": "15000.0000000",
+            "spent": "10000.0000000",
+            "reserved": "0.0000000",
+            "remaining": "5000.0000000"
+          },
+          "daily": {
+            "spent": "10000.0000000",
+            "reserved": "0.0000000"
+          },
+          "exhausted": false
+        }`
+	assert.JSONEq(t, expectedJSON, w.Body.String())
+}
+
+func TestFriendbotAPI_UpstreamTimeout(t *testing.T) {
+	fb := setupBot(t)
+	fb.Minions[0].SubmitTransaction = func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, txHash [32]byte, tx string) (*internal.TransactionResult, error) {
+		return nil, errors.Wrap(internal.ErrUpstreamTimeout, "horizon request timed out")
+	}
+	fb.Budget = internal.NewBudgetTracker(0, 0)
+	registerProblems()
+	router := validateResponses(t, initRouter(Config{}, fb))
+
+	recipientAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+	req := httptest.NewRequest("GET", "/?addr="+url.QueryEscape(recipientAddress), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	expectedJSON := `{
+          "type": "https://stellar.org/friendbot-errors/upstream_timeout",
+          "title": "Gateway Timeout",
+          "status": 504,
+          "detail": "The Stellar network did not respond in time. The address may still be funded, so check it before trying again.",
+          "extras": {"retryable": true}
+        }`
+	assert.JSONEq(t, expectedJSON, w.Body.String())
+	// The payment may have been applied, so it counts against the budget.
+	assert.Equal(t, "10000.0000000", fb.Budget.Status().Daily.Spent)
+}
+
+func TestAdminAPI_MinionsAndPause(t *testing.T) {
+	fb := setupBot(t)
+	fb.Queue = internal.NewMinionQueue(len(fb.Minions), 10, time.Second)
+	registerProblems()
+	publicRouter := initRouter(Config{}, fb)
+	router := validateResponses(t, publicRouter)
+	adminRouter := validateAdminResponses(t, initAdminRouter(fb, "test-token", http.NotFoundHandler(), nil))
+	minionAddress := fb.Minions[0].Account.AccountID
+
+	req := httptest.NewRequest("POST", "/funding/pause", nil)
+	w := httptest.NewRecorder()
+	adminRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.False(t, fb.Paused())
+
+	adminRequest := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "Bearer test-token")
+		w := httptest.NewRecorder()
+		adminRouter.ServeHTTP(w, req)
+		return w
+	}
+
+	w = adminRequest("POST", "/minions/"+minionAddress+"/quarantine")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+          "address": "`+minionAddress+`",
+          "in_use": false,
+          "quarantined": true,
+          "recent_errors": 0
+        }`, w.Body.String())
+
+	w = adminRequest("POST", "/minions/GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z/release")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = adminRequest("POST", "/minions/"+minionAddress+"/release")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = adminRequest("POST", "/funding/pause")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"paused": true}`, w.Body.String())
+
+	recipientAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+	req = httptest.NewRequest("GET", "/?addr="+url.QueryEscape(recipientAddress), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{
+          "type": "https://stellar.org/friendbot-errors/funding_paused",
+          "title": "Service Unavailable",
+          "status": 503,
+          "detail": "Funding has been paused by the friendbot operators. Please try again later.",
+          "extras": {"retryable": true}
+        }`, w.Body.String())
+
+	w = adminRequest("POST", "/funding/resume")
+	assert.Equal(t, http.StatusOK, w.Code)
+	req = httptest.NewRequest("GET", "/?addr="+url.QueryEscape(recipientAddress), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestFriendbotAPI_IdempotencyKey_ReplaysResponse(t *testing.T) {
+	fb := setupBot(t)
+	numTxSubmits := 0
+	submitTransaction := fb.Minions[0].SubmitTransaction
+	fb.Minions[0].SubmitTransaction = func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, txHash [32]byte, tx string) (*internal.TransactionResult, error) {
+		numTxSubmits++
+		return submitTransaction(ctx, minion, networkClient, txHash, tx)
+	}
+	registerProblems()
+	router := validateResponses(t, initRouter(Config{}, fb))
+
+	recipientAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+	formData := url.Values{}
+	formData.Set("addr", recipientAddress)
+
+	var responses []*httptest.ResponseRecorder
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(formData.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Idempotency-Key", "3f1c2a4e-retry")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		responses = append(responses, w)
+	}
+
+	assert.Equal(t, http.StatusOK, responses[0].Code)
+	assert.Equal(t, http.StatusOK, responses[1].Code)
+	assert.Equal(t, responses[0].Body.String(), responses[1].Body.String())
+	assert.Equal(t, responses[0].Header().Get("Content-Type"), responses[1].Header().Get("Content-Type"))
+	assert.Empty(t, responses[0].Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, "true", responses[1].Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 1, numTxSubmits)
+}
+
+func TestFriendbotAPI_IdempotencyKey_Conflict(t *testing.T) {
+	router := setup(t)
+
+	req := httptest.NewRequest("GET", "/?addr=GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z", nil)
+	req.Header.Set("Idempotency-Key", "3f1c2a4e-conflict")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest("GET", "/?addr=GD25B4QI6KWVDWXDW25CIM7EKR6A6PBSWE2RCNSAC4NJQDQJXZJYMMKR", nil)
+	req.Header.Set("Idempotency-Key", "3f1c2a4e-conflict")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	body := w.Body.String()
+	expectedJSON := `{
+          "type": "https://stellar.org/friendbot-errors/idempotency_key_conflict",
+          "title": "Conflict",
+          "status": 409,
+          "detail": "The Idempotency-Key header was already used for a request to fund a different address."
+        }`
+	assert.JSONEq(t, expectedJSON, body)
+}
+
+func TestFriendbotAPI_IdempotencyKey_StoresErrors(t *testing.T) {
+	router := setup(t)
+
+	// Invalid requests are stored and replayed like fundings.
+	var responses []*httptest.ResponseRecorder
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/?addr=GDJIN6W6PLTPKLLM57UW65ZH4BITUX", nil)
+		req.Header.Set("Idempotency-Key", "3f1c2a4e-invalid")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		responses = append(responses, w)
+	}
+	assert.Equal(t, http.StatusBadRequest, responses[0].Code)
+	assert.Equal(t, http.StatusBadRequest, responses[1].Code)
+	assert.Equal(t, responses[0].Body.String(), responses[1].Body.String())
+	assert.Equal(t, "true", responses[1].Header().Get("Idempotent-Replayed"))
+
+	req := httptest.NewRequest("GET", "/?addr=GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z", nil)
+	req.Header.Set("Idempotency-Key", "3f1c2a4e-invalid")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestFriendbotAPI_IdempotencyKey_DoesNotStoreServerErrors(t *testing.T) {
+	fb := setupBot(t)
+	registerProblems()
+	router := validateResponses(t, initRouter(Config{}, fb))
+	fund := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/?addr=GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z", nil)
+		req.Header.Set("Idempotency-Key", "3f1c2a4e-paused")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	fb.SetPaused(true)
+	assert.Equal(t, http.StatusServiceUnavailable, fund().Code)
+
+	fb.SetPaused(false)
+	w := fund()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+}
+
+func TestFriendbotAPI_IdempotencyKey_ReplaysToOtherClients(t *testing.T) {
+	router := setup(t)
+
+	var responses []*httptest.ResponseRecorder
+	for _, remoteAddr := range []string{"192.0.2.1:1234", "192.0.2.2:1234"} {
+		req := httptest.NewRequest("GET", "/?addr=GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z", nil)
+		req.Header.Set("Idempotency-Key", "3f1c2a4e-roaming")
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		responses = append(responses, w)
+	}
+	assert.Equal(t, http.StatusOK, responses[1].Code)
+	assert.Equal(t, responses[0].Body.String(), responses[1].Body.String())
+	assert.Equal(t, "true", responses[1].Header().Get("Idempotent-Replayed"))
+}
+
+func TestFriendbotAPI_IdempotencyKey_VersionConflict(t *testing.T) {
+	router := setup(t)
+
+	req := httptest.NewRequest("GET", "/?addr=GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z&api_version=1", nil)
+	req.Header.Set("Idempotency-Key", "3f1c2a4e-version")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest("GET", "/?addr=GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z&api_version=2", nil)
+	req.Header.Set("Idempotency-Key", "3f1c2a4e-version")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.JSONEq(t, `{
+          "type": "https://stellar.org/friendbot-errors/idempotency_key_conflict",
+          "title": "Conflict",
+          "status": 409,
+          "detail": "The Idempotency-Key header was already used for a request for a different version of the response."
+        }`, w.Body.String())
+}
+
+func TestFriendbotAPI_FundJSON(t *testing.T) {
+	router := setup(t)
+
+	body := `{"addr": "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z", "amount": "10000", "asset": "native"}`
+	req := httptest.NewRequest("POST", "/v2/fund", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, internal.APIVersion2MediaType+"; charset=utf-8", w.Header().Get("Content-Type"))
+	var response internal.FundingResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z", response.Address)
+	assert.Equal(t, internal.FundingActionCreated, response.Action)
+	assert.Equal(t, "10000.0000000", response.Amount)
+}
+
+func TestFriendbotAPI_FundJSON_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		field       string
+		detail      string
+	}{
+		{
+			name:        "form body",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "addr=GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z",
+			status:      http.StatusUnsupportedMediaType,
+			detail:      "The request body must be application/json.",
+		},
+		{
+			name:   "malformed json",
+			body:   `{"addr": `,
+			status: http.StatusBadRequest,
+			detail: "The request body is not valid JSON.",
+		},
+		{name: "missing addr", body: `{}`, status: http.StatusBadRequest, field: "addr"},
+		{name: "addr not a string", body: `{"addr": 1}`, status: http.StatusBadRequest, field: "addr"},
+		{name: "unknown field", body: `{"addr": "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z", "address": "x"}`, status: http.StatusBadRequest, field: "address"},
+		{name: "invalid address", body: `{"addr": "GDJIN6W6PLTPKLLM57UW65ZH4BITUX"}`, status: http.StatusBadRequest, field: "addr"},
+		{name: "malformed amount", body: `{"addr": "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z", "amount": "lots"}`, status: http.StatusBadRequest, field: "amount"},
+		{name: "different amount", body: `{"addr": "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z", "amount": "5"}`, status: http.StatusBadRequest, field: "amount"},
+		{name: "other asset", body: `{"addr": "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z", "asset": "USDC"}`, status: http.StatusBadRequest, field: "asset"},
+		{name: "memo too long", body: `{"addr": "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z", "memo": "friendbot funding from quickstart"}`, status: http.StatusBadRequest, field: "memo"},
+		{name: "unknown memo type", body: `{"addr": "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z", "memo": "1", "memo_type": "return"}`, status: http.StatusBadRequest, field: "memo_type"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			router := setup(t)
+
+			req := httptest.NewRequest("POST", "/v2/fund", strings.NewReader(tc.body))
+			contentType := tc.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+			var p struct {
+				Detail string         `json:"detail"`
+				Extras map[string]any `json:"extras"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+			if tc.field != "" {
+				assert.Equal(t, tc.field, p.Extras["invalid_field"])
+				assert.NotEmpty(t, p.Extras["reason"])
+			}
+			if tc.detail != "" {
+				assert.Equal(t, tc.detail, p.Detail)
+			}
+		})
+	}
+}
+
+func TestFriendbotAPI_FundJSON_IdempotencyKey(t *testing.T) {
+	fb := setupBot(t)
+	numTxSubmits := 0
+	submitTransaction := fb.Minions[0].SubmitTransaction
+	fb.Minions[0].SubmitTransaction = func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, txHash [32]byte, tx string) (*internal.TransactionResult, error) {
+		numTxSubmits++
+		return submitTransaction(ctx, minion, networkClient, txHash, tx)
+	}
+	registerProblems()
+	router := validateResponses(t, initRouter(Config{}, fb))
+
+	body := `{"addr": "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z", "idempotency_key": "3f1c2a4e-json"}`
+	var responses []*httptest.ResponseRecorder
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("POST", "/v2/fund", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		responses = append(responses, w)
+	}
+
+	assert.Equal(t, http.StatusOK, responses[0].Code)
+	assert.Equal(t, http.StatusOK, responses[1].Code)
+	assert.Equal(t, responses[0].Body.String(), responses[1].Body.String())
+	assert.Equal(t, "true", responses[1].Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 1, numTxSubmits)
+
+	// The key in the body must match the one in the header.
+	req := httptest.NewRequest("POST", "/v2/fund", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "3f1c2a4e-other")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+          "type": "https://stellar.org/friendbot-errors/bad_request",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "The request you sent was invalid in some way.",
+          "extras": {
+            "invalid_field": "idempotency_key",
+            "reason": "must match the Idempotency-Key header"
+          }
+        }`, w.Body.String())
+}
+
+func TestFriendbotAPI_Memo(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		defaultMemo internal.Memo
+		query       string
+		memo        internal.Memo
+		txMemo      txnbuild.Memo
+	}{
+		{
+			name:   "text",
+			query:  "&memo=" + url.QueryEscape("hello friendbot"),
+			memo:   internal.Memo{Type: "text", Value: "hello friendbot"},
+			txMemo: txnbuild.MemoText("hello friendbot"),
+		},
+		{
+			name:   "id",
+			query:  "&memo_type=id&memo=42",
+			memo:   internal.Memo{Type: "id", Value: "42"},
+			txMemo: txnbuild.MemoID(42),
+		},
+		{
+			name:   "hash",
+			query:  "&memo_type=hash&memo=" + strings.Repeat("ab", 32),
+			memo:   internal.Memo{Type: "hash", Value: strings.Repeat("ab", 32)},
+			txMemo: txnbuild.MemoHash{0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab, 0xab},
+		},
+		{
+			name:        "default",
+			defaultMemo: internal.Memo{Type: "text", Value: "quickstart"},
+			memo:        internal.Memo{Type: "text", Value: "quickstart"},
+			txMemo:      txnbuild.MemoText("quickstart"),
+		},
+		{
+			name:        "overrides default",
+			defaultMemo: internal.Memo{Type: "text", Value: "quickstart"},
+			query:       "&memo=mine",
+			memo:        internal.Memo{Type: "text", Value: "mine"},
+			txMemo:      txnbuild.MemoText("mine"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fb := setupBot(t)
+			fb.Memo = tc.defaultMemo
+			registerProblems()
+			router := validateResponses(t, initRouter(Config{}, fb))
+
+			req := httptest.NewRequest("GET", "/?addr=GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z&api_version=2"+tc.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			var response internal.FundingResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			require.NotNil(t, response.Memo)
+			assert.Equal(t, tc.memo, *response.Memo)
+
+			parsed, err := txnbuild.TransactionFromXDR(response.EnvelopeXdr)
+			require.NoError(t, err)
+			tx, ok := parsed.Transaction()
+			require.True(t, ok)
+			assert.Equal(t, tc.txMemo, tx.Memo())
+		})
+	}
+}
+
+func TestFriendbotAPI_MemoInvalid(t *testing.T) {
+	router := setup(t)
+
+	req := httptest.NewRequest("GET", "/?addr=GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z&memo_type=id&memo=-1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+          "type": "https://stellar.org/friendbot-errors/bad_request",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "The request you sent was invalid in some way.",
+          "extras": {
+            "invalid_field": "memo",
+            "reason": "id memos must be an unsigned 64-bit integer"
+          }
+        }`, w.Body.String())
+}
+
+func TestFriendbotAPI_FundingHistory(t *testing.T) {
+	fb := setupBot(t)
+	history, err := historystore.NewSQLiteStore(filepath.Join(t.TempDir(), "history.db"))
+	require.NoError(t, err)
+	defer history.Close()
+	fb.History = history
+	registerProblems()
+	publicRouter := initRouter(Config{}, fb)
+	router := validateResponses(t, publicRouter)
+	adminRouter := validateAdminResponses(t, initAdminRouter(fb, "test-token", http.NotFoundHandler(), nil))
+
+	recipientAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+	req := httptest.NewRequest("GET", "/?addr="+url.QueryEscape(recipientAddress), nil)
+	req.RemoteAddr = "203.0.113.7:41234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// The history is only served on the admin router, to the admin token.
+	req = httptest.NewRequest("GET", "/fundings?addr="+url.QueryEscape(recipientAddress), nil)
+	w = httptest.NewRecorder()
+	publicRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = httptest.NewRecorder()
+	adminRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req.Header.Set("Authorization", "Bearer test-token")
+	w = httptest.NewRecorder()
+	adminRouter.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var page struct {
+		Embedded struct {
+			Records []map[string]interface{} `json:"records"`
+		} `json:"_embedded"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	require.Len(t, page.Embedded.Records, 1)
+	record := page.Embedded.Records[0]
+	assert.Equal(t, recipientAddress, record["addr"])
+	assert.Equal(t, "account", record["addr_type"])
+	assert.Equal(t, "10000.00", record["amount"])
+	assert.Equal(t, "GD4AGPPDFFHKK3Z2X4XZDRXX6GZQKP4FMLVQ5T55NDEYGG3GIP7BQUHM", record["minion"])
+	assert.Equal(t, "a6f2f2459152559f4a5b3cd3c8652ed3491dee7d4c7729659362408db25f731b", record["hash"])
+	assert.Equal(t, "success", record["outcome"])
+	assert.NotContains(t, w.Body.String(), "203.0.113.7")
+
+	req = httptest.NewRequest("GET", "/fundings/a6f2f2459152559f4a5b3cd3c8652ed3491dee7d4c7729659362408db25f731b", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w = httptest.NewRecorder()
+	adminRouter.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"addr": "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"`)
+
+	// Hashes are matched regardless of case.
+	req = httptest.NewRequest("GET", "/fundings/"+strings.ToUpper("a6f2f2459152559f4a5b3cd3c8652ed3491dee7d4c7729659362408db25f731b"), nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w = httptest.NewRecorder()
+	adminRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest("GET", "/fundings/0000000000000000000000000000000000000000000000000000000000000000", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w = httptest.NewRecorder()
+	adminRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req = httptest.NewRequest("GET", "/fundings?addr=invalid_address", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w = httptest.NewRecorder()
+	adminRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestFriendbotAPI_MethodNotAllowed(t *testing.T) {
+	router := setup(t)
+
+	// Test PUT method which should not be allowed
+	req := httptest.NewRequest("PUT", "/", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	// Chi router returns 405 Method Not Allowed for undefined methods on existing routes
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	// Assert the response body - should be empty or contain method not allowed message
+	body := w.Body.String()
+	if body != "" {
+		// If there's a response body, it should contain error details
+		assert.Contains(t, body, "Method Not Allowed")
+	}
+}
+
+func TestFriendbotAPI_ContractAddressNotSupported(t *testing.T) {
+	// Test that a valid C address (contract) is rejected when contract addresses
+	// are not supported (e.g., Horizon-only configuration)
+
+	networkClient := &mockNetworkClient{} // SupportsContractAddresses() returns false
+
+	botSeed := "SCWNLYELENPBXN46FHYXETT5LJCYBZD5VUQQVW4KZPHFO2YTQJUWT4D5"
+	botKeypair, err := keypair.Parse(botSeed)
+	require.NoError(t, err)
+	botAccount := internal.Account{AccountID: botKeypair.Address()}
+
+	minionSeed := "SDTNSEERJPJFUE2LSDNYBFHYGVTPIWY7TU2IOJZQQGLWO2THTGB7NU5A"
+	minionKeypair, err := keypair.Parse(minionSeed)
+	require.NoError(t, err)
+
+	minion := internal.Minion{
+		Account: internal.Account{
+			AccountID: minionKeypair.Address(),
+			Sequence:  1,
+		},
+		Keypair:              minionKeypair.(*keypair.Full),
+		BotAccount:           botAccount,
+		BotSigner:            internal.NewKeypairSigner(botKeypair.(*keypair.Full)),
+		NetworkClient:        networkClient,
+		Network:              "Test SDF Network ; September 2015",
+		StartingBalance:      "10000.00",
+		SubmitTransaction:    internal.SubmitTransaction,
+		CheckSequenceRefresh: internal.CheckSequenceRefresh,
+		CheckAccountExists:   internal.CheckAccountExists,
+		BaseFee:              txnbuild.MinBaseFee,
+	}
+
+	fb := &internal.Bot{Minions: []internal.Minion{minion}, NetworkClient: networkClient}
+	registerProblems()
+	cfg := Config{}
+	router := validateResponses(t, initRouter(cfg, fb))
+
+	// Use a valid C address (contract address)
+	contractAddress := "CAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABSC4"
+
+	req := httptest.NewRequest("GET", "/?addr="+url.QueryEscape(contractAddress), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	// Should return 400 Bad Request because contract addresses are not supported
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	expectedJSON := `{
+          "type": "https://stellar.org/friendbot-errors/contract_funding_disabled",
+          "title": "Contract Funding Disabled",
+          "status": 400,
+          "detail": "This friendbot does not fund contract (C) addresses.",
+          "extras": {
+            "invalid_field": "addr",
+            "reason": "contract addresses are not supported or enabled",
+            "retryable": false
+          }
+        }`
+	assert.JSONEq(t, expectedJSON, w.Body.String())
+}
+
+func TestFriendbotAPI_InvalidContractAddress(t *testing.T) {
+	router := setup(t)
+
+	// Test an invalid address that looks like a C address but isn't valid
+	invalidContractAddress := "CINVALIDADDRESS"
+
+	req := httptest.NewRequest("GET", "/?addr="+url.QueryEscape(invalidContractAddress), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	body := w.Body.String()
+	// Should contain address validation error
+	assert.Contains(t, body, `"invalid_field"`)
+	assert.Contains(t, body, `"addr"`)
+}
+
+// mockNetworkClient implements internal.NetworkClient for basic testing without contract support
+type mockNetworkClient struct{}
+
+func (m *mockNetworkClient) SubmitTransaction(ctx context.Context, txXDR string) (*internal.SubmitTransactionResult, error) {
+	return &internal.SubmitTransactionResult{}, nil
+}
+
+func (m *mockNetworkClient) GetAccountDetails(ctx context.Context, accountID string) (*internal.AccountDetails, error) {
+	return nil, nil
+}
+
+func (m *mockNetworkClient) SimulateTransaction(ctx context.Context, txXDR string) (*internal.SimulateTransactionResult, error) {
+	return nil, horizonnetworkclient.ErrSimulationNotSupported
+}
+
+func (m *mockNetworkClient) SupportsContractAddresses() bool {
+	return false
+}
+
+func (m *mockNetworkClient) NetworkInfo(ctx context.Context) (*internal.NetworkInfo, error) {
+	return &internal.NetworkInfo{Passphrase: "Test SDF Network ; September 2015", ProtocolVersion: 22}, nil
+}
+
+// mockNetworkClientWithSimulation implements internal.NetworkClient for testing with contract support
+type mockNetworkClientWithSimulation struct {
+	simulateResult *internal.SimulateTransactionResult
+	simulateErr    error
+}
+
+func (m *mockNetworkClientWithSimulation) SubmitTransaction(ctx context.Context, txXDR string) (*internal.SubmitTransactionResult, error) {
+	return &internal.SubmitTransactionResult{}, nil
+}
+
+func (m *mockNetworkClientWithSimulation) GetAccountDetails(ctx context.Context, accountID string) (*internal.AccountDetails, error) {
+	return nil, nil
+}
+
+func (m *mockNetworkClientWithSimulation) SimulateTransaction(ctx context.Context, txXDR string) (*internal.SimulateTransactionResult, error) {
+	if m.simulateErr != nil {
+		return nil, m.simulateErr
+	}
+	return m.simulateResult, nil
+}
+
+func (m *mockNetworkClientWithSimulation) SupportsContractAddresses() bool {
+	// Return true to allow contract address tests to proceed
+	return true
+}
+
+func (m *mockNetworkClientWithSimulation) NetworkInfo(ctx context.Context) (*internal.NetworkInfo, error) {
+	return &internal.NetworkInfo{Passphrase: "Test SDF Network ; September 2015", ProtocolVersion: 22}, nil
+}
+
+// TestFriendbotAPI_ContractFunding_SuccessfulWithMockedSimulation tests that contract funding
+// succeeds when simulation returns valid data
+func TestFriendbotAPI_ContractFunding_SuccessfulWithMockedSimulation(t *testing.T) {
+	mockSubmitTransaction := func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, txHash [32]byte, tx string) (*internal.TransactionResult, error) {
+		txSuccess := internal.TransactionResult{
+			EnvelopeXdr: tx,
+			Successful:  true,
+			Hash:        hex.EncodeToString(txHash[:]),
+		}
+		return &txSuccess, nil
+	}
+
+	mockCheckAccountExists := func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, destAddress string) (bool, string, error) {
+		return false, "0", nil
+	}
+
+	botSeed := "SCWNLYELENPBXN46FHYXETT5LJCYBZD5VUQQVW4KZPHFO2YTQJUWT4D5"
+	botKeypair, err := keypair.Parse(botSeed)
+	require.NoError(t, err)
+	botAccount := internal.Account{AccountID: botKeypair.Address()}
+
+	minionSeed := "SDTNSEERJPJFUE2LSDNYBFHYGVTPIWY7TU2IOJZQQGLWO2THTGB7NU5A"
+	minionKeypair, err := keypair.Parse(minionSeed)
+	require.NoError(t, err)
+
+	// Create a mock network client that returns valid simulation data
+	// This is a minimal valid SorobanTransactionData XDR (generated from xdr.SorobanTransactionData)
+	mockNetworkClient := &mockNetworkClientWithSimulation{
+		simulateResult: &internal.SimulateTransactionResult{
+			// Valid SorobanTransactionData XDR with empty footprint and 100000 instructions/resource fee
+			TransactionDataXDR: "AAAAAAAAAAAAAAAAAAGGoAAAAAAAAAAAAAAAAAABhqA=",
+		},
+	}
+
+	minion := internal.Minion{
+		Account: internal.Account{
+			AccountID: minionKeypair.Address(),
+			Sequence:  1,
+		},
+		Keypair:              minionKeypair.(*keypair.Full),
+		BotAccount:           botAccount,
+		BotSigner:            internal.NewKeypairSigner(botKeypair.(*keypair.Full)),
+		NetworkClient:        mockNetworkClient,
+		Network:              "Test SDF Network ; September 2015",
+		StartingBalance:      "10000.00",
+		SubmitTransaction:    mockSubmitTransaction,
+		CheckSequenceRefresh: internal.CheckSequenceRefresh,
+		CheckAccountExists:   mockCheckAccountExists,
+		BaseFee:              txnbuild.MinBaseFee,
+	}
+
+	fb := &internal.Bot{Minions: []internal.Minion{minion}, NetworkClient: mockNetworkClient, FundContractAddresses: true}
+	registerProblems()
+	cfg := Config{}
+	router := validateResponses(t, initRouter(cfg, fb))
+
+	// Use a valid C address (contract address)
+	contractAddress := "CAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABSC4"
+
+	req := httptest.NewRequest("GET", "/?addr="+url.QueryEscape(contractAddress), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, `"successful": true`)
+	assert.Contains(t, body, `"hash"`)
+	assert.Contains(t, body, `"envelope_xdr"`)
+}
+
+// TestFriendbotAPI_ContractFunding_POST tests contract funding via POST method
+func TestFriendbotAPI_ContractFunding_POST(t *testing.T) {
+	mockSubmitTransaction := func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, txHash [32]byte, tx string) (*internal.TransactionResult, error) {
+		txSuccess := internal.TransactionResult{
+			EnvelopeXdr: tx,
+			Successful:  true,
+			Hash:        hex.EncodeToString(txHash[:]),
+		}
+		return &txSuccess, nil
+	}
+
+	mockCheckAccountExists := func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, destAddress string) (bool, string, error) {
+		return false, "0", nil
+	}
+
+	botSeed := "SCWNLYELENPBXN46FHYXETT5LJCYBZD5VUQQVW4KZPHFO2YTQJUWT4D5"
+	botKeypair, err := keypair.Parse(botSeed)
+	require.NoError(t, err)
+	botAccount := internal.Account{AccountID: botKeypair.Address()}
+
+	minionSeed := "SDTNSEERJPJFUE2LSDNYBFHYGVTPIWY7TU2IOJZQQGLWO2THTGB7NU5A"
+	minionKeypair, err := keypair.Parse(minionSeed)
+	require.NoError(t, err)
+
+	mockNetworkClient := &mockNetworkClientWithSimulation{
+		simulateResult: &internal.SimulateTransactionResult{
+			TransactionDataXDR: "AAAAAAAAAAAAAAAAAAGGoAAAAAAAAAAAAAAAAAABhqA=",
+		},
+	}
+
+	minion := internal.Minion{
+		Account: internal.Account{
+			AccountID: minionKeypair.Address(),
+			Sequence:  1,
+		},
+		Keypair:              minionKeypair.(*keypair.Full),
+		BotAccount:           botAccount,
+		BotSigner:            internal.NewKeypairSigner(botKeypair.(*keypair.Full)),
+		NetworkClient:        mockNetworkClient,
+		Network:              "Test SDF Network ; September 2015",
+		StartingBalance:      "10000.00",
+		SubmitTransaction:    mockSubmitTransaction,
+		CheckSequenceRefresh: internal.CheckSequenceRefresh,
+		CheckAccountExists:   mockCheckAccountExists,
+		BaseFee:              txnbuild.MinBaseFee,
+	}
+
+	// Contract payments are funded without the default memo.
+	fb := &internal.Bot{Minions: []internal.Minion{minion}, NetworkClient: mockNetworkClient, FundContractAddresses: true, Memo: internal.Memo{Type: "text", Value: "quickstart"}}
+	registerProblems()
+	cfg := Config{}
+	router := validateResponses(t, initRouter(cfg, fb))
+
+	contractAddress := "CAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABSC4"
+
+	formData := url.Values{}
+	formData.Set("addr", contractAddress)
+	formData.Set("api_version", "2")
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(formData.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response internal.FundingResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Successful)
+	assert.Nil(t, response.Memo)
+
+	// Nor can a memo be asked for.
+	formData.Set("memo", "hello")
+	req = httptest.NewRequest("POST", "/", strings.NewReader(formData.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "memos are not supported for contract addresses")
+}
+
+// TestFriendbotAPI_ContractFunding_SimulationError tests that simulation errors are handled properly
+func TestFriendbotAPI_ContractFunding_SimulationError(t *testing.T) {
+	mockSubmitTransaction := func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, txHash [32]byte, tx string) (*internal.TransactionResult, error) {
+		txSuccess := internal.TransactionResult{
+			EnvelopeXdr: tx,
+			Successful:  true,
+			Hash:        hex.EncodeToString(txHash[:]),
+		}
+		return &txSuccess, nil
+	}
+
+	mockCheckAccountExists := func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, destAddress string) (bool, string, error) {
+		return false, "0", nil
+	}
+
+	botSeed := "SCWNLYELENPBXN46FHYXETT5LJCYBZD5VUQQVW4KZPHFO2YTQJUWT4D5"
+	botKeypair, err := keypair.Parse(botSeed)
+	require.NoError(t, err)
+	botAccount := internal.Account{AccountID: botKeypair.Address()}
+
+	minionSeed := "SDTNSEERJPJFUE2LSDNYBFHYGVTPIWY7TU2IOJZQQGLWO2THTGB7NU5A"
+	minionKeypair, err := keypair.Parse(minionSeed)
+	require.NoError(t, err)
+
+	// Create a mock network client that returns simulation error
+	mockNetworkClient := &mockNetworkClientWithSimulation{
+		simulateErr: assert.AnError,
+	}
+
+	minion := internal.Minion{
+		Account: internal.Account{
+			AccountID: minionKeypair.Address(),
+			Sequence:  1,
+		},
+		Keypair:              minionKeypair.(*keypair.Full),
+		BotAccount:           botAccount,
+		BotSigner:            internal.NewKeypairSigner(botKeypair.(*keypair.Full)),
+		NetworkClient:        mockNetworkClient,
+		Network:              "Test SDF Network ; September 2015",
+		StartingBalance:      "10000.00",
+		SubmitTransaction:    mockSubmitTransaction,
+		CheckSequenceRefresh: internal.CheckSequenceRefresh,
+		CheckAccountExists:   mockCheckAccountExists,
+		BaseFee:              txnbuild.MinBaseFee,
+	}
+
+	fb := &internal.Bot{Minions: []internal.Minion{minion}, NetworkClient: mockNetworkClient, FundContractAddresses: true}
+	registerProblems()
+	cfg := Config{}
+	router := validateResponses(t, initRouter(cfg, fb))
+
+	contractAddress := "CAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABSC4"
+
+	req := httptest.NewRequest("GET", "/?addr="+url.QueryEscape(contractAddress), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	// Should return 500 Internal Server Error when simulation fails
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	body := w.Body.String()
+	// Should NOT contain "invalid_field" - the error is in simulation, not address validation
+	assert.NotContains(t, body, `"invalid_field"`)
+}
+
+// TestFriendbotAPI_ContractChecksBalance tests that contracts have their balance checked
+// just like accounts, to prevent over-funding
+func TestFriendbotAPI_ContractChecksBalance(t *testing.T) {
+	mockSubmitTransaction := func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, txHash [32]byte, tx string) (*internal.TransactionResult, error) {
+		txSuccess := internal.TransactionResult{
+			EnvelopeXdr: tx,
+			Successful:  true,
+			Hash:        hex.EncodeToString(txHash[:]),
+		}
+		return &txSuccess, nil
+	}
+
+	// This mock should be called for contract addresses now
+	mockCheckAccountExistsCalled := false
+	mockCheckAccountExists := func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, destAddress string) (bool, string, error) {
+		mockCheckAccountExistsCalled = true
+		return true, "0", nil // Return low balance so funding proceeds
+	}
+
+	botSeed := "SCWNLYELENPBXN46FHYXETT5LJCYBZD5VUQQVW4KZPHFO2YTQJUWT4D5"
+	botKeypair, err := keypair.Parse(botSeed)
+	require.NoError(t, err)
+	botAccount := internal.Account{AccountID: botKeypair.Address()}
+
+	minionSeed := "SDTNSEERJPJFUE2LSDNYBFHYGVTPIWY7TU2IOJZQQGLWO2THTGB7NU5A"
+	minionKeypair, err := keypair.Parse(minionSeed)
+	require.NoError(t, err)
+
+	mockNetworkClient := &mockNetworkClientWithSimulation{
+		simulateResult: &internal.SimulateTransactionResult{
+			TransactionDataXDR: "AAAAAAAAAAAAAAAAAAGGoAAAAAAAAAAAAAAAAAABhqA=",
+		},
+	}
+
+	minion := internal.Minion{
+		Account: internal.Account{
+			AccountID: minionKeypair.Address(),
+			Sequence:  1,
+		},
+		Keypair:              minionKeypair.(*keypair.Full),
+		BotAccount:           botAccount,
+		BotSigner:            internal.NewKeypairSigner(botKeypair.(*keypair.Full)),
+		NetworkClient:        mockNetworkClient,
+		Network:              "Test SDF Network ; September 2015",
+		StartingBalance:      "10000.00",
+		SubmitTransaction:    mockSubmitTransaction,
+		CheckSequenceRefresh: internal.CheckSequenceRefresh,
+		CheckAccountExists:   mockCheckAccountExists,
+		BaseFee:              txnbuild.MinBaseFee,
+	}
+
+	fb := &internal.Bot{Minions: []internal.Minion{minion}, NetworkClient: mockNetworkClient, FundContractAddresses: true}
+	registerProblems()
+	cfg := Config{}
+	router := validateResponses(t, initRouter(cfg, fb))
+
+	contractAddress := "CAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABSC4"
+
+	req := httptest.NewRequest("GET", "/?addr="+url.QueryEscape(contractAddress), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	// Should succeed because balance is below starting balance
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// CheckAccountExists SHOULD be called for contract addresses now
+	assert.True(t, mockCheckAccountExistsCalled, "CheckAccountExists should be called for contract addresses")
+}
+
+// TestFriendbotAPI_GAddressStillWorksWithSimulationClient tests that G addresses
+// continue to work normally even when using a network client with simulation support
+func TestFriendbotAPI_GAddressStillWorksWithSimulationClient(t *testing.T) {
+	mockSubmitTransaction := func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, txHash [32]byte, tx string) (*internal.TransactionResult, error) {
+		txSuccess := internal.TransactionResult{
+			EnvelopeXdr: tx,
+			Successful:  true,
+			Hash:        hex.EncodeToString(txHash[:]),
+		}
+		return &txSuccess, nil
+	}
+
+	mockCheckAccountExists := func(ctx context.Context, minion *internal.Minion, networkClient internal.NetworkClient, destAddress string) (bool, string, error) {
+		return false, "0", nil
+	}
+
+	botSeed := "SCWNLYELENPBXN46FHYXETT5LJCYBZD5VUQQVW4KZPHFO2YTQJUWT4D5"
+	botKeypair, err := keypair.Parse(botSeed)
+	require.NoError(t, err)
+	botAccount := internal.Account{AccountID: botKeypair.Address()}
+
+	minionSeed := "SDTNSEERJPJFUE2LSDNYBFHYGVTPIWY7TU2IOJZQQGLWO2THTGB7NU5A"
+	minionKeypair, err := keypair.Parse(minionSeed)
+	require.NoError(t, err)
+
+	// SimulateTransaction should never be called for G addresses
+	simulateCalled := false
+	mockNetworkClient := &mockNetworkClientWithSimulation{
+		simulateResult: &internal.SimulateTransactionResult{
+			TransactionDataXDR: "AAAAAAAAAAAAAAAAAAGGoAAAAAAAAAAAAAAAAAABhqA=",
+		},
+	}
+	// Wrap to track if simulate is called
+	originalSimulate := mockNetworkClient.SimulateTransaction
+	mockNetworkClient.simulateResult = nil
+	mockNetworkClient.simulateErr = nil
+
+	trackingClient := &trackingNetworkClient{mockNetworkClient, &simulateCalled}
+	minion := internal.Minion{
+		Account: internal.Account{
+			AccountID: minionKeypair.Address(),
+			Sequence:  1,
+		},
+		Keypair:              minionKeypair.(*keypair.Full),
+		BotAccount:           botAccount,
+		BotSigner:            internal.NewKeypairSigner(botKeypair.(*keypair.Full)),
+		NetworkClient:        trackingClient,
+		Network:              "Test SDF Network ; September 2015",
+		StartingBalance:      "10000.00",
+		SubmitTransaction:    mockSubmitTransaction,
+		CheckSequenceRefresh: internal.CheckSequenceRefresh,
+		CheckAccountExists:   mockCheckAccountExists,
+		BaseFee:              txnbuild.MinBaseFee,
+	}
+	_ = originalSimulate // suppress unused warning
+
+	fb := &internal.Bot{Minions: []internal.Minion{minion}, NetworkClient: trackingClient}
+	registerProblems()
+	cfg := Config{}
+	router := validateResponses(t, initRouter(cfg, fb))
+
+	// Use a G address (account)
+	accountAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+
+	req := httptest.NewRequest("GET", "/?addr="+url.QueryEscape(accountAddress), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, `"successful": true`)
+
+	// SimulateTransaction should NOT have been called for G addresses
+	assert.False(t, simulateCalled, "SimulateTransaction should not be called for G addresses")
+}
+
+// trackingNetworkClient wraps a network client to track if SimulateTransaction is called
+type trackingNetworkClient struct {
+	*mockNetworkClientWithSimulation
+	simulateCalled *bool
+}
+
+func (t *trackingNetworkClient) SimulateTransaction(ctx context.Context, txXDR string) (*internal.SimulateTransactionResult, error) {
+	*t.simulateCalled = true
+	return t.mockNetworkClientWithSimulation.SimulateTransaction(ctx, txXDR)
+}
+
+func (t *trackingNetworkClient) SupportsContractAddresses() bool {
+	return t.mockNetworkClientWithSimulation.SupportsContractAddresses()
+}
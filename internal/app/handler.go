@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"io"
+	stdhttp "net/http"
+
+	"github.com/stellar/friendbot/internal"
+)
+
+// NewHandler returns the public friendbot API configured by cfg and secrets,
+// without the admin server, treasury refills or autoscaling. Accounts are
+// funded through networkClient, or through the client configured by
+// horizon_url or rpc_url if it is nil.
+//
+// The returned func stops creating minions in the background and closes the
+// history store, and unregisters the handler's metrics. It must be called
+// once the handler is no longer used.
+func NewHandler(cfg Config, secrets Secrets, networkClient internal.NetworkClient) (stdhttp.Handler, func(), error) {
+	if err := checkParams(cfg, secrets); err != nil {
+		return nil, nil, err
+	}
+	if err := checkCORS(cfg, nil); err != nil {
+		return nil, nil, err
+	}
+	if networkClient == nil {
+		var err error
+		networkClient, err = newNetworkClient(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	fb, err := newFriendbot(ctx, cfg, secrets, networkClient)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	router := initRouter(cfg, fb)
+	registerProblems()
+
+	shutdown := func() {
+		cancel()
+		if closer, ok := fb.History.(io.Closer); ok {
+			closer.Close()
+		}
+		// Unregister the gauges of the queue and budget, so that handlers
+		// created and shut down repeatedly, as in tests, do not leak them.
+		fb.Queue.Close()
+		fb.Budget.Close()
+	}
+	return router, shutdown, nil
+}
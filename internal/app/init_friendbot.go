@@ -0,0 +1,430 @@
+package app
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/stellar/friendbot/internal"
+	"github.com/stellar/friendbot/internal/historystore"
+	"github.com/stellar/friendbot/internal/horizonnetworkclient"
+	"github.com/stellar/friendbot/internal/rpcnetworkclient"
+	"github.com/stellar/go-stellar-sdk/amount"
+	"github.com/stellar/go-stellar-sdk/clients/horizonclient"
+	"github.com/stellar/go-stellar-sdk/keypair"
+	"github.com/stellar/go-stellar-sdk/support/errors"
+	"github.com/stellar/go-stellar-sdk/txnbuild"
+)
+
+// historyBufferSize is the number of funding attempts waiting to be recorded
+// in the history store before further attempts are dropped.
+const historyBufferSize = 1000
+
+func initFriendbot(cfg Config, secrets Secrets) (*internal.Bot, error) {
+	if err := checkParams(cfg, secrets); err != nil {
+		return nil, err
+	}
+	networkClient, err := newNetworkClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newFriendbot(context.Background(), cfg, secrets, networkClient)
+}
+
+// checkParams returns an error if the settings friendbot cannot start without
+// are missing.
+func checkParams(cfg Config, secrets Secrets) error {
+	if (secrets.FriendbotSecret == "" && cfg.RemoteSignerURL == "") || cfg.NetworkPassphrase == "" || cfg.StartingBalance == "" || cfg.NumMinions < 0 {
+		return errors.New("invalid input param(s)")
+	}
+	return nil
+}
+
+// newFriendbot returns the bot for cfg, which funds accounts through
+// networkClient. Minions still being created in the background when it
+// returns stop being created once ctx is done.
+func newFriendbot(ctx context.Context, cfg Config, secrets Secrets, networkClient internal.NetworkClient) (*internal.Bot, error) {
+	networkInfo, err := checkNetwork(ctx, networkClient, cfg.NetworkPassphrase)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Connected to %q at protocol version %d", networkInfo.Passphrase, networkInfo.ProtocolVersion)
+
+	botSigner, botAccountID, err := initBotSigner(ctx, cfg, secrets, networkClient)
+	if err != nil {
+		return nil, err
+	}
+	botAccount := internal.Account{AccountID: botAccountID}
+	// set default values
+	minionBalance := "101.00"
+	numMinions := cfg.NumMinions
+	if numMinions == 0 {
+		numMinions = 1000
+	}
+	minionBatchSize := cfg.MinionBatchSize
+	if minionBatchSize == 0 {
+		minionBatchSize = 50
+	}
+	submitTxRetriesAllowed := cfg.SubmitTxRetriesAllowed
+	if submitTxRetriesAllowed == 0 {
+		submitTxRetriesAllowed = 5
+	}
+	settings, err := newSettings(cfg)
+	if err != nil {
+		return nil, err
+	}
+	replayTTL := time.Duration(cfg.ReplayTTLMs) * time.Millisecond
+	if replayTTL == 0 {
+		replayTTL = 5 * time.Second
+	}
+	memo, err := internal.ParseMemo(cfg.MemoType, cfg.Memo)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid memo")
+	}
+
+	history, err := newHistoryStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate that contract address funding is only enabled when using RPC
+	if cfg.FundContractAddresses && !networkClient.SupportsContractAddresses() {
+		return nil, errors.New("fund_contract_addresses is enabled but the network client does not support contract addresses; configure rpc_url instead of horizon_url to fund contract addresses")
+	}
+	if cfg.FundContractAddresses && !networkInfo.SupportsContracts() {
+		return nil, errors.Errorf("fund_contract_addresses is enabled but the network is at protocol version %d, and contracts require protocol version %d",
+			networkInfo.ProtocolVersion, internal.MinContractProtocolVersion)
+	}
+
+	log.Printf("Found all valid params, now creating %d minions", numMinions)
+	minionFactory := newMinionFactory(botAccount, botSigner, cfg.NetworkPassphrase, cfg.StartingBalance, minionBalance, minionBatchSize, submitTxRetriesAllowed, cfg.BaseFee, networkClient)
+	minionFactory.Concurrency = cfg.MinionCreationConcurrency
+	if minionFactory.Concurrency == 0 {
+		minionFactory.Concurrency = 4
+	}
+	// The minion store holds the minions' secret seeds, so it is only used
+	// when a path for it is configured.
+	if cfg.MinionStore != "none" && cfg.MinionStorePath != "" {
+		minionFactory.Store = internal.NewFileMinionStore(cfg.MinionStorePath, botAccount.AccountID, cfg.NetworkPassphrase)
+	}
+	minReadyMinions := cfg.MinReadyMinions
+	if minReadyMinions == 0 {
+		minReadyMinions = min(minionBatchSize, numMinions)
+	}
+	minions, err := initMinions(ctx, minionFactory, min(minReadyMinions, numMinions))
+	if err != nil {
+		return nil, err
+	}
+
+	budget := internal.NewBudgetTracker(settings.HourlyBudget, settings.DailyBudget)
+	if history != nil {
+		if err := budget.Restore(ctx, history); err != nil {
+			return nil, errors.Wrap(err, "restoring the budget")
+		}
+	}
+
+	fb := &internal.Bot{
+		Minions:               minions,
+		NetworkClient:         networkClient,
+		FundContractAddresses: cfg.FundContractAddresses,
+		Queue:                 internal.NewMinionQueue(len(minions), settings.QueueMaxDepth, settings.QueueMaxWait),
+		ReplayTTL:             replayTTL,
+		History:               history,
+		Budget:                budget,
+		MinionFactory:         minionFactory,
+		ProtocolVersion:       networkInfo.ProtocolVersion,
+		Memo:                  memo,
+	}
+	if len(minions) < numMinions {
+		log.Printf("Serving requests with %d minions while the remaining %d are created", len(minions), numMinions-len(minions))
+		go fb.FillMinions(ctx, numMinions)
+	}
+	return fb, nil
+}
+
+// initBotSigner returns the signer for the bot account, and the account's ID.
+// The keys the signer signs with are checked against the on-chain signers of
+// the account.
+func initBotSigner(ctx context.Context, cfg Config, secrets Secrets, networkClient internal.NetworkClient) (internal.Signer, string, error) {
+	if cfg.RemoteSignerURL != "" {
+		signer := internal.NewRemoteSigner(cfg.RemoteSignerURL, secrets.RemoteSignerToken)
+		publicKeys, err := signer.PublicKeys(ctx)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "getting remote signer public keys")
+		}
+		needed, err := checkBotSigners(ctx, networkClient, cfg.FriendbotAccountID, publicKeys, cfg.SigningThreshold)
+		if err != nil {
+			return nil, "", err
+		}
+		if needed < len(publicKeys) {
+			return nil, "", errors.Errorf("remote signer signs with %d keys, but only %d are needed to reach the signing threshold; transactions carrying unneeded signatures are rejected",
+				len(publicKeys), needed)
+		}
+		log.Printf("Signing friendbot account operations with remote signer %s", cfg.RemoteSignerURL)
+		return signer, cfg.FriendbotAccountID, nil
+	}
+
+	keypairs, err := parseBotKeypairs(secrets)
+	if err != nil {
+		return nil, "", err
+	}
+	botKeypair := keypairs[0]
+	publicKeys := make([]string, len(keypairs))
+	for i, kp := range keypairs {
+		publicKeys[i] = kp.Address()
+	}
+
+	needed, err := checkBotSigners(ctx, networkClient, botKeypair.Address(), publicKeys, cfg.SigningThreshold)
+	if err != nil {
+		return nil, "", err
+	}
+	if needed > 1 {
+		log.Printf("Signing friendbot account operations with %d of %d keys", needed, len(keypairs))
+	}
+	return internal.NewKeypairSigner(keypairs[:needed]...), botKeypair.Address(), nil
+}
+
+// parseBotKeypairs returns the keypairs of friendbot_secret followed by those
+// of friendbot_signer_secrets.
+func parseBotKeypairs(secrets Secrets) ([]*keypair.Full, error) {
+	botKeypair, err := keypair.ParseFull(secrets.FriendbotSecret)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing friendbot_secret")
+	}
+	keypairs := []*keypair.Full{botKeypair}
+	for i, secret := range secrets.FriendbotSignerSecrets {
+		kp, err := keypair.ParseFull(secret)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing friendbot_signer_secrets[%d]", i)
+		}
+		keypairs = append(keypairs, kp)
+	}
+	return keypairs, nil
+}
+
+// checkBotSigners checks publicKeys against the on-chain signers of the bot
+// account, and returns how many of them are needed to reach the signing
+// threshold.
+func checkBotSigners(ctx context.Context, networkClient internal.NetworkClient, botAccountID string, publicKeys []string, threshold int) (int, error) {
+	details, err := networkClient.GetAccountDetails(ctx, botAccountID)
+	if err != nil {
+		return 0, errors.Wrap(err, "getting bot account details")
+	}
+	needed, err := internal.SelectBotSigners(botAccountID, details, publicKeys, int32(threshold))
+	if err != nil {
+		return 0, errors.Wrap(err, "checking bot signing keys")
+	}
+	return needed, nil
+}
+
+// initMinions returns the minions resumed from the minion store, topped up
+// with new minions until at least minReadyMinions are ready. It fails if fewer
+// than minReadyMinions could be created.
+func initMinions(ctx context.Context, factory *internal.MinionFactory, minReadyMinions int) ([]internal.Minion, error) {
+	minions, err := factory.Resume(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "resuming minion accounts")
+	}
+	if len(minions) < minReadyMinions {
+		created, err := factory.Create(ctx, minReadyMinions-len(minions))
+		minions = append(minions, created...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating minion accounts: only %d of the %d minions required to start are ready", len(minions), minReadyMinions)
+		}
+	}
+	log.Printf("Adding %d minions to friendbot", len(minions))
+	return minions, nil
+}
+
+func newNetworkClient(cfg Config) (internal.NetworkClient, error) {
+	if cfg.HorizonURL != "" && cfg.RPCURL != "" {
+		return nil, errors.New("only one of horizon_url or rpc_url should be provided, not both")
+	}
+	if cfg.RPCURL != "" {
+		return rpcnetworkclient.NewNetworkClient(cfg.RPCURL, http.DefaultClient, cfg.NetworkPassphrase), nil
+	}
+	if cfg.HorizonURL != "" {
+		return horizonnetworkclient.NewNetworkClient(&horizonclient.Client{
+			HorizonURL: cfg.HorizonURL,
+			HTTP:       http.DefaultClient,
+			AppName:    "friendbot",
+		}), nil
+	}
+	return nil, errors.New("either horizon_url or rpc_url must be provided")
+}
+
+// checkNetwork checks that networkClient is connected to the network of
+// networkPassphrase, and returns its details. Transactions signed for another
+// network would all be rejected.
+func checkNetwork(ctx context.Context, networkClient internal.NetworkClient, networkPassphrase string) (*internal.NetworkInfo, error) {
+	info, err := networkClient.NetworkInfo(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting upstream network info")
+	}
+	if info.Passphrase != networkPassphrase {
+		return nil, errors.Errorf("network_passphrase %q does not match the upstream network passphrase %q", networkPassphrase, info.Passphrase)
+	}
+	return info, nil
+}
+
+// newSettings returns the settings of the bot that can be changed by reloading
+// the config, with defaults applied.
+func newSettings(cfg Config) (internal.Settings, error) {
+	settings := internal.Settings{
+		StartingBalance:       cfg.StartingBalance,
+		BaseFee:               cfg.BaseFee,
+		FundContractAddresses: cfg.FundContractAddresses,
+		QueueMaxDepth:         cfg.QueueMaxDepth,
+		QueueMaxWait:          time.Duration(cfg.QueueMaxWaitMs) * time.Millisecond,
+	}
+	if settings.QueueMaxDepth == 0 {
+		settings.QueueMaxDepth = 1000
+	}
+	if settings.QueueMaxWait == 0 {
+		settings.QueueMaxWait = 10 * time.Second
+	}
+	for _, budget := range []struct {
+		name, value string
+		cap         *int64
+	}{
+		{"hourly_budget", cfg.HourlyBudget, &settings.HourlyBudget},
+		{"daily_budget", cfg.DailyBudget, &settings.DailyBudget},
+	} {
+		if budget.value == "" {
+			continue
+		}
+		stroops, err := amount.ParseInt64(budget.value)
+		if err != nil || stroops <= 0 {
+			return internal.Settings{}, errors.Errorf("invalid %s %q: must be a positive amount of XLM", budget.name, budget.value)
+		}
+		*budget.cap = stroops
+	}
+	return settings, nil
+}
+
+// initTreasury returns the treasury configured by treasury_secret, or nil if
+// refilling the friendbot account is disabled. The refills are recorded in
+// history, if it is not nil.
+func initTreasury(ctx context.Context, cfg Config, secrets Secrets, networkClient internal.NetworkClient, botAccountID string, history internal.HistoryStore) (*internal.Treasury, error) {
+	if secrets.TreasurySecret == "" {
+		return nil, nil
+	}
+	treasuryKP, err := keypair.ParseFull(secrets.TreasurySecret)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing treasury keypair")
+	}
+	watermark, target, maxDailyRefill, err := parseTreasuryAmounts(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	baseFee := cfg.BaseFee
+	if baseFee < txnbuild.MinBaseFee {
+		baseFee = txnbuild.MinBaseFee
+	}
+	log.Printf("Refilling bot account from treasury %s when its balance drops below %s XLM", treasuryKP.Address(), cfg.TreasuryWatermark)
+	treasury := internal.NewTreasury(treasuryKP.Address(), internal.NewKeypairSigner(treasuryKP), botAccountID, networkClient, cfg.NetworkPassphrase, baseFee, watermark, target, maxDailyRefill)
+	treasury.History = history
+	if err := treasury.Restore(ctx); err != nil {
+		return nil, errors.Wrap(err, "restoring the treasury refills")
+	}
+	return treasury, nil
+}
+
+// parseTreasuryAmounts returns treasury_watermark, treasury_target and
+// treasury_max_daily_refill in stroops.
+func parseTreasuryAmounts(cfg Config) (watermark, target, maxDailyRefill int64, err error) {
+	var amounts [3]int64
+	for i, setting := range []struct{ name, value string }{
+		{"treasury_watermark", cfg.TreasuryWatermark},
+		{"treasury_target", cfg.TreasuryTarget},
+		{"treasury_max_daily_refill", cfg.TreasuryMaxDailyRefill},
+	} {
+		stroops, err := amount.ParseInt64(setting.value)
+		if err != nil || stroops <= 0 {
+			return 0, 0, 0, errors.Errorf("invalid %s %q: must be a positive amount of XLM when treasury_secret is set", setting.name, setting.value)
+		}
+		amounts[i] = stroops
+	}
+	if amounts[1] <= amounts[0] {
+		return 0, 0, 0, errors.New("treasury_target must be greater than treasury_watermark")
+	}
+	return amounts[0], amounts[1], amounts[2], nil
+}
+
+// treasuryCheckInterval returns how often the bot balance is checked for a
+// treasury refill.
+func treasuryCheckInterval(cfg Config) time.Duration {
+	if cfg.TreasuryCheckSeconds == 0 {
+		return time.Minute
+	}
+	return time.Duration(cfg.TreasuryCheckSeconds) * time.Second
+}
+
+// initAutoscaler returns the autoscaler for the minion pool, or nil if
+// autoscale_max_minions is not set.
+func initAutoscaler(cfg Config, fb *internal.Bot) *internal.Autoscaler {
+	if cfg.AutoscaleMaxMinions == 0 {
+		return nil
+	}
+	step := cfg.AutoscaleStep
+	if step == 0 {
+		step = fb.MinionFactory.BatchSize
+	}
+	autoscaler := internal.NewAutoscaler(fb, cfg.AutoscaleMinMinions, cfg.AutoscaleMaxMinions, step)
+	if cfg.AutoscaleScaleUpWaitMs != 0 {
+		autoscaler.ScaleUpWait = time.Duration(cfg.AutoscaleScaleUpWaitMs) * time.Millisecond
+	}
+	if cfg.AutoscaleScaleDownSecs != 0 {
+		autoscaler.ScaleDownAfter = time.Duration(cfg.AutoscaleScaleDownSecs) * time.Second
+	}
+	log.Printf("Autoscaling the minion pool between %d and %d minions", cfg.AutoscaleMinMinions, cfg.AutoscaleMaxMinions)
+	return autoscaler
+}
+
+// autoscaleInterval returns how often the autoscaler checks the minion pool.
+func autoscaleInterval(cfg Config) time.Duration {
+	if cfg.AutoscaleIntervalSecs == 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(cfg.AutoscaleIntervalSecs) * time.Second
+}
+
+// newHistoryStore returns the store configured by history_store, which is
+// SQLite unless it is set to none, in which case nil is returned. Attempts are
+// recorded in the background and kept for history_retention_days.
+func newHistoryStore(cfg Config) (internal.HistoryStore, error) {
+	if cfg.HistoryStore == "none" {
+		return nil, nil
+	}
+	path := cfg.HistoryStorePath
+	if path == "" {
+		path = "friendbot-history.db"
+	}
+	store, err := historystore.NewSQLiteStore(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening history store")
+	}
+	retentionDays := cfg.HistoryRetentionDays
+	if retentionDays == 0 {
+		retentionDays = 30
+	}
+	log.Printf("Recording funding history to %s for %d days", path, retentionDays)
+	return internal.NewHistoryRecorder(store, historyBufferSize, time.Duration(retentionDays)*24*time.Hour), nil
+}
+
+func newMinionFactory(botAccount internal.Account, botSigner internal.Signer, networkPassphrase, newAccountBalance, minionBalance string,
+	minionBatchSize, submitTxRetriesAllowed int, baseFee int64, networkClient internal.NetworkClient) *internal.MinionFactory {
+	return &internal.MinionFactory{
+		BotAccount:             botAccount,
+		BotSigner:              botSigner,
+		NetworkClient:          networkClient,
+		Network:                networkPassphrase,
+		StartingBalance:        newAccountBalance,
+		MinionBalance:          minionBalance,
+		BatchSize:              minionBatchSize,
+		SubmitTxRetriesAllowed: submitTxRetriesAllowed,
+		BaseFee:                baseFee,
+	}
+}
@@ -1,6 +1,7 @@
-package main
+package app
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
@@ -15,7 +16,7 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
-func TestInitFriendbot_createMinionAccounts_success(t *testing.T) {
+func TestInitFriendbot_newMinionFactory_success(t *testing.T) {
 
 	randSecretKey := "SDLNA2YUQSFIWVEB57M6D3OOCJHFVCVQZJ33LPA656KJESVRK5DQUZOH"
 	botKP, err := keypair.Parse(randSecretKey)
@@ -43,13 +44,14 @@ func TestInitFriendbot_createMinionAccounts_success(t *testing.T) {
 	minionBatchSize := 50
 	submitTxRetriesAllowed := 5
 	networkClient := horizonnetworkclient.NewNetworkClient(&horizonClientMock)
-	createdMinions, err := createMinionAccounts(botAccount, botKeypair, "Test SDF Network ; September 2015", "10000", "101", numMinion, minionBatchSize, submitTxRetriesAllowed, 1000, networkClient)
+	factory := newMinionFactory(botAccount, internal.NewKeypairSigner(botKeypair), "Test SDF Network ; September 2015", "10000", "101", minionBatchSize, submitTxRetriesAllowed, 1000, networkClient)
+	createdMinions, err := factory.Create(context.Background(), numMinion)
 	assert.NoError(t, err)
 
 	assert.Equal(t, 1000, len(createdMinions))
 }
 
-func TestInitFriendbot_createMinionAccounts_timeoutError(t *testing.T) {
+func TestInitFriendbot_newMinionFactory_timeoutError(t *testing.T) {
 	randSecretKey := "SDLNA2YUQSFIWVEB57M6D3OOCJHFVCVQZJ33LPA656KJESVRK5DQUZOH"
 	botKP, err := keypair.Parse(randSecretKey)
 	assert.NoError(t, err)
@@ -88,7 +90,8 @@ func TestInitFriendbot_createMinionAccounts_timeoutError(t *testing.T) {
 	minionBatchSize := 50
 	submitTxRetriesAllowed := 5
 	networkClient := horizonnetworkclient.NewNetworkClient(&horizonClientMock)
-	createdMinions, err := createMinionAccounts(botAccount, botKeypair, "Test SDF Network ; September 2015", "10000", "101", numMinion, minionBatchSize, submitTxRetriesAllowed, 1000, networkClient)
+	factory := newMinionFactory(botAccount, internal.NewKeypairSigner(botKeypair), "Test SDF Network ; September 2015", "10000", "101", minionBatchSize, submitTxRetriesAllowed, 1000, networkClient)
+	createdMinions, err := factory.Create(context.Background(), numMinion)
 	assert.Equal(t, 150, len(createdMinions))
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "after retrying 5 times: submitting create accounts tx:")
@@ -138,3 +141,19 @@ func TestNewNetworkClient(t *testing.T) {
 		assert.Equal(t, "https://rpc.stellar.org", rpcClient.URL())
 	})
 }
+
+func TestCheckNetwork(t *testing.T) {
+	horizonClientMock := horizonclient.MockClient{}
+	horizonClientMock.On("Root").Return(horizon.Root{
+		NetworkPassphrase:      "Test SDF Network ; September 2015",
+		CurrentProtocolVersion: 22,
+	}, nil)
+	networkClient := horizonnetworkclient.NewNetworkClient(&horizonClientMock)
+
+	info, err := checkNetwork(context.Background(), networkClient, "Test SDF Network ; September 2015")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(22), info.ProtocolVersion)
+
+	_, err = checkNetwork(context.Background(), networkClient, "Public Global Stellar Network ; September 2015")
+	assert.EqualError(t, err, `network_passphrase "Public Global Stellar Network ; September 2015" does not match the upstream network passphrase "Test SDF Network ; September 2015"`)
+}
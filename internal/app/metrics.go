@@ -0,0 +1,25 @@
+package app
+
+import (
+	stdhttp "net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+
+	"github.com/stellar/go-stellar-sdk/support/errors"
+)
+
+// initMetrics installs a global OpenTelemetry meter provider that exports to
+// a Prometheus registry, and returns the handler serving that registry.
+func initMetrics() (stdhttp.Handler, error) {
+	registry := prometheus.NewRegistry()
+	exporter, err := otelprometheus.New(otelprometheus.WithRegisterer(registry))
+	if err != nil {
+		return nil, errors.Wrap(err, "creating prometheus exporter")
+	}
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(exporter)))
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), nil
+}
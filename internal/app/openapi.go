@@ -0,0 +1,30 @@
+package app
+
+import (
+	_ "embed"
+	stdhttp "net/http"
+)
+
+// openAPIDocument is the OpenAPI document describing every route, response
+// and problem type of the public API. The API tests validate the responses of
+// the handlers against it.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// adminOpenAPIDocument is the OpenAPI document of the admin API, which is
+// served on the admin port only so that the public document does not expose
+// the operator endpoints.
+//
+//go:embed admin_openapi.json
+var adminOpenAPIDocument []byte
+
+// serveOpenAPI returns a handler serving document, so that clients can be
+// generated from the running friendbot.
+func serveOpenAPI(document []byte) stdhttp.HandlerFunc {
+	return func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(stdhttp.StatusOK)
+		w.Write(document)
+	}
+}
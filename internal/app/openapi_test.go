@@ -0,0 +1,273 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stellar/friendbot/internal"
+	"github.com/stellar/friendbot/internal/historystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// openAPISpec is an OpenAPI document, parsed for validating responses
+// against.
+type openAPISpec struct {
+	doc   map[string]any
+	paths []openAPIPath
+}
+
+// openAPIPath is a path of the document, with the pattern matching the
+// request paths it describes.
+type openAPIPath struct {
+	template string
+	pattern  *regexp.Regexp
+	item     map[string]any
+}
+
+func loadOpenAPISpec(t testing.TB, document []byte) *openAPISpec {
+	t.Helper()
+	spec := &openAPISpec{}
+	require.NoError(t, json.Unmarshal(document, &spec.doc))
+	paths, ok := spec.doc["paths"].(map[string]any)
+	require.True(t, ok, "the OpenAPI document has no paths")
+	for template, item := range paths {
+		segments := strings.Split(template, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, "{") {
+				segments[i] = `[^/]+`
+			} else {
+				segments[i] = regexp.QuoteMeta(segment)
+			}
+		}
+		spec.paths = append(spec.paths, openAPIPath{
+			template: template,
+			pattern:  regexp.MustCompile("^" + strings.Join(segments, "/") + "$"),
+			item:     item.(map[string]any),
+		})
+	}
+	return spec
+}
+
+// operation returns the operation of the document for the method and the path
+// of a request, or nil if it is not documented. found reports whether the path
+// is documented.
+func (spec *openAPISpec) operation(method, path string) (operation map[string]any, found bool) {
+	for _, p := range spec.paths {
+		if p.template == path || p.pattern.MatchString(path) {
+			operation, _ = p.item[strings.ToLower(method)].(map[string]any)
+			return operation, true
+		}
+	}
+	return nil, false
+}
+
+// resolve returns the object referenced by the $ref of v, or v if it is not a
+// reference.
+func (spec *openAPISpec) resolve(v map[string]any) map[string]any {
+	ref, ok := v["$ref"].(string)
+	if !ok {
+		return v
+	}
+	var target any = spec.doc
+	for _, name := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		target = target.(map[string]any)[name]
+	}
+	return target.(map[string]any)
+}
+
+// validateResponse checks that the response to a request with the method and
+// path has a status, content type and body documented by the spec.
+func (spec *openAPISpec) validateResponse(method, path string, w *httptest.ResponseRecorder) error {
+	operation, found := spec.operation(method, path)
+	switch {
+	case operation != nil:
+	case found:
+		// Requests with undocumented methods for documented paths are not
+		// allowed.
+		if w.Code != http.StatusMethodNotAllowed {
+			return fmt.Errorf("%s %s is not documented, but responded with %d", method, path, w.Code)
+		}
+		return nil
+	default:
+		// Requests for undocumented paths are not found.
+		if w.Code != http.StatusNotFound {
+			return fmt.Errorf("%s %s is not documented, but responded with %d", method, path, w.Code)
+		}
+		operation = map[string]any{"responses": map[string]any{
+			"404": map[string]any{"$ref": "#/components/responses/NotFound"},
+		}}
+	}
+
+	responses, _ := operation["responses"].(map[string]any)
+	response, ok := responses[strconv.Itoa(w.Code)].(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s %s responded with undocumented status %d", method, path, w.Code)
+	}
+	response = spec.resolve(response)
+
+	content, _ := response["content"].(map[string]any)
+	if len(content) == 0 {
+		if w.Body.Len() > 0 {
+			return fmt.Errorf("%s %s responded with a body to %d, which is documented without one", method, path, w.Code)
+		}
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("%s %s responded with an invalid Content-Type: %w", method, path, err)
+	}
+	mediaTypeObject, ok := content[mediaType].(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s %s responded to %d with undocumented content type %s", method, path, w.Code, mediaType)
+	}
+	if !strings.HasSuffix(mediaType, "json") {
+		return nil
+	}
+
+	// The schema is validated in a document with the components of the spec,
+	// so that its references resolve.
+	schema := map[string]any{
+		"components": spec.doc["components"],
+		"allOf":      []any{mediaTypeObject["schema"]},
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewGoLoader(schema), gojsonschema.NewBytesLoader(w.Body.Bytes()))
+	if err != nil {
+		return fmt.Errorf("%s %s responded to %d with a body that could not be validated: %w", method, path, w.Code, err)
+	}
+	if !result.Valid() {
+		var errs []string
+		for _, e := range result.Errors() {
+			errs = append(errs, e.String())
+		}
+		return fmt.Errorf("%s %s responded to %d with a body that does not match the OpenAPI document: %s", method, path, w.Code, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// validateResponses returns a handler serving requests with the public
+// handler, which fails the test if a response does not match the public
+// OpenAPI document.
+func validateResponses(t *testing.T, handler http.Handler) http.Handler {
+	return validateResponsesAgainst(t, loadOpenAPISpec(t, openAPIDocument), handler)
+}
+
+// validateAdminResponses returns a handler serving requests with the admin
+// handler, which fails the test if a response does not match the admin
+// OpenAPI document.
+func validateAdminResponses(t *testing.T, handler http.Handler) http.Handler {
+	return validateResponsesAgainst(t, loadOpenAPISpec(t, adminOpenAPIDocument), handler)
+}
+
+func validateResponsesAgainst(t *testing.T, spec *openAPISpec, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		// CORS preflight requests are answered before routing, for every
+		// path, and are not documented.
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if !preflight {
+			assert.NoError(t, spec.validateResponse(r.Method, r.URL.Path, rec))
+		}
+
+		for key, values := range rec.Header() {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(rec.Code)
+		w.Write(rec.Body.Bytes())
+	})
+}
+
+func TestOpenAPI_Served(t *testing.T) {
+	router := setup(t)
+
+	req := httptest.NewRequest("GET", "/openapi.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, string(openAPIDocument), w.Body.String())
+
+	// The admin document is only served on the admin port.
+	adminRouter := validateAdminResponses(t, initAdminRouter(setupBot(t), "test-token", http.NotFoundHandler(), nil))
+	w = httptest.NewRecorder()
+	adminRouter.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, string(adminOpenAPIDocument), w.Body.String())
+}
+
+func TestOpenAPI_DocumentsEveryRoute(t *testing.T) {
+	fb := setupBot(t)
+	// Serve the routes that are only served with a history store, and with
+	// a config to reload.
+	history, err := historystore.NewSQLiteStore(filepath.Join(t.TempDir(), "history.db"))
+	require.NoError(t, err)
+	defer history.Close()
+	fb.History = history
+	routers := []struct {
+		router chi.Routes
+		spec   *openAPISpec
+	}{
+		{initRouter(Config{}, fb), loadOpenAPISpec(t, openAPIDocument)},
+		{initAdminRouter(fb, "test-token", http.NotFoundHandler(), &configReloader{}), loadOpenAPISpec(t, adminOpenAPIDocument)},
+	}
+
+	for _, r := range routers {
+		routes := map[string]bool{}
+		err := chi.Walk(r.router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+			operation, _ := r.spec.operation(method, route)
+			assert.NotNil(t, operation, "%s %s is not documented", method, route)
+			routes[strings.ToLower(method)+" "+route] = true
+			return nil
+		})
+		require.NoError(t, err)
+
+		// Every documented operation is served by the router, so that the
+		// document does not describe the routes of the other one.
+		for _, p := range r.spec.paths {
+			for method := range p.item {
+				assert.True(t, routes[method+" "+p.template], "%s %s is documented but not served", method, p.template)
+			}
+		}
+	}
+}
+
+func TestOpenAPI_FundRequestSchema(t *testing.T) {
+	spec := loadOpenAPISpec(t, openAPIDocument)
+
+	schema := spec.doc["components"].(map[string]any)["schemas"].(map[string]any)["FundRequest"]
+	documented, err := json.Marshal(schema)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(internal.FundRequestSchema), string(documented))
+}
+
+func TestOpenAPI_ValidateResponse(t *testing.T) {
+	spec := loadOpenAPISpec(t, openAPIDocument)
+	adminSpec := loadOpenAPISpec(t, adminOpenAPIDocument)
+
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Type", "application/hal+json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.WriteString(`{"paused": "no"}`)
+	assert.ErrorContains(t, adminSpec.validateResponse("POST", "/funding/pause", w), "does not match the OpenAPI document")
+
+	w = httptest.NewRecorder()
+	w.WriteHeader(http.StatusTeapot)
+	assert.ErrorContains(t, spec.validateResponse("GET", "/", w), "undocumented status 418")
+
+	w = httptest.NewRecorder()
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(http.StatusOK)
+	assert.ErrorContains(t, spec.validateResponse("GET", "/", w), "undocumented content type text/html")
+}
@@ -0,0 +1,175 @@
+package app
+
+import (
+	"fmt"
+	stdhttp "net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/stellar/friendbot/internal"
+	"github.com/stellar/go-stellar-sdk/support/errors"
+	"github.com/stellar/go-stellar-sdk/support/log"
+	"github.com/stellar/go-stellar-sdk/support/render/hal"
+	"github.com/stellar/go-stellar-sdk/support/render/problem"
+)
+
+// reloadableSettings are the config settings that are applied to a running
+// friendbot when its config is reloaded. Changing any other setting requires a
+// restart.
+var reloadableSettings = map[string]bool{
+	"starting_balance":        true,
+	"base_fee":                true,
+	"fund_contract_addresses": true,
+	"hourly_budget":           true,
+	"daily_budget":            true,
+	"queue_max_depth":         true,
+	"queue_max_wait_ms":       true,
+}
+
+// configReloadFailedProblem is returned by the admin endpoint reloading the
+// config when the new config is invalid or changes settings that require a
+// restart.
+var configReloadFailedProblem = problem.P{
+	Type:   "config_reload_failed",
+	Title:  "Config Reload Failed",
+	Status: stdhttp.StatusUnprocessableEntity,
+}
+
+// settingChange is a config setting changed by a reload.
+type settingChange struct {
+	Key string `json:"key"`
+	Old any    `json:"old"`
+	New any    `json:"new"`
+}
+
+func (c settingChange) String() string {
+	return fmt.Sprintf("%s: %v -> %v", c.Key, c.Old, c.New)
+}
+
+// configReloadResult is the response to reloading the config.
+type configReloadResult struct {
+	Changes []settingChange `json:"changes"`
+}
+
+// configReloader reloads the config files friendbot was started with, and
+// applies the changed settings to the running bot.
+type configReloader struct {
+	cfgPath           string
+	secretPath        string
+	signerSecretPaths []string
+	bot               *internal.Bot
+
+	mu  sync.Mutex
+	cfg Config
+}
+
+func newConfigReloader(cfg Config, bot *internal.Bot, cfgPath, secretPath string, signerSecretPaths []string) *configReloader {
+	return &configReloader{
+		cfgPath:           cfgPath,
+		secretPath:        secretPath,
+		signerSecretPaths: signerSecretPaths,
+		bot:               bot,
+		cfg:               cfg,
+	}
+}
+
+// Reload reads the config again and applies the changed settings to the bot,
+// returning them. The reload is rejected as a whole if the config is invalid
+// or changes a setting that requires a restart. trigger describes what
+// requested the reload, and is recorded in the log. Secrets are not reloaded.
+func (r *configReloader) Reload(trigger string) ([]settingChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	logger := log.WithField("trigger", trigger)
+
+	cfg, _, err := loadConfig(r.cfgPath, r.secretPath, r.signerSecretPaths...)
+	if err != nil {
+		err = errors.Wrap(err, "rejected config reload")
+		logger.Error(err)
+		return nil, err
+	}
+
+	changes := diffConfig(r.cfg, cfg)
+	var restartRequired []string
+	for _, change := range changes {
+		if !reloadableSettings[change.Key] {
+			restartRequired = append(restartRequired, change.Key)
+		}
+	}
+	if len(restartRequired) > 0 {
+		err := errors.Errorf("rejected config reload: changing %s requires a restart", strings.Join(restartRequired, ", "))
+		logger.Error(err)
+		return nil, err
+	}
+	if len(changes) == 0 {
+		logger.Info("Reloaded config with no changes")
+		return nil, nil
+	}
+
+	settings, err := newSettings(cfg)
+	if err == nil {
+		err = r.bot.Reconfigure(settings)
+	}
+	if err != nil {
+		err = errors.Wrap(err, "rejected config reload")
+		logger.Error(err)
+		return nil, err
+	}
+	r.cfg = cfg
+
+	descriptions := make([]string, len(changes))
+	for i, change := range changes {
+		descriptions[i] = change.String()
+	}
+	logger.WithFields(log.F{
+		"audit":   "config_reload",
+		"changes": strings.Join(descriptions, ", "),
+	}).Info("Applied config reload")
+	return changes, nil
+}
+
+// ReloadOnSignal reloads the config every time friendbot receives SIGHUP.
+func (r *configReloader) ReloadOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		// Failures are logged by Reload, and the previous config stays in
+		// effect.
+		r.Reload("SIGHUP")
+	}
+}
+
+// HandleReload serves admin requests to reload the config.
+func (r *configReloader) HandleReload(w stdhttp.ResponseWriter, req *stdhttp.Request) {
+	changes, err := r.Reload("admin API")
+	if err != nil {
+		p := configReloadFailedProblem
+		p.Detail = err.Error()
+		problem.Render(req.Context(), w, p)
+		return
+	}
+	hal.Render(w, configReloadResult{Changes: changes})
+}
+
+// diffConfig returns the settings that differ between the running and the
+// reloaded config, in the order they are declared in Config.
+func diffConfig(running, reloaded Config) []settingChange {
+	var changes []settingChange
+	runningValue, reloadedValue := reflect.ValueOf(running), reflect.ValueOf(reloaded)
+	for i := 0; i < runningValue.NumField(); i++ {
+		field := runningValue.Type().Field(i)
+		key := field.Tag.Get("toml")
+		if key == "" {
+			key = strings.ToLower(field.Name)
+		}
+		before, after := runningValue.Field(i).Interface(), reloadedValue.Field(i).Interface()
+		if !reflect.DeepEqual(before, after) {
+			changes = append(changes, settingChange{Key: key, Old: before, New: after})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const reloadTestConfig = `
+port = 8000
+friendbot_secret = "SCZANGBA5YHTNYVVV3C7CAZMTQDBJHJG6C34CIRY52VDRRW3DPQUTZY2"
+network_passphrase = "Test SDF Network ; September 2015"
+starting_balance = "10000.00"
+base_fee = 100
+`
+
+func TestConfigReloader(t *testing.T) {
+	confFile := filepath.Join(t.TempDir(), "friendbot.cfg")
+	require.NoError(t, os.WriteFile(confFile, []byte(reloadTestConfig), 0600))
+	cfg, _, err := loadConfig(confFile, "")
+	require.NoError(t, err)
+	fb := setupBot(t)
+	reloader := newConfigReloader(cfg, fb, confFile, "", nil)
+
+	t.Run("no changes", func(t *testing.T) {
+		changes, err := reloader.Reload("test")
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("applies reloadable settings", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(confFile, []byte(`
+port = 8000
+friendbot_secret = "SCZANGBA5YHTNYVVV3C7CAZMTQDBJHJG6C34CIRY52VDRRW3DPQUTZY2"
+network_passphrase = "Test SDF Network ; September 2015"
+starting_balance = "5000.00"
+base_fee = 200
+`), 0600))
+
+		changes, err := reloader.Reload("test")
+		require.NoError(t, err)
+		assert.Equal(t, []settingChange{
+			{Key: "starting_balance", Old: "10000.00", New: "5000.00"},
+			{Key: "base_fee", Old: int64(100), New: int64(200)},
+		}, changes)
+		assert.Equal(t, "5000.00", fb.Minions[0].StartingBalance)
+		assert.Equal(t, int64(200), fb.Minions[0].BaseFee)
+	})
+
+	t.Run("rejects settings requiring a restart", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(confFile, []byte(`
+port = 8001
+num_minions = 10
+friendbot_secret = "SCZANGBA5YHTNYVVV3C7CAZMTQDBJHJG6C34CIRY52VDRRW3DPQUTZY2"
+network_passphrase = "Test SDF Network ; September 2015"
+starting_balance = "1.00"
+base_fee = 200
+`), 0600))
+
+		_, err := reloader.Reload("test")
+		assert.EqualError(t, err, "rejected config reload: changing port, num_minions requires a restart")
+		// The reloadable settings changed alongside are not applied either.
+		assert.Equal(t, "5000.00", fb.Minions[0].StartingBalance)
+	})
+
+	t.Run("rejects invalid settings", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(confFile, []byte(`
+port = 8000
+friendbot_secret = "SCZANGBA5YHTNYVVV3C7CAZMTQDBJHJG6C34CIRY52VDRRW3DPQUTZY2"
+network_passphrase = "Test SDF Network ; September 2015"
+starting_balance = "5000.00"
+base_fee = 200
+fund_contract_addresses = true
+`), 0600))
+
+		_, err := reloader.Reload("test")
+		assert.ErrorContains(t, err, "funding contract addresses is not supported")
+		assert.False(t, fb.SupportsContractAddresses())
+	})
+}
+
+func TestAdminAPI_ConfigReload(t *testing.T) {
+	confFile := filepath.Join(t.TempDir(), "friendbot.cfg")
+	require.NoError(t, os.WriteFile(confFile, []byte(reloadTestConfig), 0600))
+	cfg, _, err := loadConfig(confFile, "")
+	require.NoError(t, err)
+	fb := setupBot(t)
+	registerProblems()
+	adminRouter := validateAdminResponses(t, initAdminRouter(fb, "test-token", http.NotFoundHandler(), newConfigReloader(cfg, fb, confFile, "", nil)))
+
+	require.NoError(t, os.WriteFile(confFile, []byte(reloadTestConfig+"queue_max_wait_ms = 500\n"), 0600))
+	req := httptest.NewRequest("POST", "/config/reload", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	adminRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"changes": [{"key": "queue_max_wait_ms", "old": 0, "new": 500}]}`, w.Body.String())
+
+	require.NoError(t, os.WriteFile(confFile, []byte(reloadTestConfig+"queue_max_wait_ms = 500\nadmin_port = 9000\n"), 0600))
+	w = httptest.NewRecorder()
+	adminRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "changing admin_port requires a restart")
+}
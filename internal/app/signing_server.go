@@ -0,0 +1,82 @@
+package app
+
+import (
+	"fmt"
+	stdhttp "net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stellar/friendbot/internal"
+	"github.com/stellar/go-stellar-sdk/keypair"
+	"github.com/stellar/go-stellar-sdk/support/config"
+	"github.com/stellar/go-stellar-sdk/support/errors"
+	"github.com/stellar/go-stellar-sdk/support/log"
+)
+
+// newSigningServerCmd returns the command running the reference remote
+// signing service, for trying out remote_signer_url without other
+// infrastructure.
+func newSigningServerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "signing-server",
+		Short: "Run a reference remote signing service for local development",
+		Long: "Serves the remote signing API used by remote_signer_url, signing every transaction it receives with " +
+			"friendbot_secret and friendbot_signer_secrets from --secret. It is not meant to guard keys in production.",
+		Run: runSigningServer,
+	}
+	cmd.Flags().Int("port", 8001, "port to listen on")
+	return cmd
+}
+
+func runSigningServer(cmd *cobra.Command, args []string) {
+	secretPath := cmd.Root().PersistentFlags().Lookup("secret").Value.String()
+	port, _ := cmd.Flags().GetInt("port")
+	log.SetLevel(log.InfoLevel)
+
+	server, err := initSigningServer(secretPath)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	log.Infof("signing server listening on %s", addr)
+	httpServer := &stdhttp.Server{
+		Addr:              addr,
+		Handler:           server,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	if err := httpServer.ListenAndServe(); err != nil {
+		log.Error(errors.Wrap(err, "signing server failed"))
+		os.Exit(1)
+	}
+}
+
+// initSigningServer returns the signing service for the keys in the secret
+// file at secretPath.
+func initSigningServer(secretPath string) (*internal.SigningServer, error) {
+	if secretPath == "" {
+		return nil, errors.New("--secret is required")
+	}
+	var secrets Secrets
+	if err := config.Read(secretPath, &secrets); err != nil {
+		return nil, errors.Wrap(err, "reading secret file")
+	}
+
+	var keypairs []*keypair.Full
+	for i, secret := range append([]string{secrets.FriendbotSecret}, secrets.FriendbotSignerSecrets...) {
+		if secret == "" {
+			continue
+		}
+		kp, err := keypair.ParseFull(secret)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing signing key %d", i)
+		}
+		keypairs = append(keypairs, kp)
+	}
+	if len(keypairs) == 0 {
+		return nil, errors.New("friendbot_secret or friendbot_signer_secrets is required")
+	}
+	return internal.NewSigningServer(secrets.RemoteSignerToken, keypairs...), nil
+}
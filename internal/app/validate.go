@@ -0,0 +1,194 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stellar/friendbot/internal"
+	"github.com/stellar/go-stellar-sdk/amount"
+	"github.com/stellar/go-stellar-sdk/keypair"
+	"github.com/stellar/go-stellar-sdk/support/errors"
+)
+
+// maxMinionBatchSize is the most minions that can be created by a single
+// transaction, which is limited to 100 operations.
+const maxMinionBatchSize = 100
+
+// onlineValidationTimeout bounds the requests made by `config validate
+// --online`.
+const onlineValidationTimeout = 30 * time.Second
+
+// newConfigCmd returns the command grouping config subcommands.
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the friendbot configuration",
+	}
+	validateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the configuration without starting friendbot",
+		Long: "Checks every config and secret setting, as read from --conf, --secret, --signer-secret and FRIENDBOT_* " +
+			"environment variables. With --online, it also connects to the configured upstream to check the network " +
+			"passphrase and the bot account, without creating or submitting anything.",
+		Run: runConfigValidate,
+	}
+	validateCmd.Flags().Bool("online", false, "also check the configuration against the upstream network")
+	cmd.AddCommand(validateCmd)
+	return cmd
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) {
+	online, _ := cmd.Flags().GetBool("online")
+	signerSecretPaths, _ := cmd.Flags().GetStringArray("signer-secret")
+
+	cfg, secrets, err := loadConfig(configPath(cmd), flagOrEnv(cmd, "secret", "FRIENDBOT_SECRET_FILE"), signerSecretPaths...)
+	var errs []error
+	if err != nil {
+		errs = []error{err}
+	} else {
+		errs = validateConfig(cfg, secrets)
+		if len(errs) == 0 && online {
+			ctx, cancel := context.WithTimeout(cmd.Context(), onlineValidationTimeout)
+			defer cancel()
+			errs = validateOnline(ctx, cfg, secrets)
+		}
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintf(cmd.ErrOrStderr(), "error: %v\n", err)
+		}
+		os.Exit(1)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "config is valid")
+}
+
+// validateConfig checks the settings that loadConfig leaves to initFriendbot,
+// without connecting to the network, and returns every problem found.
+func validateConfig(cfg Config, secrets Secrets) []error {
+	var errs []error
+
+	switch {
+	case cfg.HorizonURL != "" && cfg.RPCURL != "":
+		errs = append(errs, errors.New("only one of horizon_url or rpc_url should be provided, not both"))
+	case cfg.HorizonURL == "" && cfg.RPCURL == "":
+		errs = append(errs, errors.New("either horizon_url or rpc_url must be provided"))
+	}
+	for _, setting := range []struct{ name, value string }{
+		{"horizon_url", cfg.HorizonURL},
+		{"rpc_url", cfg.RPCURL},
+		{"remote_signer_url", cfg.RemoteSignerURL},
+	} {
+		if setting.value == "" {
+			continue
+		}
+		if err := validateURL(setting.value); err != nil {
+			errs = append(errs, errors.Wrapf(err, "invalid %s %q", setting.name, setting.value))
+		}
+	}
+	if cfg.FundContractAddresses && cfg.RPCURL == "" {
+		errs = append(errs, errors.New("fund_contract_addresses requires rpc_url; contract addresses cannot be funded through horizon_url"))
+	}
+
+	if cfg.RemoteSignerURL == "" {
+		if _, err := parseBotKeypairs(secrets); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if secrets.TreasurySecret != "" {
+		if _, err := keypair.ParseFull(secrets.TreasurySecret); err != nil {
+			errs = append(errs, errors.Wrap(err, "parsing treasury_secret"))
+		}
+		if _, _, _, err := parseTreasuryAmounts(cfg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	settings, err := newSettings(cfg)
+	if err == nil {
+		err = settings.Validate()
+	}
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if cfg.NumMinions < 0 {
+		errs = append(errs, errors.New("num_minions must not be negative"))
+	}
+	if cfg.MinionBatchSize < 0 || cfg.MinionBatchSize > maxMinionBatchSize {
+		errs = append(errs, errors.Errorf("minion_batch_size must be between 0 (the default) and %d", maxMinionBatchSize))
+	}
+
+	if cfg.TLS != nil {
+		for _, file := range []struct{ name, path string }{
+			{"certificate-file", cfg.TLS.CertificateFile},
+			{"private-key-file", cfg.TLS.PrivateKeyFile},
+		} {
+			if _, err := os.Stat(file.path); err != nil {
+				errs = append(errs, errors.Wrapf(err, "invalid tls %s", file.name))
+			}
+		}
+	}
+	return errs
+}
+
+// validateURL checks that rawURL is an absolute http or https URL.
+func validateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("must be an http or https URL")
+	}
+	return nil
+}
+
+// validateOnline checks cfg against the upstream network: that it is the
+// network of network_passphrase at a protocol version supporting the enabled
+// features, and that the bot account exists, is signed for by the configured
+// keys and can afford to create the minions. Nothing is created or submitted.
+func validateOnline(ctx context.Context, cfg Config, secrets Secrets) []error {
+	networkClient, err := newNetworkClient(cfg)
+	if err != nil {
+		return []error{err}
+	}
+	networkInfo, err := checkNetwork(ctx, networkClient, cfg.NetworkPassphrase)
+	if err != nil {
+		return []error{err}
+	}
+	if cfg.FundContractAddresses && !networkInfo.SupportsContracts() {
+		return []error{errors.Errorf("fund_contract_addresses requires protocol version %d, but the network is at protocol version %d",
+			internal.MinContractProtocolVersion, networkInfo.ProtocolVersion)}
+	}
+	_, botAccountID, err := initBotSigner(ctx, cfg, secrets, networkClient)
+	if err != nil {
+		if e, ok := errors.Cause(err).(internal.NetworkError); ok && e.IsNotFound() {
+			return []error{errors.Errorf("bot account does not exist: %v", err)}
+		}
+		return []error{err}
+	}
+
+	details, err := networkClient.GetAccountDetails(ctx, botAccountID)
+	if err != nil {
+		return []error{errors.Wrap(err, "getting bot account details")}
+	}
+	balance, err := amount.ParseInt64(details.Balance)
+	if err != nil {
+		return []error{errors.Wrap(err, "parsing bot account balance")}
+	}
+	numMinions := cfg.NumMinions
+	if numMinions == 0 {
+		numMinions = 1000
+	}
+	needed := int64(numMinions) * 101 * amount.One
+	if balance < needed {
+		return []error{errors.Errorf("bot account %s has a balance of %s XLM, but creating %d minions needs %s XLM",
+			botAccountID, details.Balance, numMinions, amount.StringFromInt64(needed))}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stellar/go-stellar-sdk/keypair"
+	"github.com/stellar/go-stellar-sdk/support/render/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validateTestPassphrase = "Test SDF Network ; September 2015"
+
+func validTestConfig() (Config, Secrets) {
+	cfg := Config{
+		Port:              8000,
+		NetworkPassphrase: validateTestPassphrase,
+		HorizonURL:        "https://horizon-testnet.stellar.org",
+		StartingBalance:   "10000.00",
+		BaseFee:           100,
+		NumMinions:        10,
+	}
+	secrets := Secrets{FriendbotSecret: keypair.MustRandom().Seed()}
+	return cfg, secrets
+}
+
+func TestValidateConfig(t *testing.T) {
+	cfg, secrets := validTestConfig()
+	assert.Empty(t, validateConfig(cfg, secrets))
+
+	cfg.RPCURL = "localhost:8000"
+	cfg.BaseFee = 10
+	cfg.MinionBatchSize = 101
+	secrets.FriendbotSecret = "SBADSEED"
+	var messages []string
+	for _, err := range validateConfig(cfg, secrets) {
+		messages = append(messages, err.Error())
+	}
+	assert.Equal(t, []string{
+		"only one of horizon_url or rpc_url should be provided, not both",
+		`invalid rpc_url "localhost:8000": must be an http or https URL`,
+		"parsing friendbot_secret: invalid checksum",
+		"invalid base fee 10: must be at least 100 stroops",
+		"minion_batch_size must be between 0 (the default) and 100",
+	}, messages)
+}
+
+func TestValidateConfig_contractAddressesWithHorizon(t *testing.T) {
+	cfg, secrets := validTestConfig()
+	cfg.FundContractAddresses = true
+	errs := validateConfig(cfg, secrets)
+	require.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], "fund_contract_addresses requires rpc_url")
+}
+
+// newValidateTestHorizon returns a Horizon server reporting passphrase and
+// serving the account of botKeypair with balance, if it is not empty.
+func newValidateTestHorizon(t *testing.T, passphrase string, botKeypair *keypair.Full, balance string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"network_passphrase": passphrase, "current_protocol_version": 22})
+	})
+	mux.HandleFunc("/accounts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if balance == "" || r.PathValue("id") != botKeypair.Address() {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(problem.P{Type: "not_found", Title: "Resource Missing", Status: http.StatusNotFound})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":         botKeypair.Address(),
+			"account_id": botKeypair.Address(),
+			"sequence":   "1",
+			"balances":   []map[string]any{{"asset_type": "native", "balance": balance}},
+			"signers":    []map[string]any{{"key": botKeypair.Address(), "weight": 1, "type": "ed25519_public_key"}},
+		})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestValidateOnline(t *testing.T) {
+	ctx := context.Background()
+	botKeypair := keypair.MustRandom()
+	cfg, secrets := validTestConfig()
+	secrets.FriendbotSecret = botKeypair.Seed()
+
+	t.Run("valid", func(t *testing.T) {
+		cfg.HorizonURL = newValidateTestHorizon(t, validateTestPassphrase, botKeypair, "1010.0000000").URL
+		assert.Empty(t, validateOnline(ctx, cfg, secrets))
+	})
+
+	t.Run("passphrase mismatch", func(t *testing.T) {
+		cfg.HorizonURL = newValidateTestHorizon(t, "Public Global Stellar Network ; September 2015", botKeypair, "1010.0000000").URL
+		errs := validateOnline(ctx, cfg, secrets)
+		require.Len(t, errs, 1)
+		assert.ErrorContains(t, errs[0], "does not match the upstream network passphrase")
+	})
+
+	t.Run("missing bot account", func(t *testing.T) {
+		cfg.HorizonURL = newValidateTestHorizon(t, validateTestPassphrase, botKeypair, "").URL
+		errs := validateOnline(ctx, cfg, secrets)
+		require.Len(t, errs, 1)
+		assert.ErrorContains(t, errs[0], "bot account does not exist")
+	})
+
+	t.Run("insufficient balance", func(t *testing.T) {
+		cfg.HorizonURL = newValidateTestHorizon(t, validateTestPassphrase, botKeypair, "1009.9999999").URL
+		errs := validateOnline(ctx, cfg, secrets)
+		require.Len(t, errs, 1)
+		assert.ErrorContains(t, errs[0], "has a balance of 1009.9999999 XLM, but creating 10 minions needs 1010.0000000 XLM")
+	})
+}
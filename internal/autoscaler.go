@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Autoscaler grows the minion pool while it is saturated, and shrinks it
+// while it is quiet, keeping its size between MinMinions and MaxMinions.
+type Autoscaler struct {
+	Bot        *Bot
+	MinMinions int
+	MaxMinions int
+	// Step is the number of minions added or retired at a time.
+	Step int
+	// ScaleUpWait is the queue wait at or above which the pool is grown.
+	ScaleUpWait time.Duration
+	// ScaleUpUtilization is the fraction of busy minions at or above which
+	// the pool is grown.
+	ScaleUpUtilization float64
+	// ScaleDownUtilization is the fraction of busy minions at or below which
+	// the pool is considered quiet.
+	ScaleDownUtilization float64
+	// ScaleDownAfter is how long the pool must stay quiet before it is
+	// shrunk.
+	ScaleDownAfter time.Duration
+
+	quietSince time.Time
+	// now returns the current time, and is replaced in tests.
+	now func() time.Time
+
+	scaleEvents metric.Int64Counter
+}
+
+// NewAutoscaler returns an autoscaler for the pool of bot, with default
+// thresholds.
+func NewAutoscaler(bot *Bot, minMinions, maxMinions, step int) *Autoscaler {
+	a := &Autoscaler{
+		Bot:                  bot,
+		MinMinions:           minMinions,
+		MaxMinions:           maxMinions,
+		Step:                 step,
+		ScaleUpWait:          500 * time.Millisecond,
+		ScaleUpUtilization:   0.9,
+		ScaleDownUtilization: 0.25,
+		ScaleDownAfter:       5 * time.Minute,
+		now:                  time.Now,
+	}
+	a.registerMetrics()
+	return a
+}
+
+func (a *Autoscaler) registerMetrics() {
+	meter := otel.Meter(meterName)
+
+	scaleEvents, err := meter.Int64Counter(
+		"friendbot.autoscaler.scale_events",
+		metric.WithDescription("Number of times the autoscaler added or retired minions."),
+	)
+	if err != nil {
+		log.Printf("Failed to create autoscaler scale events metric: %v", err)
+	}
+	a.scaleEvents = scaleEvents
+
+	_, err = meter.Int64ObservableGauge(
+		"friendbot.pool.minions",
+		metric.WithDescription("Number of minions in the pool."),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(int64(a.Bot.NumMinions()))
+			return nil
+		}),
+	)
+	if err != nil {
+		log.Printf("Failed to create pool minions metric: %v", err)
+	}
+}
+
+// Run checks the pool every interval, scaling it when needed, until ctx is
+// done.
+func (a *Autoscaler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := a.Check(ctx); err != nil {
+			log.Printf("Autoscaler failed to scale the minion pool: %v", err)
+		}
+	}
+}
+
+// Check samples the pressure on the pool since the previous check, and adds
+// or retires minions if needed.
+func (a *Autoscaler) Check(ctx context.Context) error {
+	sample := a.Bot.Queue.Sample()
+	total := a.Bot.NumMinions()
+	utilization := 1.0
+	if total > 0 {
+		utilization = float64(total-sample.MinIdle) / float64(total)
+	}
+	now := a.now()
+
+	switch {
+	case total < a.MinMinions:
+		return a.scaleUp(ctx, a.MinMinions-total, "below minimum")
+	case total > a.MaxMinions:
+		return a.scaleDown(ctx, total-a.MaxMinions, "above maximum")
+	case sample.Depth > 0 || sample.MaxWait >= a.ScaleUpWait || utilization >= a.ScaleUpUtilization:
+		a.quietSince = time.Time{}
+		if n := min(a.Step, a.MaxMinions-total); n > 0 {
+			return a.scaleUp(ctx, n, "saturated")
+		}
+	case utilization <= a.ScaleDownUtilization:
+		if a.quietSince.IsZero() {
+			a.quietSince = now
+			return nil
+		}
+		if now.Sub(a.quietSince) < a.ScaleDownAfter {
+			return nil
+		}
+		// Wait for another quiet period before shrinking any further.
+		a.quietSince = now
+		if n := min(a.Step, total-a.MinMinions); n > 0 {
+			return a.scaleDown(ctx, n, "quiet")
+		}
+	default:
+		a.quietSince = time.Time{}
+	}
+	return nil
+}
+
+func (a *Autoscaler) scaleUp(ctx context.Context, n int, reason string) error {
+	log.Printf("Autoscaler adding %d minions: pool is %s", n, reason)
+	added, err := a.Bot.AddMinions(ctx, n)
+	a.recordScaleEvent(ctx, "up", added)
+	return err
+}
+
+func (a *Autoscaler) scaleDown(ctx context.Context, n int, reason string) error {
+	log.Printf("Autoscaler retiring %d minions: pool is %s", n, reason)
+	retired, err := a.Bot.RetireMinions(ctx, n)
+	a.recordScaleEvent(ctx, "down", retired)
+	return err
+}
+
+func (a *Autoscaler) recordScaleEvent(ctx context.Context, direction string, n int) {
+	if a.scaleEvents != nil && n > 0 {
+		a.scaleEvents.Add(ctx, 1, metric.WithAttributes(attribute.String("direction", direction)))
+	}
+}
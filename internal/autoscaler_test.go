@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stellar/go-stellar-sdk/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAutoscaler(t *testing.T, numMinions, minMinions, maxMinions int) *Autoscaler {
+	fb, _ := newTestPoolBot(t, numMinions, nil)
+	fb.MinionFactory = &MinionFactory{
+		BotAccount:      fb.Minions[0].BotAccount.(Account),
+		BotSigner:       fb.Minions[0].BotSigner,
+		NetworkClient:   fb.NetworkClient,
+		Network:         "Test SDF Network ; September 2015",
+		StartingBalance: "10000.00",
+		MinionBalance:   "101.00",
+		BatchSize:       50,
+		BaseFee:         txnbuild.MinBaseFee,
+	}
+	return NewAutoscaler(fb, minMinions, maxMinions, 2)
+}
+
+func TestAutoscaler_ScalesUpWhenSaturated(t *testing.T) {
+	ctx := context.Background()
+	a := newTestAutoscaler(t, 2, 1, 5)
+
+	for i := 0; i < 2; i++ {
+		_, err := a.Bot.Queue.Acquire(ctx, "client")
+		require.NoError(t, err)
+	}
+	require.NoError(t, a.Check(ctx))
+	assert.Equal(t, 4, a.Bot.NumMinions())
+	assert.Equal(t, 2, a.Bot.Queue.Idle())
+
+	// The pool never grows beyond the maximum.
+	for i := 0; i < 2; i++ {
+		_, err := a.Bot.Queue.Acquire(ctx, "client")
+		require.NoError(t, err)
+	}
+	require.NoError(t, a.Check(ctx))
+	assert.Equal(t, 5, a.Bot.NumMinions())
+}
+
+func TestAutoscaler_ScalesDownWhenQuiet(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	a := newTestAutoscaler(t, 4, 1, 10)
+	a.now = func() time.Time { return now }
+
+	require.NoError(t, a.Check(ctx))
+	assert.Equal(t, 4, a.Bot.NumMinions())
+
+	now = now.Add(a.ScaleDownAfter)
+	require.NoError(t, a.Check(ctx))
+	assert.Equal(t, 2, a.Bot.NumMinions())
+	assert.Equal(t, 2, a.Bot.Queue.Idle())
+	assert.Len(t, a.Bot.MinionStatuses(ctx), 2)
+
+	// Busy minions are not retired, and the pool never shrinks below the
+	// minimum.
+	_, err := a.Bot.Queue.Acquire(ctx, "client")
+	require.NoError(t, err)
+	a.Bot.Queue.Sample()
+	retired, err := a.Bot.RetireMinions(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retired)
+
+	now = now.Add(2 * a.ScaleDownAfter)
+	require.NoError(t, a.Check(ctx))
+	assert.Equal(t, 1, a.Bot.NumMinions())
+}
+
+func TestAutoscaler_ScalesToMinimum(t *testing.T) {
+	ctx := context.Background()
+	a := newTestAutoscaler(t, 1, 3, 10)
+
+	require.NoError(t, a.Check(ctx))
+	assert.Equal(t, 3, a.Bot.NumMinions())
+}
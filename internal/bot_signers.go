@@ -0,0 +1,46 @@
+package internal
+
+import "github.com/stellar/go-stellar-sdk/support/errors"
+
+// SelectBotSigners checks the signing keys at publicKeys against the on-chain
+// signers of the bot account described by details, and returns how many of
+// them, in order, are needed to sign bot account operations. Every key must be
+// a signer of the account.
+//
+// Keys are selected until their combined weight reaches threshold, or the
+// account's medium threshold if threshold is 0. Keys beyond those needed must
+// not sign, since the network rejects transactions carrying unneeded
+// signatures.
+func SelectBotSigners(accountID string, details *AccountDetails, publicKeys []string, threshold int32) (int, error) {
+	if threshold == 0 {
+		threshold = max(details.MediumThreshold, 1)
+	} else if threshold < details.MediumThreshold {
+		return 0, errors.Errorf("signing threshold %d is below the medium threshold %d of bot account %s",
+			threshold, details.MediumThreshold, accountID)
+	}
+
+	var (
+		selected int
+		weight   int32
+		seen     = map[string]bool{}
+	)
+	for _, publicKey := range publicKeys {
+		if seen[publicKey] {
+			return 0, errors.Errorf("signing key %s is configured more than once", publicKey)
+		}
+		seen[publicKey] = true
+		signerWeight := details.Signers[publicKey]
+		if signerWeight == 0 {
+			return 0, errors.Errorf("signing key %s is not a signer of bot account %s", publicKey, accountID)
+		}
+		if weight < threshold {
+			selected++
+			weight += signerWeight
+		}
+	}
+	if weight < threshold {
+		return 0, errors.Errorf("signing keys have a combined weight of %d on bot account %s, below the signing threshold %d",
+			weight, accountID, threshold)
+	}
+	return selected, nil
+}
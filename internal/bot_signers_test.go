@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stellar/go-stellar-sdk/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectBotSigners(t *testing.T) {
+	master := keypair.MustRandom()
+	signer1 := keypair.MustRandom()
+	signer2 := keypair.MustRandom()
+	outsider := keypair.MustRandom()
+	accountID := master.Address()
+	details := &AccountDetails{
+		Signers: map[string]int32{
+			master.Address():  1,
+			signer1.Address(): 1,
+			signer2.Address(): 2,
+		},
+		MediumThreshold: 2,
+	}
+
+	t.Run("defaults to the medium threshold", func(t *testing.T) {
+		selected, err := SelectBotSigners(accountID, details, []string{master.Address(), signer1.Address(), signer2.Address()}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 2, selected)
+	})
+
+	t.Run("configured threshold", func(t *testing.T) {
+		selected, err := SelectBotSigners(accountID, details, []string{master.Address(), signer1.Address(), signer2.Address()}, 4)
+		require.NoError(t, err)
+		assert.Equal(t, 3, selected)
+	})
+
+	t.Run("threshold below medium threshold", func(t *testing.T) {
+		_, err := SelectBotSigners(accountID, details, []string{master.Address(), signer2.Address()}, 1)
+		assert.ErrorContains(t, err, "signing threshold 1 is below the medium threshold 2")
+	})
+
+	t.Run("insufficient weight", func(t *testing.T) {
+		_, err := SelectBotSigners(accountID, details, []string{master.Address()}, 0)
+		assert.ErrorContains(t, err, "combined weight of 1")
+	})
+
+	t.Run("key is not a signer", func(t *testing.T) {
+		_, err := SelectBotSigners(accountID, details, []string{master.Address(), outsider.Address()}, 0)
+		assert.ErrorContains(t, err, "signing key "+outsider.Address()+" is not a signer")
+	})
+
+	t.Run("duplicate key", func(t *testing.T) {
+		_, err := SelectBotSigners(accountID, details, []string{signer1.Address(), signer1.Address()}, 0)
+		assert.ErrorContains(t, err, "configured more than once")
+	})
+}
@@ -0,0 +1,220 @@
+package internal
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stellar/go-stellar-sdk/amount"
+	"github.com/stellar/go-stellar-sdk/support/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ErrBudgetExhausted is returned when funding an address would exceed the
+// configured spending budget.
+var ErrBudgetExhausted = errors.New("funding budget exhausted")
+
+// BudgetTracker caps the total amount disbursed by minions within rolling
+// hourly and daily windows. Once a cap is reached, funding is refused until
+// enough earlier spending falls out of the window.
+type BudgetTracker struct {
+	mu        sync.Mutex
+	hourlyCap int64
+	dailyCap  int64
+	spends    []budgetSpend
+	reserved  int64
+
+	// now returns the current time, and is replaced in tests.
+	now func() time.Time
+	// gauges reports the spent gauge until Close.
+	gauges metric.Registration
+}
+
+type budgetSpend struct {
+	at     time.Time
+	amount int64
+}
+
+// BudgetWindowStatus is the spending within a single budget window. Amounts
+// are in XLM. Cap and Remaining are empty if the window is unlimited.
+type BudgetWindowStatus struct {
+	Cap       string `json:"cap,omitempty"`
+	Spent     string `json:"spent"`
+	Reserved  string `json:"reserved"`
+	Remaining string `json:"remaining,omitempty"`
+}
+
+// BudgetStatus is the current spending against the budget.
+type BudgetStatus struct {
+	Hourly    BudgetWindowStatus `json:"hourly"`
+	Daily     BudgetWindowStatus `json:"daily"`
+	Exhausted bool               `json:"exhausted"`
+}
+
+// NewBudgetTracker returns a tracker capping spending to hourlyCap and
+// dailyCap stroops. A cap of 0 leaves that window unlimited.
+func NewBudgetTracker(hourlyCap, dailyCap int64) *BudgetTracker {
+	b := &BudgetTracker{hourlyCap: hourlyCap, dailyCap: dailyCap, now: time.Now}
+	b.registerMetrics(otel.Meter(meterName))
+	return b
+}
+
+func (b *BudgetTracker) registerMetrics(meter metric.Meter) {
+	spent, err := meter.Float64ObservableGauge(
+		"friendbot.budget.spent",
+		metric.WithDescription("Amount of XLM disbursed within the rolling budget window."),
+	)
+	if err != nil {
+		log.Printf("Failed to create budget spent metric: %v", err)
+		return
+	}
+
+	b.gauges, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		hourly, daily := b.spent()
+		o.ObserveFloat64(spent, float64(hourly)/amount.One, metric.WithAttributes(attribute.String("window", "hourly")))
+		o.ObserveFloat64(spent, float64(daily)/amount.One, metric.WithAttributes(attribute.String("window", "daily")))
+		return nil
+	}, spent)
+	if err != nil {
+		log.Printf("Failed to register budget spent metric: %v", err)
+	}
+}
+
+// Close stops reporting the budget's gauge, which otherwise keeps the tracker
+// referenced by the global meter provider.
+func (b *BudgetTracker) Close() error {
+	if b.gauges == nil {
+		return nil
+	}
+	return b.gauges.Unregister()
+}
+
+// Reserve holds amount stroops of the budget for a payment that is about to
+// be made, returning ErrBudgetExhausted if that would exceed either cap. The
+// returned func must be called once the payment completes, with whether the
+// amount was actually disbursed.
+func (b *BudgetTracker) Reserve(amount int64) (func(disbursed bool), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	hourly, daily := b.spentLocked()
+	if b.hourlyCap > 0 && hourly+b.reserved+amount > b.hourlyCap {
+		return nil, ErrBudgetExhausted
+	}
+	if b.dailyCap > 0 && daily+b.reserved+amount > b.dailyCap {
+		return nil, ErrBudgetExhausted
+	}
+	b.reserved += amount
+
+	var once sync.Once
+	return func(disbursed bool) {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.reserved -= amount
+			if disbursed {
+				b.spends = append(b.spends, budgetSpend{at: b.now(), amount: amount})
+			}
+		})
+	}, nil
+}
+
+// Restore counts the amounts disbursed within the last day, as recorded in
+// history, against the budget, so that restarting friendbot does not reset
+// it. Attempts that timed out are counted, as Reserve's callers count them,
+// since the payment may have been made.
+func (b *BudgetTracker) Restore(ctx context.Context, history HistoryStore) error {
+	b.mu.Lock()
+	since := b.now().Add(-24 * time.Hour)
+	b.mu.Unlock()
+	records, err := history.FundingsSince(ctx, since)
+	if err != nil {
+		return errors.Wrap(err, "loading funding history")
+	}
+
+	var spends []budgetSpend
+	for _, record := range records {
+		if record.Amount == "" {
+			continue
+		}
+		if record.Outcome != FundingOutcomeSuccess && record.Problem != ProblemTypeUpstreamTimeout {
+			continue
+		}
+		stroops, err := amount.ParseInt64(record.Amount)
+		if err != nil {
+			return errors.Wrapf(err, "parsing amount of funding record %d", record.ID)
+		}
+		spends = append(spends, budgetSpend{at: record.CreatedAt, amount: stroops})
+	}
+	sort.SliceStable(spends, func(i, j int) bool { return spends[i].at.Before(spends[j].at) })
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.spends = append(spends, b.spends...)
+	return nil
+}
+
+// SetCaps changes the hourly and daily caps to hourlyCap and dailyCap stroops.
+// A cap of 0 leaves that window unlimited. Spending already recorded counts
+// against the new caps.
+func (b *BudgetTracker) SetCaps(hourlyCap, dailyCap int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.hourlyCap = hourlyCap
+	b.dailyCap = dailyCap
+}
+
+// Status returns the current spending against the budget.
+func (b *BudgetTracker) Status() BudgetStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	hourly, daily := b.spentLocked()
+	status := BudgetStatus{
+		Hourly: windowStatus(b.hourlyCap, hourly, b.reserved),
+		Daily:  windowStatus(b.dailyCap, daily, b.reserved),
+	}
+	status.Exhausted = (b.hourlyCap > 0 && hourly+b.reserved >= b.hourlyCap) ||
+		(b.dailyCap > 0 && daily+b.reserved >= b.dailyCap)
+	return status
+}
+
+func windowStatus(limit, spent, reserved int64) BudgetWindowStatus {
+	status := BudgetWindowStatus{
+		Spent:    amount.StringFromInt64(spent),
+		Reserved: amount.StringFromInt64(reserved),
+	}
+	if limit > 0 {
+		status.Cap = amount.StringFromInt64(limit)
+		status.Remaining = amount.StringFromInt64(max(limit-spent-reserved, 0))
+	}
+	return status
+}
+
+func (b *BudgetTracker) spent() (int64, int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spentLocked()
+}
+
+// spentLocked drops spends older than a day, and returns the amounts spent in
+// the last hour and the last day. The caller must hold b.mu.
+func (b *BudgetTracker) spentLocked() (int64, int64) {
+	now := b.now()
+	i := 0
+	for i < len(b.spends) && now.Sub(b.spends[i].at) >= 24*time.Hour {
+		i++
+	}
+	b.spends = b.spends[i:]
+
+	var hourly, daily int64
+	for _, s := range b.spends {
+		daily += s.amount
+		if now.Sub(s.at) < time.Hour {
+			hourly += s.amount
+		}
+	}
+	return hourly, daily
+}
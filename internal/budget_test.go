@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stellar/go-stellar-sdk/amount"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBudgetTracker_HourlyCap(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := NewBudgetTracker(20*amount.One, 0)
+	b.now = func() time.Time { return now }
+
+	settle, err := b.Reserve(10 * amount.One)
+	require.NoError(t, err)
+	settle(true)
+	settle, err = b.Reserve(10 * amount.One)
+	require.NoError(t, err)
+	settle(true)
+
+	_, err = b.Reserve(10 * amount.One)
+	assert.ErrorIs(t, err, ErrBudgetExhausted)
+	assert.Equal(t, BudgetStatus{
+		Hourly:    BudgetWindowStatus{Cap: "20.0000000", Spent: "20.0000000", Reserved: "0.0000000", Remaining: "0.0000000"},
+		Daily:     BudgetWindowStatus{Spent: "20.0000000", Reserved: "0.0000000"},
+		Exhausted: true,
+	}, b.Status())
+
+	// The budget frees up once the spending leaves the rolling window.
+	now = now.Add(time.Hour)
+	settle, err = b.Reserve(10 * amount.One)
+	require.NoError(t, err)
+	settle(true)
+	assert.Equal(t, "10.0000000", b.Status().Hourly.Spent)
+	assert.Equal(t, "30.0000000", b.Status().Daily.Spent)
+}
+
+func TestBudgetTracker_DailyCap(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := NewBudgetTracker(0, 20*amount.One)
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		settle, err := b.Reserve(10 * amount.One)
+		require.NoError(t, err)
+		settle(true)
+		now = now.Add(2 * time.Hour)
+	}
+
+	_, err := b.Reserve(10 * amount.One)
+	assert.ErrorIs(t, err, ErrBudgetExhausted)
+
+	now = now.Add(20 * time.Hour)
+	_, err = b.Reserve(10 * amount.One)
+	assert.NoError(t, err)
+}
+
+func TestBudgetTracker_ReservationsCountUntilSettled(t *testing.T) {
+	b := NewBudgetTracker(20*amount.One, 0)
+
+	settleFirst, err := b.Reserve(10 * amount.One)
+	require.NoError(t, err)
+	settleSecond, err := b.Reserve(10 * amount.One)
+	require.NoError(t, err)
+	_, err = b.Reserve(10 * amount.One)
+	assert.ErrorIs(t, err, ErrBudgetExhausted)
+
+	// A failed payment returns its reservation to the budget.
+	settleFirst(false)
+	settleFirst(true)
+	settleSecond(true)
+	assert.Equal(t, "10.0000000", b.Status().Hourly.Spent)
+	assert.Equal(t, "0.0000000", b.Status().Hourly.Reserved)
+	assert.False(t, b.Status().Exhausted)
+}
+
+func TestBudgetTracker_Close(t *testing.T) {
+	meter, reader := newTestMeter()
+	b := NewBudgetTracker(0, 0)
+	require.NoError(t, b.Close())
+	b.registerMetrics(meter)
+	assert.Equal(t, []string{"friendbot.budget.spent"}, reportedMetrics(t, reader))
+
+	require.NoError(t, b.Close())
+	assert.Empty(t, reportedMetrics(t, reader))
+}
+
+// recentFundings is a HistoryStore holding only the fundings FundingsSince
+// returns.
+type recentFundings struct {
+	HistoryStore
+	records []FundingRecord
+}
+
+func (h recentFundings) FundingsSince(_ context.Context, t time.Time) ([]FundingRecord, error) {
+	var records []FundingRecord
+	for _, record := range h.records {
+		if !record.CreatedAt.Before(t) {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func TestBudgetTracker_Restore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := NewBudgetTracker(30*amount.One, 50*amount.One)
+	b.now = func() time.Time { return now }
+
+	history := recentFundings{records: []FundingRecord{
+		{CreatedAt: now.Add(-25 * time.Hour), Amount: "10", Outcome: FundingOutcomeSuccess},
+		{CreatedAt: now.Add(-2 * time.Hour), Amount: "10", Outcome: FundingOutcomeSuccess},
+		{CreatedAt: now.Add(-30 * time.Minute), Amount: "10", Outcome: FundingOutcomeFailure, Problem: "bad_request"},
+		{CreatedAt: now.Add(-20 * time.Minute), Amount: "10", Outcome: FundingOutcomeFailure, Problem: ProblemTypeUpstreamTimeout},
+		{CreatedAt: now.Add(-10 * time.Minute), Amount: "10", Outcome: FundingOutcomeSuccess},
+		{CreatedAt: now.Add(-5 * time.Minute), Outcome: FundingOutcomeFailure, Problem: ProblemTypeUpstreamTimeout},
+	}}
+	require.NoError(t, b.Restore(context.Background(), history))
+	assert.Equal(t, "20.0000000", b.Status().Hourly.Spent)
+	assert.Equal(t, "30.0000000", b.Status().Daily.Spent)
+
+	settle, err := b.Reserve(10 * amount.One)
+	require.NoError(t, err)
+	settle(true)
+	_, err = b.Reserve(10 * amount.One)
+	assert.ErrorIs(t, err, ErrBudgetExhausted)
+
+	// The restored spending leaves the window like any other.
+	now = now.Add(2 * time.Hour)
+	assert.Equal(t, "0.0000000", b.Status().Hourly.Spent)
+	assert.Equal(t, "40.0000000", b.Status().Daily.Spent)
+}
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// payGroup deduplicates payments to the same destination. Concurrent payments
+// share a single funding attempt, and a successful funding is replayed to
+// later payments for the same destination until it expires.
+type payGroup struct {
+	flights singleflight.Group
+
+	mu       sync.Mutex
+	recent   map[string]recentPayment
+	expiries []string
+}
+
+type recentPayment struct {
+	result    *TransactionResult
+	expiresAt time.Time
+}
+
+// do calls pay for destAddress unless an identical payment is already in
+// flight or completed successfully within ttl, in which case the result of
+// that payment is returned instead. A caller whose ctx is done stops waiting,
+// but does not cancel the payment shared with other callers.
+func (g *payGroup) do(ctx context.Context, destAddress string, ttl time.Duration, pay func(ctx context.Context) (*TransactionResult, error)) (*TransactionResult, error) {
+	if result, ok := g.lookup(destAddress); ok {
+		return result, nil
+	}
+
+	resultChan := g.flights.DoChan(destAddress, func() (interface{}, error) {
+		result, err := pay(context.WithoutCancel(ctx))
+		if err == nil && ttl > 0 {
+			g.remember(destAddress, result, ttl)
+		}
+		return result, err
+	})
+
+	select {
+	case res := <-resultChan:
+		result, _ := res.Val.(*TransactionResult)
+		return result, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (g *payGroup) lookup(destAddress string) (*TransactionResult, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.expire(time.Now())
+	recent, ok := g.recent[destAddress]
+	return recent.result, ok
+}
+
+func (g *payGroup) remember(destAddress string, result *TransactionResult, ttl time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.recent == nil {
+		g.recent = map[string]recentPayment{}
+	}
+	g.recent[destAddress] = recentPayment{result: result, expiresAt: time.Now().Add(ttl)}
+	g.expiries = append(g.expiries, destAddress)
+}
+
+// expire forgets payments whose replay window has passed, in the order they
+// were remembered. The caller must hold g.mu.
+func (g *payGroup) expire(now time.Time) {
+	i := 0
+	for ; i < len(g.expiries); i++ {
+		recent, ok := g.recent[g.expiries[i]]
+		if ok && now.Before(recent.expiresAt) {
+			break
+		}
+		delete(g.recent, g.expiries[i])
+	}
+	g.expiries = g.expiries[i:]
+}
@@ -4,6 +4,12 @@ import (
 	"context"
 	"log"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stellar/go-stellar-sdk/amount"
+	"github.com/stellar/go-stellar-sdk/strkey"
+	"github.com/stellar/go-stellar-sdk/support/errors"
 )
 
 // Bot represents the friendbot subsystem and primarily delegates work
@@ -12,32 +18,157 @@ type Bot struct {
 	Minions               []Minion
 	NetworkClient         NetworkClient
 	FundContractAddresses bool
-	nextMinionIndex       int
-	indexMux              sync.Mutex
+	// Queue, if set, reserves a minion exclusively for each request and
+	// bounds how many requests may wait for one. If nil, minions are
+	// selected round-robin regardless of whether they are busy.
+	Queue *MinionQueue
+	// ReplayTTL is how long a successful funding is returned again to
+	// repeated requests for the same destination instead of paying again.
+	ReplayTTL time.Duration
+	// History, if set, records every funding attempt.
+	History HistoryStore
+	// Budget, if set, caps the total amount disbursed per hour and per day.
+	Budget *BudgetTracker
+	// MinionFactory, if set, creates the minions added to the pool while
+	// friendbot is running.
+	MinionFactory *MinionFactory
+	// ProtocolVersion is the protocol version of the network when the bot
+	// started, or 0 if it is unknown.
+	ProtocolVersion uint32
+	// Memo is the memo of funding transactions that do not ask for one, so
+	// that fundings from this deployment can be told apart on explorers.
+	Memo Memo
+
+	nextMinionIndex int
+	// indexMux guards Minions, minionStates, nextMinionIndex and, once the
+	// bot is running, FundContractAddresses.
+	indexMux     sync.Mutex
+	minionStates []minionState
+	growMux      sync.Mutex
+	paused       atomic.Bool
+	payments     payGroup
 }
 
 // SubmitResult is the result from the asynchronous tx submission.
 type SubmitResult struct {
 	maybeTransactionSuccess *TransactionResult
 	maybeErr                error
+	// outcomeUnknown is true if the transaction was submitted but the network
+	// did not respond in time, so it may still have been applied.
+	outcomeUnknown bool
 }
 
-// Pay funds the account at `destAddress`.
+// Pay funds the account at `destAddress`. Concurrent requests for the same
+// destination share a single funding attempt and its result.
 func (bot *Bot) Pay(ctx context.Context, destAddress string) (*TransactionResult, error) {
-	bot.indexMux.Lock()
-	log.Printf("Selecting minion at index %d of max length %d", bot.nextMinionIndex, len(bot.Minions))
-	minion := bot.Minions[bot.nextMinionIndex]
-	bot.nextMinionIndex = (bot.nextMinionIndex + 1) % len(bot.Minions)
-	bot.indexMux.Unlock()
+	return bot.PayWithMemo(ctx, destAddress, Memo{})
+}
+
+// PayWithMemo funds the account at `destAddress` like Pay, with a funding
+// transaction carrying memo, or the bot's Memo if memo is zero. Contract
+// addresses are funded without a memo.
+func (bot *Bot) PayWithMemo(ctx context.Context, destAddress string, memo Memo) (*TransactionResult, error) {
+	if memo.IsZero() {
+		memo = bot.Memo
+	}
+	if strkey.IsValidContractAddress(destAddress) {
+		memo = Memo{}
+	}
+	// Only requests for the same memo share a funding attempt, so that the
+	// result always carries the memo asked for.
+	key := destAddress
+	if !memo.IsZero() {
+		key += "/" + memo.Type + ":" + memo.Value
+	}
+	return bot.payments.do(ctx, key, bot.ReplayTTL, func(ctx context.Context) (*TransactionResult, error) {
+		return bot.pay(ctx, destAddress, memo)
+	})
+}
+
+func (bot *Bot) pay(ctx context.Context, destAddress string, memo Memo) (*TransactionResult, error) {
+	start := time.Now()
+	if bot.Paused() {
+		bot.recordFunding(ctx, newFundingRecord(ctx, start, destAddress, Minion{}, nil, ErrFundingPaused))
+		return nil, ErrFundingPaused
+	}
+	minion, release, err := bot.acquireMinion(ctx)
+	if err != nil {
+		bot.recordFunding(ctx, newFundingRecord(ctx, start, destAddress, Minion{}, nil, err))
+		return nil, err
+	}
+	settle, err := bot.reserveBudget(minion)
+	if err != nil {
+		release(minion, nil)
+		bot.recordFunding(ctx, newFundingRecord(ctx, start, destAddress, minion, nil, err))
+		return nil, err
+	}
 	resultChan := make(chan SubmitResult)
-	go minion.Run(ctx, destAddress, resultChan)
+	go minion.Run(ctx, destAddress, memo, resultChan)
 	maybeSubmitResult := <-resultChan
 	close(resultChan)
+	// A payment that timed out may have been applied, so it counts against
+	// the budget to make sure it is never exceeded.
+	settle(maybeSubmitResult.maybeErr == nil || maybeSubmitResult.outcomeUnknown)
+	release(minion, maybeSubmitResult.maybeErr)
+	bot.recordFunding(ctx, newFundingRecord(ctx, start, destAddress, minion, maybeSubmitResult.maybeTransactionSuccess, maybeSubmitResult.maybeErr))
 	return maybeSubmitResult.maybeTransactionSuccess, maybeSubmitResult.maybeErr
 }
 
+// reserveBudget holds the amount minion is about to disburse against the
+// budget, if one is configured. The returned func settles the reservation once
+// the payment completes.
+func (bot *Bot) reserveBudget(minion Minion) (func(disbursed bool), error) {
+	if bot.Budget == nil {
+		return func(bool) {}, nil
+	}
+	stroops, err := amount.ParseInt64(minion.StartingBalance)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing starting balance")
+	}
+	return bot.Budget.Reserve(stroops)
+}
+
+// recordFunding stores a funding attempt in the history store, if one is
+// configured. Failing to record an attempt does not fail the funding.
+func (bot *Bot) recordFunding(ctx context.Context, record FundingRecord) {
+	if bot.History == nil {
+		return
+	}
+	if err := bot.History.RecordFunding(ctx, record); err != nil {
+		log.Printf("Failed to record funding of %s: %v", record.Address, err)
+	}
+}
+
+// StartingBalance returns the amount the bot funds addresses with, or "" if it
+// has no minions.
+func (bot *Bot) StartingBalance() string {
+	bot.indexMux.Lock()
+	defer bot.indexMux.Unlock()
+	if len(bot.Minions) == 0 {
+		return ""
+	}
+	return bot.Minions[0].StartingBalance
+}
+
 // SupportsContractAddresses returns true if the bot is configured to fund
 // contract addresses (C addresses) and the network client supports it.
 func (bot *Bot) SupportsContractAddresses() bool {
-	return bot.FundContractAddresses && bot.NetworkClient.SupportsContractAddresses()
+	bot.indexMux.Lock()
+	enabled := bot.FundContractAddresses
+	bot.indexMux.Unlock()
+	return enabled && bot.NetworkClient.SupportsContractAddresses()
+}
+
+type clientContextKey struct{}
+
+// WithClient returns a copy of ctx carrying the key identifying the client
+// that made the request, typically its IP address. It is used to schedule
+// queued requests fairly between clients.
+func WithClient(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, clientContextKey{}, client)
+}
+
+func clientFromContext(ctx context.Context) string {
+	client, _ := ctx.Value(clientContextKey{}).(string)
+	return client
 }
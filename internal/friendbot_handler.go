@@ -1,8 +1,12 @@
 package internal
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"net"
 	"net/http"
 	"net/url"
 
@@ -20,10 +24,26 @@ const (
 	tracerName = "stellar-friendbot"
 )
 
+// ErrIdempotencyKeyConflict is returned when an idempotency key is reused for
+// a request to fund a different address.
+var ErrIdempotencyKeyConflict = errors.New("idempotency key was already used for a different address")
+
+// ErrIdempotencyKeyVersionConflict is returned when an idempotency key is
+// reused for a request asking for a different version of the response.
+var ErrIdempotencyKeyVersionConflict = errors.New("idempotency key was already used for a different response version")
+
+// ErrContractFundingDisabled is returned for contract addresses when funding
+// them is disabled, or not supported by the network client.
+var ErrContractFundingDisabled = errors.New("contract addresses are not supported or enabled")
+
 // FriendbotHandler causes an account at `Address` to be created.
 type FriendbotHandler struct {
 	Friendbot *Bot
-	tracer    trace.Tracer
+	// IdempotencyStore, if set, stores responses to requests made with an
+	// Idempotency-Key header so that retries replay the original response.
+	IdempotencyStore IdempotencyStore
+	tracer           trace.Tracer
+	idempotencyLocks keyLocks
 }
 
 // NewFriendbotHandler returns friendbot handler based on the tracing enabled
@@ -40,6 +60,7 @@ func NewFriendbotHandler(fb *Bot) *FriendbotHandler {
 func (handler *FriendbotHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	ctx, span := handler.tracer.Start(r.Context(), "friendbot.init_http_request")
 	defer span.End()
+	ctx = WithClient(ctx, clientIP(r))
 
 	// Add request attributes to span
 	span.SetAttributes(
@@ -48,19 +69,168 @@ func (handler *FriendbotHandler) Handle(w http.ResponseWriter, r *http.Request)
 		attribute.String("http.user_agent", r.UserAgent()),
 	)
 
-	result, err := handler.doHandle(ctx, r)
+	if key := r.Header.Get(IdempotencyKeyHeader); key != "" && handler.IdempotencyStore != nil {
+		// Let the regular handling report malformed requests.
+		if err := r.ParseForm(); err == nil {
+			if version, err := requestedAPIVersion(r); err == nil {
+				handler.handleIdempotent(ctx, w, key, r.Form.Get("addr"), version, func(ctx context.Context, w http.ResponseWriter) {
+					handler.respond(ctx, w, r)
+				})
+				return
+			}
+		}
+	}
+	handler.respond(ctx, w, r)
+}
+
+// HandleJSON is a method that implements http.HandlerFunc for requests with a
+// JSON FundRequest body. They are always answered with the version 2
+// response.
+func (handler *FriendbotHandler) HandleJSON(w http.ResponseWriter, r *http.Request) {
+	ctx, span := handler.tracer.Start(r.Context(), "friendbot.init_http_request")
+	defer span.End()
+	ctx = WithClient(ctx, clientIP(r))
+
+	span.SetAttributes(
+		attribute.String("http.method", r.Method),
+		attribute.String("http.url", r.URL.String()),
+		attribute.String("http.user_agent", r.UserAgent()),
+		attribute.Int("friendbot.api_version", APIVersion2),
+	)
+
+	req, err := handler.decodeFundRequest(ctx, w, r)
+	if err != nil {
+		problem.Render(ctx, w, err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if req.IdempotencyKey != "" {
+		if key != "" && key != req.IdempotencyKey {
+			err := fmt.Errorf("must match the %s header", IdempotencyKeyHeader)
+			problem.Render(ctx, w, problem.MakeInvalidFieldProblem("idempotency_key", err))
+			span.SetStatus(codes.Error, err.Error())
+			return
+		}
+		key = req.IdempotencyKey
+	}
+	respond := func(ctx context.Context, w http.ResponseWriter) {
+		handler.respondJSON(ctx, w, req)
+	}
+	if key != "" && handler.IdempotencyStore != nil {
+		handler.handleIdempotent(ctx, w, key, req.Addr, APIVersion2, respond)
+		return
+	}
+	respond(ctx, w)
+}
+
+// respond handles the request and renders the result or problem to w. The
+// result is rendered in the version of the response the request asks for.
+func (handler *FriendbotHandler) respond(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	span := trace.SpanFromContext(ctx)
+	w.Header().Add("Vary", "Accept")
+	result, version, err := handler.doHandle(ctx, r)
 	if err != nil {
-		problem.Render(r.Context(), w, err)
+		problem.Render(ctx, w, err)
 		span.SetStatus(codes.Error, err.Error())
 		return
 	}
 
 	span.SetStatus(codes.Ok, codes.Ok.String())
+	if version == APIVersion2 {
+		renderFundingResponse(w, result)
+		return
+	}
 	hal.Render(w, *result)
 }
 
-// doHandle is just a convenience method that returns the object to be rendered
-func (handler *FriendbotHandler) doHandle(ctx context.Context, r *http.Request) (*TransactionResult, error) {
+// respondJSON funds the address of req and renders the version 2 response or
+// problem to w.
+func (handler *FriendbotHandler) respondJSON(ctx context.Context, w http.ResponseWriter, req FundRequest) {
+	span := trace.SpanFromContext(ctx)
+	address, memo, err := handler.validateFundRequest(ctx, req)
+	if err != nil {
+		problem.Render(ctx, w, err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	result, err := handler.Friendbot.PayWithMemo(ctx, address, memo)
+	if err != nil {
+		problem.Render(ctx, w, err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	span.SetStatus(codes.Ok, codes.Ok.String())
+	renderFundingResponse(w, result)
+}
+
+// handleIdempotent replays the response stored for the idempotency key if
+// there is one, and otherwise responds to the request for address, asking for
+// the given version of the response, and stores its response. Server errors
+// are not stored, since the request may succeed if it is retried, and a
+// request that timed out may have funded the address, which the retry then
+// reports.
+func (handler *FriendbotHandler) handleIdempotent(ctx context.Context, w http.ResponseWriter, key, address string, version int, respond func(context.Context, http.ResponseWriter)) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("idempotency.key", key))
+
+	if len(key) > maxIdempotencyKeyLength {
+		err := fmt.Errorf("must be at most %d characters", maxIdempotencyKeyLength)
+		problem.Render(ctx, w, problem.MakeInvalidFieldProblem(IdempotencyKeyHeader, err))
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	unlock := handler.idempotencyLocks.lock(key)
+	defer unlock()
+
+	record, err := handler.IdempotencyStore.Get(ctx, key)
+	if err != nil {
+		problem.Render(ctx, w, err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	if record != nil {
+		if record.Address != address {
+			problem.Render(ctx, w, ErrIdempotencyKeyConflict)
+			span.SetStatus(codes.Error, ErrIdempotencyKeyConflict.Error())
+			return
+		}
+		if record.Version != version {
+			problem.Render(ctx, w, ErrIdempotencyKeyVersionConflict)
+			span.SetStatus(codes.Error, ErrIdempotencyKeyVersionConflict.Error())
+			return
+		}
+		span.SetAttributes(attribute.Bool("idempotency.replayed", true))
+		w.Header().Set("Content-Type", record.ContentType)
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(record.StatusCode)
+		w.Write(record.Body)
+		return
+	}
+
+	capture := newResponseCapture()
+	respond(ctx, capture)
+	if capture.status < http.StatusInternalServerError {
+		err = handler.IdempotencyStore.Put(ctx, key, IdempotencyRecord{
+			Address:     address,
+			Version:     version,
+			StatusCode:  capture.status,
+			ContentType: capture.header.Get("Content-Type"),
+			Body:        capture.body.Bytes(),
+		})
+		if err != nil {
+			log.Printf("Failed to store response for idempotency key %q: %v", key, err)
+		}
+	}
+	capture.writeTo(w)
+}
+
+// doHandle is just a convenience method that returns the object to be
+// rendered, and the version of the response to render it in.
+func (handler *FriendbotHandler) doHandle(ctx context.Context, r *http.Request) (*TransactionResult, int, error) {
 	ctx, span := handler.tracer.Start(ctx, "friendbot.parse_http_request")
 	defer span.End()
 	err := r.ParseForm()
@@ -68,55 +238,142 @@ func (handler *FriendbotHandler) doHandle(ctx context.Context, r *http.Request)
 		p := problem.BadRequest
 		p.Detail = "Request parameters are not escaped or incorrectly formatted."
 		span.SetStatus(codes.Error, err.Error())
-		return nil, &p
+		return nil, 0, &p
 	}
 
+	version, err := requestedAPIVersion(r)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, 0, problem.MakeInvalidFieldProblem("api_version", err)
+	}
+	span.SetAttributes(attribute.Int("friendbot.api_version", version))
+
 	address, err := handler.loadAddress(ctx, r)
 	if err != nil {
 		span.SetStatus(codes.Error, err.Error())
-		return nil, problem.MakeInvalidFieldProblem("addr", err)
+		return nil, 0, addressProblem(err)
+	}
+	memo, err := requestMemo(address, r.Form.Get("memo_type"), r.Form.Get("memo"))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, 0, err
 	}
 	span.SetStatus(codes.Ok, codes.Ok.String())
-	return handler.Friendbot.Pay(ctx, address)
+	result, err := handler.Friendbot.PayWithMemo(ctx, address, memo)
+	return result, version, err
 }
 
+// loadAddress returns the destination address of the addr parameter of r,
+// once validated.
 func (handler *FriendbotHandler) loadAddress(ctx context.Context, r *http.Request) (string, error) {
+	unescaped, err := url.QueryUnescape(r.Form.Get("addr"))
+	if err != nil {
+		_, span := handler.tracer.Start(ctx, "minion.destination_address")
+		defer span.End()
+		span.SetStatus(codes.Error, err.Error())
+		return unescaped, err
+	}
+	return handler.validateAddress(ctx, unescaped)
+}
+
+// validateAddress returns address if it is an account (G) address, or a
+// contract (C) address the bot is able to fund.
+func (handler *FriendbotHandler) validateAddress(ctx context.Context, address string) (string, error) {
 	_, span := handler.tracer.Start(ctx, "minion.destination_address")
 	defer span.End()
 
-	address := r.Form.Get("addr")
 	if address == "" {
 		span.SetStatus(codes.Error, "missing destination account address")
 		span.SetAttributes(attribute.String("error.type", "missing_parameter"))
 	}
 
-	unescaped, err := url.QueryUnescape(address)
-	if err != nil {
-		span.SetStatus(codes.Error, err.Error())
-		return unescaped, err
-	}
-
 	// Log the address for tracing (even if invalid)
-	span.SetAttributes(attribute.String("destination.address", unescaped))
+	span.SetAttributes(attribute.String("destination.address", address))
 
 	// Check if it's a valid G address (account)
-	if strkey.IsValidEd25519PublicKey(unescaped) {
+	if strkey.IsValidEd25519PublicKey(address) {
 		span.SetStatus(codes.Ok, codes.Ok.String())
-		return unescaped, nil
+		return address, nil
 	}
 
 	// Check if it's a valid C address (contract)
-	if strkey.IsValidContractAddress(unescaped) {
+	if strkey.IsValidContractAddress(address) {
 		if !handler.Friendbot.SupportsContractAddresses() {
-			err = errors.New("contract addresses are not supported or enabled")
-			span.SetStatus(codes.Error, err.Error())
-			return unescaped, err
+			span.SetStatus(codes.Error, ErrContractFundingDisabled.Error())
+			return address, ErrContractFundingDisabled
 		}
 		span.SetStatus(codes.Ok, codes.Ok.String())
-		return unescaped, nil
+		return address, nil
 	}
 
-	err = errors.New("invalid address: must be a valid G or C address")
+	err := errors.New("invalid address: must be a valid G or C address")
 	span.SetStatus(codes.Error, err.Error())
-	return unescaped, err
+	return address, err
+}
+
+// addressProblem returns the problem to render for an address rejected by
+// validateAddress.
+func addressProblem(err error) error {
+	if errors.Is(err, ErrContractFundingDisabled) {
+		return err
+	}
+	return problem.MakeInvalidFieldProblem("addr", err)
+}
+
+// requestMemo returns the memo a request to fund address asks for, or the
+// problem to render if it is invalid.
+func requestMemo(address, memoType, value string) (Memo, error) {
+	memo, err := ParseMemo(memoType, value)
+	if errors.Is(err, errInvalidMemoType) {
+		return Memo{}, problem.MakeInvalidFieldProblem("memo_type", err)
+	}
+	if err != nil {
+		return Memo{}, problem.MakeInvalidFieldProblem("memo", err)
+	}
+	if !memo.IsZero() && strkey.IsValidContractAddress(address) {
+		return Memo{}, problem.MakeInvalidFieldProblem("memo", errors.New("memos are not supported for contract addresses"))
+	}
+	return memo, nil
+}
+
+// clientIP returns the IP address of the client that made the request. The
+// XFF middleware may have already replaced RemoteAddr with a bare IP.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// responseCapture is an http.ResponseWriter that buffers the response so it
+// can be stored before being written to the client.
+type responseCapture struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseCapture() *responseCapture {
+	return &responseCapture{header: http.Header{}, status: http.StatusOK}
+}
+
+func (c *responseCapture) Header() http.Header {
+	return c.header
+}
+
+func (c *responseCapture) Write(b []byte) (int, error) {
+	return c.body.Write(b)
+}
+
+func (c *responseCapture) WriteHeader(status int) {
+	c.status = status
+}
+
+func (c *responseCapture) writeTo(w http.ResponseWriter) {
+	for k, v := range c.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(c.status)
+	w.Write(c.body.Bytes())
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stellar/go-stellar-sdk/txnbuild"
 
@@ -48,7 +49,7 @@ func TestFriendbot_Pay_accountDoesNotExist(t *testing.T) {
 		},
 		Keypair:              minionKeypair.(*keypair.Full),
 		BotAccount:           botAccount,
-		BotKeypair:           botKeypair.(*keypair.Full),
+		BotSigner:            NewKeypairSigner(botKeypair.(*keypair.Full)),
 		Network:              "Test SDF Network ; September 2015",
 		StartingBalance:      "10000.00",
 		SubmitTransaction:    mockSubmitTransaction,
@@ -121,7 +122,7 @@ func TestFriendbot_Pay_accountExists(t *testing.T) {
 		},
 		Keypair:              minionKeypair.(*keypair.Full),
 		BotAccount:           botAccount,
-		BotKeypair:           botKeypair.(*keypair.Full),
+		BotSigner:            NewKeypairSigner(botKeypair.(*keypair.Full)),
 		Network:              "Test SDF Network ; September 2015",
 		StartingBalance:      "10000.00",
 		SubmitTransaction:    mockSubmitTransaction,
@@ -190,7 +191,7 @@ func TestFriendbot_Pay_accountExistsAlreadyFunded(t *testing.T) {
 		},
 		Keypair:              minionKeypair.(*keypair.Full),
 		BotAccount:           botAccount,
-		BotKeypair:           botKeypair.(*keypair.Full),
+		BotSigner:            NewKeypairSigner(botKeypair.(*keypair.Full)),
 		Network:              "Test SDF Network ; September 2015",
 		StartingBalance:      "10000.00",
 		SubmitTransaction:    mockSubmitTransaction,
@@ -204,3 +205,132 @@ func TestFriendbot_Pay_accountExistsAlreadyFunded(t *testing.T) {
 	_, err = fb.Pay(ctx, recipientAddress)
 	assert.ErrorIs(t, err, ErrAccountFunded)
 }
+
+func TestFriendbot_Pay_coalescesConcurrentPayments(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		numTxSubmits int
+		mux          sync.Mutex
+	)
+	submitStarted := make(chan struct{})
+	releaseSubmit := make(chan struct{})
+	mockSubmitTransaction := func(ctx context.Context, minion *Minion, networkClient NetworkClient, txHash [32]byte, tx string) (*TransactionResult, error) {
+		mux.Lock()
+		numTxSubmits++
+		mux.Unlock()
+		close(submitStarted)
+		<-releaseSubmit
+		return &TransactionResult{Successful: true, EnvelopeXdr: tx}, nil
+	}
+
+	mockCheckAccountExists := func(ctx context.Context, minion *Minion, networkClient NetworkClient, destAddress string) (bool, string, error) {
+		return false, "0", nil
+	}
+
+	// Public key: GD25B4QI6KWVDWXDW25CIM7EKR6A6PBSWE2RCNSAC4NJQDQJXZJYMMKR
+	botSeed := "SCWNLYELENPBXN46FHYXETT5LJCYBZD5VUQQVW4KZPHFO2YTQJUWT4D5"
+	botKeypair, err := keypair.Parse(botSeed)
+	if !assert.NoError(t, err) {
+		return
+	}
+	botAccount := Account{AccountID: botKeypair.Address()}
+
+	// Public key: GD4AGPPDFFHKK3Z2X4XZDRXX6GZQKP4FMLVQ5T55NDEYGG3GIP7BQUHM
+	minionSeed := "SDTNSEERJPJFUE2LSDNYBFHYGVTPIWY7TU2IOJZQQGLWO2THTGB7NU5A"
+	minionKeypair, err := keypair.Parse(minionSeed)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	minion := Minion{
+		Account: Account{
+			AccountID: minionKeypair.Address(),
+			Sequence:  1,
+		},
+		Keypair:              minionKeypair.(*keypair.Full),
+		BotAccount:           botAccount,
+		BotSigner:            NewKeypairSigner(botKeypair.(*keypair.Full)),
+		Network:              "Test SDF Network ; September 2015",
+		StartingBalance:      "10000.00",
+		SubmitTransaction:    mockSubmitTransaction,
+		CheckSequenceRefresh: CheckSequenceRefresh,
+		CheckAccountExists:   mockCheckAccountExists,
+		BaseFee:              txnbuild.MinBaseFee,
+	}
+	fb := &Bot{Minions: []Minion{minion, minion}, ReplayTTL: time.Minute}
+
+	recipientAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+
+	results := make(chan *TransactionResult, 2)
+	go func() {
+		result, err := fb.Pay(ctx, recipientAddress)
+		assert.NoError(t, err)
+		results <- result
+	}()
+	<-submitStarted
+	go func() {
+		result, err := fb.Pay(ctx, recipientAddress)
+		assert.NoError(t, err)
+		results <- result
+	}()
+	close(releaseSubmit)
+
+	first, second := <-results, <-results
+	assert.Same(t, first, second)
+
+	// A payment made after the first completed is replayed within the TTL.
+	replayed, err := fb.Pay(ctx, recipientAddress)
+	assert.NoError(t, err)
+	assert.Same(t, first, replayed)
+
+	assert.Equal(t, 1, numTxSubmits)
+}
+
+func TestFriendbot_Pay_doesNotReplayFailedPayments(t *testing.T) {
+	ctx := context.Background()
+
+	numChecks := 0
+	mockCheckAccountExists := func(ctx context.Context, minion *Minion, networkClient NetworkClient, destAddress string) (bool, string, error) {
+		numChecks++
+		return true, "10000.00", nil
+	}
+
+	// Public key: GD25B4QI6KWVDWXDW25CIM7EKR6A6PBSWE2RCNSAC4NJQDQJXZJYMMKR
+	botSeed := "SCWNLYELENPBXN46FHYXETT5LJCYBZD5VUQQVW4KZPHFO2YTQJUWT4D5"
+	botKeypair, err := keypair.Parse(botSeed)
+	if !assert.NoError(t, err) {
+		return
+	}
+	botAccount := Account{AccountID: botKeypair.Address()}
+
+	// Public key: GD4AGPPDFFHKK3Z2X4XZDRXX6GZQKP4FMLVQ5T55NDEYGG3GIP7BQUHM
+	minionSeed := "SDTNSEERJPJFUE2LSDNYBFHYGVTPIWY7TU2IOJZQQGLWO2THTGB7NU5A"
+	minionKeypair, err := keypair.Parse(minionSeed)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	minion := Minion{
+		Account: Account{
+			AccountID: minionKeypair.Address(),
+			Sequence:  1,
+		},
+		Keypair:              minionKeypair.(*keypair.Full),
+		BotAccount:           botAccount,
+		BotSigner:            NewKeypairSigner(botKeypair.(*keypair.Full)),
+		Network:              "Test SDF Network ; September 2015",
+		StartingBalance:      "10000.00",
+		CheckSequenceRefresh: CheckSequenceRefresh,
+		CheckAccountExists:   mockCheckAccountExists,
+		BaseFee:              txnbuild.MinBaseFee,
+	}
+	fb := &Bot{Minions: []Minion{minion}, ReplayTTL: time.Minute}
+
+	recipientAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+	_, err = fb.Pay(ctx, recipientAddress)
+	assert.ErrorIs(t, err, ErrAccountFunded)
+	_, err = fb.Pay(ctx, recipientAddress)
+	assert.ErrorIs(t, err, ErrAccountFunded)
+	assert.Equal(t, 2, numChecks)
+}
@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/stellar/go-stellar-sdk/amount"
+	"github.com/stellar/go-stellar-sdk/support/render/problem"
+	"github.com/xeipuuv/gojsonschema"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// NativeAsset is the asset of FundRequest for XLM, the only asset friendbot
+// funds addresses with.
+const NativeAsset = "native"
+
+// maxFundRequestSize is the largest FundRequest body accepted, in bytes.
+const maxFundRequestSize = 64 << 10
+
+// FundRequestSchema is the JSON schema of FundRequest bodies.
+//
+//go:embed fund_request.json
+var FundRequestSchema []byte
+
+var fundRequestSchema = mustLoadSchema(FundRequestSchema)
+
+// FundRequest is the JSON body of a request to fund an address.
+type FundRequest struct {
+	Addr string `json:"addr"`
+	// Amount, if set, must be the amount friendbot funds addresses with.
+	Amount string `json:"amount,omitempty"`
+	// Asset, if set, must be NativeAsset.
+	Asset string `json:"asset,omitempty"`
+	// Memo is the memo of the funding transaction, of MemoType, which
+	// defaults to MemoTypeText.
+	Memo           string `json:"memo,omitempty"`
+	MemoType       string `json:"memo_type,omitempty"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+// mustLoadSchema returns the compiled JSON schema, and panics if it is
+// invalid.
+func mustLoadSchema(schema []byte) *gojsonschema.Schema {
+	s, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schema))
+	if err != nil {
+		panic(fmt.Sprintf("invalid JSON schema: %v", err))
+	}
+	return s
+}
+
+// decodeFundRequest returns the FundRequest in the body of r, once it is
+// validated against FundRequestSchema.
+func (handler *FriendbotHandler) decodeFundRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) (FundRequest, error) {
+	_, span := handler.tracer.Start(ctx, "friendbot.parse_json_request")
+	defer span.End()
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		span.SetStatus(codes.Error, "unsupported media type")
+		return FundRequest{}, &problem.P{
+			Type:   "unsupported_media_type",
+			Title:  "Unsupported Media Type",
+			Status: http.StatusUnsupportedMediaType,
+			Detail: "The request body must be application/json.",
+		}
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxFundRequestSize))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		p := problem.BadRequest
+		p.Detail = "The request body could not be read."
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			p.Detail = fmt.Sprintf("The request body must be at most %d bytes.", maxFundRequestSize)
+		}
+		return FundRequest{}, &p
+	}
+
+	result, err := fundRequestSchema.Validate(gojsonschema.NewBytesLoader(body))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		p := problem.BadRequest
+		p.Detail = "The request body is not valid JSON."
+		return FundRequest{}, &p
+	}
+	if !result.Valid() {
+		field, reason := schemaError(result.Errors()[0])
+		span.SetStatus(codes.Error, reason)
+		span.SetAttributes(attribute.String("error.type", "invalid_body"))
+		return FundRequest{}, problem.MakeInvalidFieldProblem(field, errors.New(reason))
+	}
+
+	var req FundRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		p := problem.BadRequest
+		p.Detail = "The request body is not valid JSON."
+		return FundRequest{}, &p
+	}
+	span.SetStatus(codes.Ok, codes.Ok.String())
+	return req, nil
+}
+
+// schemaError returns the field of the body and the reason for a violation of
+// FundRequestSchema.
+func schemaError(e gojsonschema.ResultError) (field, reason string) {
+	field = e.Field()
+	if field == gojsonschema.STRING_CONTEXT_ROOT {
+		// Missing and unknown properties are reported for the body itself.
+		if property, ok := e.Details()["property"].(string); ok {
+			field = property
+		} else {
+			field = "body"
+		}
+	}
+	return strings.TrimPrefix(field, gojsonschema.STRING_CONTEXT_ROOT+"."), e.Description()
+}
+
+// validateFundRequest returns the address req asks to fund and the memo to
+// fund it with, once its fields are checked against what the bot is able to
+// fund.
+func (handler *FriendbotHandler) validateFundRequest(ctx context.Context, req FundRequest) (string, Memo, error) {
+	address, err := handler.validateAddress(ctx, req.Addr)
+	if err != nil {
+		return "", Memo{}, addressProblem(err)
+	}
+	if req.Amount != "" {
+		if starting := handler.Friendbot.StartingBalance(); starting != "" && !sameAmount(req.Amount, starting) {
+			err := fmt.Errorf("must be %s, the amount friendbot funds addresses with", normalizeAmount(starting))
+			return "", Memo{}, problem.MakeInvalidFieldProblem("amount", err)
+		}
+	}
+	if req.Asset != "" && req.Asset != NativeAsset {
+		return "", Memo{}, problem.MakeInvalidFieldProblem("asset", fmt.Errorf("must be %q", NativeAsset))
+	}
+	memo, err := requestMemo(address, req.MemoType, req.Memo)
+	if err != nil {
+		return "", Memo{}, err
+	}
+	return address, memo, nil
+}
+
+// sameAmount returns true if a and b are the same valid amount.
+func sameAmount(a, b string) bool {
+	x, err := amount.ParseInt64(a)
+	if err != nil {
+		return false
+	}
+	y, err := amount.ParseInt64(b)
+	return err == nil && x == y
+}
@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stellar/go-stellar-sdk/amount"
+	"github.com/stellar/go-stellar-sdk/strkey"
+	"github.com/stellar/go-stellar-sdk/support/errors"
+)
+
+// Versions of the response to a successful funding. Version 1 is the
+// default, so that existing clients keep receiving the same response.
+const (
+	APIVersion1 = 1
+	APIVersion2 = 2
+)
+
+// APIVersion2MediaType is the media type of the version 2 response. Clients
+// opt in to version 2 by including it in the Accept header, or with the
+// api_version=2 parameter.
+const APIVersion2MediaType = "application/vnd.stellar.friendbot.v2+json"
+
+// Funding actions in the version 2 response.
+const (
+	FundingActionCreated  = "created"
+	FundingActionToppedUp = "topped_up"
+)
+
+// FundingResponse is the version 2 response to a successful funding.
+type FundingResponse struct {
+	Successful  bool   `json:"successful"`
+	Hash        string `json:"hash"`
+	EnvelopeXdr string `json:"envelope_xdr"`
+	// Address is the funded address, and AddressType whether it is an
+	// account or a contract.
+	Address     string `json:"addr"`
+	AddressType string `json:"addr_type"`
+	// Amount is the amount of XLM sent to the address.
+	Amount string `json:"amount"`
+	// Action is FundingActionCreated if the funding created the account, and
+	// FundingActionToppedUp if it paid an existing account or contract.
+	Action string `json:"action"`
+	// Ledger is the sequence of the ledger the transaction was included in,
+	// LedgerCloseTime the time the ledger closed, ResultXdr the result of the
+	// transaction and FeeCharged the fee it was charged in stroops. They are
+	// omitted if the network did not report them.
+	Ledger          uint32     `json:"ledger,omitempty"`
+	LedgerCloseTime *time.Time `json:"ledger_close_time,omitempty"`
+	ResultXdr       string     `json:"result_xdr,omitempty"`
+	FeeCharged      int64      `json:"fee_charged,omitempty"`
+	// Memo is the memo of the transaction, omitted if it has none.
+	Memo *Memo `json:"memo,omitempty"`
+}
+
+// NewFundingResponse returns the version 2 response for result.
+func NewFundingResponse(result *TransactionResult) FundingResponse {
+	response := FundingResponse{
+		Successful:  result.Successful,
+		Hash:        result.Hash,
+		EnvelopeXdr: result.EnvelopeXdr,
+		Address:     result.Destination,
+		AddressType: AddressTypeAccount,
+		Amount:      result.Amount,
+		Action:      FundingActionToppedUp,
+		Ledger:      result.Ledger,
+		ResultXdr:   result.ResultXdr,
+		FeeCharged:  result.FeeCharged,
+	}
+	if !result.LedgerCloseTime.IsZero() {
+		response.LedgerCloseTime = &result.LedgerCloseTime
+	}
+	if strkey.IsValidContractAddress(result.Destination) {
+		response.AddressType = AddressTypeContract
+	}
+	if result.Created {
+		response.Action = FundingActionCreated
+	}
+	if !result.Memo.IsZero() {
+		memo := result.Memo
+		response.Memo = &memo
+	}
+	return response
+}
+
+// requestedAPIVersion returns the version of the response the request asks
+// for with its api_version parameter or, failing that, its Accept header. The
+// form of the request must already be parsed.
+func requestedAPIVersion(r *http.Request) (int, error) {
+	switch r.Form.Get("api_version") {
+	case "":
+	case "1":
+		return APIVersion1, nil
+	case "2":
+		return APIVersion2, nil
+	default:
+		return 0, errors.New("must be 1 or 2")
+	}
+
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType, _, err := mime.ParseMediaType(mediaRange)
+			if err == nil && mediaType == APIVersion2MediaType {
+				return APIVersion2, nil
+			}
+		}
+	}
+	return APIVersion1, nil
+}
+
+// renderFundingResponse writes the version 2 response for result to w.
+func renderFundingResponse(w http.ResponseWriter, result *TransactionResult) {
+	w.Header().Set("Content-Type", APIVersion2MediaType+"; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NewFundingResponse(result))
+}
+
+// normalizeAmount returns the amount in its canonical form, with seven
+// decimal places, or unchanged if it cannot be parsed.
+func normalizeAmount(a string) string {
+	stroops, err := amount.ParseInt64(a)
+	if err != nil {
+		return a
+	}
+	return amount.StringFromInt64(stroops)
+}
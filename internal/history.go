@@ -0,0 +1,227 @@
+package internal
+
+import (
+	"context"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/stellar/go-stellar-sdk/strkey"
+	"github.com/stellar/go-stellar-sdk/support/errors"
+	"github.com/stellar/go-stellar-sdk/support/render/problem"
+)
+
+// Funding outcomes recorded in the funding history.
+const (
+	FundingOutcomeSuccess = "success"
+	FundingOutcomeFailure = "failure"
+)
+
+// Address types recorded in the funding history.
+const (
+	AddressTypeAccount  = "account"
+	AddressTypeContract = "contract"
+)
+
+// FundingRecord describes a single attempt to fund an address.
+type FundingRecord struct {
+	ID          int64     `json:"id,string"`
+	CreatedAt   time.Time `json:"created_at"`
+	Address     string    `json:"addr"`
+	AddressType string    `json:"addr_type"`
+	Amount      string    `json:"amount,omitempty"`
+	Minion      string    `json:"minion,omitempty"`
+	Hash        string    `json:"hash,omitempty"`
+	Outcome     string    `json:"outcome"`
+	Problem     string    `json:"problem,omitempty"`
+	// ClientIP is recorded for operators but never returned by the API.
+	ClientIP  string `json:"-"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// RefillRecord describes a refill of the bot account from the treasury that
+// counts against the daily refill maximum.
+type RefillRecord struct {
+	CreatedAt time.Time
+	// Amount is the amount refilled, in stroops.
+	Amount int64
+}
+
+// PagingToken implements hal.Pageable.
+func (r FundingRecord) PagingToken() string {
+	return strconv.FormatInt(r.ID, 10)
+}
+
+// HistoryPageQuery selects a page of funding records, in the same style as
+// Horizon's paging parameters.
+type HistoryPageQuery struct {
+	// Cursor is the ID of the record to start after, or 0 to start from the
+	// beginning (or end, for descending order).
+	Cursor int64
+	// Order is either "asc" or "desc".
+	Order string
+	Limit int
+}
+
+// HistoryStore records funding attempts and answers queries about them.
+type HistoryStore interface {
+	// RecordFunding stores a funding attempt.
+	RecordFunding(ctx context.Context, record FundingRecord) error
+	// FundingsByAddress returns a page of funding attempts for an address.
+	FundingsByAddress(ctx context.Context, address string, page HistoryPageQuery) ([]FundingRecord, error)
+	// FundingByHash returns the funding attempt that submitted the transaction
+	// with the given hash, or sql.ErrNoRows if there is none.
+	FundingByHash(ctx context.Context, hash string) (FundingRecord, error)
+	// FundingsSince returns the funding attempts made at or after t, oldest
+	// first.
+	FundingsSince(ctx context.Context, t time.Time) ([]FundingRecord, error)
+	// DeleteFundingsBefore removes the funding attempts made before t, and
+	// returns how many were removed.
+	DeleteFundingsBefore(ctx context.Context, t time.Time) (int64, error)
+	// RecordRefill stores a treasury refill.
+	RecordRefill(ctx context.Context, record RefillRecord) error
+	// RefillsSince returns the treasury refills made at or after t, oldest
+	// first.
+	RefillsSince(ctx context.Context, t time.Time) ([]RefillRecord, error)
+	// DeleteRefillsBefore removes the treasury refills made before t.
+	DeleteRefillsBefore(ctx context.Context, t time.Time) error
+}
+
+// errHistoryBufferFull is returned by HistoryRecorder.RecordFunding when the
+// store cannot keep up with the funding attempts.
+var errHistoryBufferFull = errors.New("history buffer is full")
+
+// historyPruneInterval is how often a HistoryRecorder removes funding
+// attempts older than its retention.
+const historyPruneInterval = time.Hour
+
+// HistoryRecorder is a HistoryStore that records funding attempts in the
+// background, so that payments never wait on the underlying store. Attempts
+// are dropped if more than its buffer are waiting to be recorded. Attempts
+// and refills older than its retention are removed periodically.
+type HistoryRecorder struct {
+	HistoryStore
+	retention time.Duration
+	records   chan FundingRecord
+	done      chan struct{}
+}
+
+// Ensure HistoryRecorder implements the HistoryStore interface.
+var _ HistoryStore = (*HistoryRecorder)(nil)
+
+// NewHistoryRecorder returns a recorder writing to store, holding up to
+// bufferSize attempts waiting to be recorded, and keeping attempts for
+// retention, or forever if it is 0. Close must be called to stop it.
+func NewHistoryRecorder(store HistoryStore, bufferSize int, retention time.Duration) *HistoryRecorder {
+	r := &HistoryRecorder{
+		HistoryStore: store,
+		retention:    retention,
+		records:      make(chan FundingRecord, bufferSize),
+		done:         make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// RecordFunding queues a funding attempt to be recorded, and returns an error
+// without waiting if the buffer is full.
+func (r *HistoryRecorder) RecordFunding(ctx context.Context, record FundingRecord) error {
+	select {
+	case r.records <- record:
+		return nil
+	default:
+		return errHistoryBufferFull
+	}
+}
+
+// Close records the attempts still waiting, stops the recorder and closes
+// the underlying store if it is an io.Closer. RecordFunding must not be
+// called afterwards.
+func (r *HistoryRecorder) Close() error {
+	close(r.records)
+	<-r.done
+	if closer, ok := r.HistoryStore.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (r *HistoryRecorder) run() {
+	defer close(r.done)
+	ticker := time.NewTicker(historyPruneInterval)
+	defer ticker.Stop()
+	r.prune()
+	for {
+		select {
+		case record, ok := <-r.records:
+			if !ok {
+				return
+			}
+			if err := r.HistoryStore.RecordFunding(context.Background(), record); err != nil {
+				log.Printf("Failed to record funding of %s: %v", record.Address, err)
+			}
+		case <-ticker.C:
+			r.prune()
+		}
+	}
+}
+
+// prune removes the attempts and refills older than the retention.
+func (r *HistoryRecorder) prune() {
+	if r.retention == 0 {
+		return
+	}
+	before := time.Now().Add(-r.retention)
+	if err := r.HistoryStore.DeleteRefillsBefore(context.Background(), before); err != nil {
+		log.Printf("Failed to remove old treasury refills: %v", err)
+	}
+	deleted, err := r.HistoryStore.DeleteFundingsBefore(context.Background(), before)
+	if err != nil {
+		log.Printf("Failed to remove old funding history: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Removed %d funding attempts older than %s from the history", deleted, r.retention)
+	}
+}
+
+// newFundingRecord builds the history record of an attempt to fund
+// destAddress using minion, which is the zero Minion if none was acquired.
+func newFundingRecord(ctx context.Context, start time.Time, destAddress string, minion Minion, result *TransactionResult, err error) FundingRecord {
+	record := FundingRecord{
+		CreatedAt:   start.UTC(),
+		Address:     destAddress,
+		AddressType: AddressTypeAccount,
+		Amount:      minion.StartingBalance,
+		Minion:      minion.Account.AccountID,
+		Outcome:     FundingOutcomeSuccess,
+		ClientIP:    clientFromContext(ctx),
+		LatencyMs:   time.Since(start).Milliseconds(),
+	}
+	if strkey.IsValidContractAddress(destAddress) {
+		record.AddressType = AddressTypeContract
+	}
+	if result != nil {
+		record.Hash = result.Hash
+	}
+	if err != nil {
+		record.Outcome = FundingOutcomeFailure
+		record.Problem = problemType(err)
+	}
+	return record
+}
+
+// problemType returns the type of the problem that err is rendered as.
+func problemType(err error) string {
+	switch p := errors.Cause(err).(type) {
+	case problem.P:
+		return p.Type
+	case *problem.P:
+		return p.Type
+	}
+	if known, ok := problem.IsKnownError(err).(problem.P); ok {
+		return known.Type
+	}
+	return problem.ServerError.Type
+}
@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stellar/go-stellar-sdk/strkey"
+	"github.com/stellar/go-stellar-sdk/support/render/hal"
+	"github.com/stellar/go-stellar-sdk/support/render/problem"
+)
+
+const (
+	defaultHistoryPageLimit = 10
+	maxHistoryPageLimit     = 200
+)
+
+// HistoryHandler serves queries about past funding attempts.
+type HistoryHandler struct {
+	History HistoryStore
+}
+
+// NewHistoryHandler returns a handler serving queries against store.
+func NewHistoryHandler(store HistoryStore) *HistoryHandler {
+	return &HistoryHandler{History: store}
+}
+
+// ListFundings serves a page of the funding attempts for the `addr` query
+// parameter.
+func (handler *HistoryHandler) ListFundings(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("addr")
+	if !strkey.IsValidEd25519PublicKey(address) && !strkey.IsValidContractAddress(address) {
+		problem.Render(r.Context(), w, problem.MakeInvalidFieldProblem("addr", errors.New("invalid address: must be a valid G or C address")))
+		return
+	}
+
+	query, err := parseHistoryPageQuery(r)
+	if err != nil {
+		problem.Render(r.Context(), w, err)
+		return
+	}
+
+	records, err := handler.History.FundingsByAddress(r.Context(), address, query)
+	if err != nil {
+		problem.Render(r.Context(), w, err)
+		return
+	}
+
+	page := hal.Page{
+		Order:  query.Order,
+		Limit:  uint64(query.Limit),
+		Cursor: r.URL.Query().Get("cursor"),
+	}
+	page.FullURL = r.URL
+	for _, record := range records {
+		page.Add(record)
+	}
+	page.PopulateLinks()
+	hal.Render(w, page)
+}
+
+// GetFunding serves the funding attempt that submitted the transaction with
+// the `hash` URL parameter.
+func (handler *HistoryHandler) GetFunding(w http.ResponseWriter, r *http.Request) {
+	hash := chi.URLParam(r, "hash")
+	if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != 32 {
+		problem.Render(r.Context(), w, problem.MakeInvalidFieldProblem("hash", errors.New("must be a hex encoded transaction hash")))
+		return
+	}
+
+	// Hashes are stored in lowercase.
+	record, err := handler.History.FundingByHash(r.Context(), strings.ToLower(hash))
+	if err != nil {
+		problem.Render(r.Context(), w, err)
+		return
+	}
+	hal.Render(w, record)
+}
+
+func parseHistoryPageQuery(r *http.Request) (HistoryPageQuery, error) {
+	params := r.URL.Query()
+	query := HistoryPageQuery{Order: "asc", Limit: defaultHistoryPageLimit}
+
+	if cursor := params.Get("cursor"); cursor != "" {
+		c, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || c < 0 {
+			return query, problem.MakeInvalidFieldProblem("cursor", errors.New("must be a funding id"))
+		}
+		query.Cursor = c
+	}
+
+	switch order := params.Get("order"); order {
+	case "":
+	case "asc", "desc":
+		query.Order = order
+	default:
+		return query, problem.MakeInvalidFieldProblem("order", errors.New(`must be "asc" or "desc"`))
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 1 || l > maxHistoryPageLimit {
+			return query, problem.MakeInvalidFieldProblem("limit", errors.New("must be between 1 and 200"))
+		}
+		query.Limit = l
+	}
+
+	return query, nil
+}
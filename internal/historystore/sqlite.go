@@ -0,0 +1,232 @@
+package historystore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stellar/friendbot/internal"
+	"github.com/stellar/go-stellar-sdk/support/errors"
+
+	// Registers the pure Go "sqlite" database/sql driver.
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS fundings (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	created_at   INTEGER NOT NULL,
+	address      TEXT    NOT NULL,
+	address_type TEXT    NOT NULL,
+	amount       TEXT    NOT NULL,
+	minion       TEXT    NOT NULL,
+	hash         TEXT    NOT NULL,
+	outcome      TEXT    NOT NULL,
+	problem      TEXT    NOT NULL,
+	client_ip    TEXT    NOT NULL,
+	latency_ms   INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS fundings_by_address ON fundings (address, id);
+CREATE INDEX IF NOT EXISTS fundings_by_hash ON fundings (hash);
+CREATE INDEX IF NOT EXISTS fundings_by_created_at ON fundings (created_at);
+CREATE TABLE IF NOT EXISTS refills (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	created_at INTEGER NOT NULL,
+	amount     INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS refills_by_created_at ON refills (created_at);
+`
+
+const fundingColumns = "id, created_at, address, address_type, amount, minion, hash, outcome, problem, client_ip, latency_ms"
+
+// SQLiteStore is an internal.HistoryStore backed by an embedded SQLite
+// database file.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// Ensure SQLiteStore implements the internal.HistoryStore interface.
+var _ internal.HistoryStore = (*SQLiteStore)(nil)
+
+// NewSQLiteStore opens, creating if necessary, the SQLite database at path.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)", path))
+	if err != nil {
+		return nil, errors.Wrap(err, "opening history database")
+	}
+	// SQLite allows a single writer, so serialize access through one
+	// connection rather than failing with SQLITE_BUSY under load.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "creating history schema")
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Close closes the underlying database.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// RecordFunding stores a funding attempt.
+func (s *SQLiteStore) RecordFunding(ctx context.Context, record internal.FundingRecord) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO fundings (created_at, address, address_type, amount, minion, hash, outcome, problem, client_ip, latency_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.CreatedAt.UnixMilli(), record.Address, record.AddressType, record.Amount, record.Minion,
+		record.Hash, record.Outcome, record.Problem, record.ClientIP, record.LatencyMs,
+	)
+	if err != nil {
+		return errors.Wrap(err, "inserting funding record")
+	}
+	return nil
+}
+
+// FundingsByAddress returns a page of funding attempts for an address.
+func (s *SQLiteStore) FundingsByAddress(ctx context.Context, address string, page internal.HistoryPageQuery) ([]internal.FundingRecord, error) {
+	query := "SELECT " + fundingColumns + " FROM fundings WHERE address = ?"
+	args := []interface{}{address}
+	if page.Order == "desc" {
+		if page.Cursor > 0 {
+			query += " AND id < ?"
+			args = append(args, page.Cursor)
+		}
+		query += " ORDER BY id DESC"
+	} else {
+		query += " AND id > ? ORDER BY id ASC"
+		args = append(args, page.Cursor)
+	}
+	query += " LIMIT ?"
+	args = append(args, page.Limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying funding records")
+	}
+	defer rows.Close()
+
+	records := []internal.FundingRecord{}
+	for rows.Next() {
+		record, err := scanFunding(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading funding records")
+	}
+	return records, nil
+}
+
+// FundingByHash returns the funding attempt that submitted the transaction
+// with the given hash, or sql.ErrNoRows if there is none.
+func (s *SQLiteStore) FundingByHash(ctx context.Context, hash string) (internal.FundingRecord, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+fundingColumns+" FROM fundings WHERE hash = ? ORDER BY id LIMIT 1", hash)
+	return scanFunding(row)
+}
+
+// FundingsSince returns the funding attempts made at or after t, oldest
+// first.
+func (s *SQLiteStore) FundingsSince(ctx context.Context, t time.Time) ([]internal.FundingRecord, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+fundingColumns+" FROM fundings WHERE created_at >= ? ORDER BY id", t.UnixMilli())
+	if err != nil {
+		return nil, errors.Wrap(err, "querying funding records")
+	}
+	defer rows.Close()
+	var records []internal.FundingRecord
+	for rows.Next() {
+		record, err := scanFunding(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading funding records")
+	}
+	return records, nil
+}
+
+// DeleteFundingsBefore removes the funding attempts made before t, and
+// returns how many were removed.
+func (s *SQLiteStore) DeleteFundingsBefore(ctx context.Context, t time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM fundings WHERE created_at < ?", t.UnixMilli())
+	if err != nil {
+		return 0, errors.Wrap(err, "deleting funding records")
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "deleting funding records")
+	}
+	return deleted, nil
+}
+
+// RecordRefill stores a treasury refill.
+func (s *SQLiteStore) RecordRefill(ctx context.Context, record internal.RefillRecord) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO refills (created_at, amount) VALUES (?, ?)",
+		record.CreatedAt.UnixMilli(), record.Amount)
+	if err != nil {
+		return errors.Wrap(err, "inserting refill record")
+	}
+	return nil
+}
+
+// RefillsSince returns the treasury refills made at or after t, oldest first.
+func (s *SQLiteStore) RefillsSince(ctx context.Context, t time.Time) ([]internal.RefillRecord, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT created_at, amount FROM refills WHERE created_at >= ? ORDER BY id", t.UnixMilli())
+	if err != nil {
+		return nil, errors.Wrap(err, "querying refill records")
+	}
+	defer rows.Close()
+
+	var records []internal.RefillRecord
+	for rows.Next() {
+		var (
+			record    internal.RefillRecord
+			createdAt int64
+		)
+		if err := rows.Scan(&createdAt, &record.Amount); err != nil {
+			return nil, errors.Wrap(err, "scanning refill record")
+		}
+		record.CreatedAt = time.UnixMilli(createdAt).UTC()
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading refill records")
+	}
+	return records, nil
+}
+
+// DeleteRefillsBefore removes the treasury refills made before t.
+func (s *SQLiteStore) DeleteRefillsBefore(ctx context.Context, t time.Time) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM refills WHERE created_at < ?", t.UnixMilli()); err != nil {
+		return errors.Wrap(err, "deleting refill records")
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanFunding(row scanner) (internal.FundingRecord, error) {
+	var (
+		record    internal.FundingRecord
+		createdAt int64
+	)
+	err := row.Scan(
+		&record.ID, &createdAt, &record.Address, &record.AddressType, &record.Amount, &record.Minion,
+		&record.Hash, &record.Outcome, &record.Problem, &record.ClientIP, &record.LatencyMs,
+	)
+	if err == sql.ErrNoRows {
+		return internal.FundingRecord{}, err
+	}
+	if err != nil {
+		return internal.FundingRecord{}, errors.Wrap(err, "scanning funding record")
+	}
+	record.CreatedAt = time.UnixMilli(createdAt).UTC()
+	return record, nil
+}
@@ -0,0 +1,190 @@
+package historystore
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stellar/friendbot/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "history.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestSQLiteStore_RecordAndQueryByHash(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	record := internal.FundingRecord{
+		CreatedAt:   createdAt,
+		Address:     "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z",
+		AddressType: internal.AddressTypeAccount,
+		Amount:      "10000.00",
+		Minion:      "GD4AGPPDFFHKK3Z2X4XZDRXX6GZQKP4FMLVQ5T55NDEYGG3GIP7BQUHM",
+		Hash:        "a6f2f2459152559f4a5b3cd3c8652ed3491dee7d4c7729659362408db25f731b",
+		Outcome:     internal.FundingOutcomeSuccess,
+		ClientIP:    "203.0.113.7",
+		LatencyMs:   1234,
+	}
+	require.NoError(t, store.RecordFunding(ctx, record))
+
+	got, err := store.FundingByHash(ctx, record.Hash)
+	require.NoError(t, err)
+	record.ID = got.ID
+	assert.Equal(t, record, got)
+
+	_, err = store.FundingByHash(ctx, "0000000000000000000000000000000000000000000000000000000000000000")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestSQLiteStore_FundingsByAddressPagination(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	address := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+	for i := 0; i < 5; i++ {
+		require.NoError(t, store.RecordFunding(ctx, internal.FundingRecord{
+			CreatedAt:   time.Now(),
+			Address:     address,
+			AddressType: internal.AddressTypeAccount,
+			Outcome:     internal.FundingOutcomeFailure,
+			Problem:     "bad_request",
+		}))
+	}
+	require.NoError(t, store.RecordFunding(ctx, internal.FundingRecord{
+		CreatedAt:   time.Now(),
+		Address:     "GD25B4QI6KWVDWXDW25CIM7EKR6A6PBSWE2RCNSAC4NJQDQJXZJYMMKR",
+		AddressType: internal.AddressTypeAccount,
+		Outcome:     internal.FundingOutcomeSuccess,
+	}))
+
+	firstPage, err := store.FundingsByAddress(ctx, address, internal.HistoryPageQuery{Order: "asc", Limit: 3})
+	require.NoError(t, err)
+	require.Len(t, firstPage, 3)
+	assert.Less(t, firstPage[0].ID, firstPage[1].ID)
+
+	secondPage, err := store.FundingsByAddress(ctx, address, internal.HistoryPageQuery{Cursor: firstPage[2].ID, Order: "asc", Limit: 3})
+	require.NoError(t, err)
+	require.Len(t, secondPage, 2)
+	assert.Greater(t, secondPage[0].ID, firstPage[2].ID)
+
+	descending, err := store.FundingsByAddress(ctx, address, internal.HistoryPageQuery{Order: "desc", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, descending, 5)
+	assert.Equal(t, secondPage[1].ID, descending[0].ID)
+	for _, record := range descending {
+		assert.Equal(t, address, record.Address)
+	}
+}
+
+func TestSQLiteStore_DeleteFundingsBefore(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	address := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+	now := time.Now()
+	for _, createdAt := range []time.Time{now.Add(-48 * time.Hour), now.Add(-25 * time.Hour), now} {
+		require.NoError(t, store.RecordFunding(ctx, internal.FundingRecord{
+			CreatedAt:   createdAt,
+			Address:     address,
+			AddressType: internal.AddressTypeAccount,
+			Outcome:     internal.FundingOutcomeSuccess,
+		}))
+	}
+
+	deleted, err := store.DeleteFundingsBefore(ctx, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	records, err := store.FundingsByAddress(ctx, address, internal.HistoryPageQuery{Order: "asc", Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+}
+
+func TestSQLiteStore_FundingsSince(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	address := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+	now := time.Now()
+	for _, createdAt := range []time.Time{now.Add(-25 * time.Hour), now.Add(-time.Hour), now} {
+		require.NoError(t, store.RecordFunding(ctx, internal.FundingRecord{
+			CreatedAt:   createdAt,
+			Address:     address,
+			AddressType: internal.AddressTypeAccount,
+			Outcome:     internal.FundingOutcomeSuccess,
+		}))
+	}
+
+	records, err := store.FundingsSince(ctx, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Less(t, records[0].ID, records[1].ID)
+	assert.Equal(t, now.Add(-time.Hour).UnixMilli(), records[0].CreatedAt.UnixMilli())
+}
+
+func TestSQLiteStore_Refills(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	now := time.Now()
+	for _, createdAt := range []time.Time{now.Add(-25 * time.Hour), now.Add(-time.Hour), now} {
+		require.NoError(t, store.RecordRefill(ctx, internal.RefillRecord{CreatedAt: createdAt, Amount: 100}))
+	}
+
+	refills, err := store.RefillsSince(ctx, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, refills, 2)
+	assert.Equal(t, now.Add(-time.Hour).UnixMilli(), refills[0].CreatedAt.UnixMilli())
+	assert.Equal(t, int64(100), refills[0].Amount)
+
+	require.NoError(t, store.DeleteRefillsBefore(ctx, now.Add(-30*time.Minute)))
+	refills, err = store.RefillsSince(ctx, time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, refills, 1)
+}
+
+func TestHistoryRecorder(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "history.db")
+	store, err := NewSQLiteStore(path)
+	require.NoError(t, err)
+
+	address := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+	require.NoError(t, store.RecordFunding(ctx, internal.FundingRecord{
+		CreatedAt:   time.Now().Add(-48 * time.Hour),
+		Address:     address,
+		AddressType: internal.AddressTypeAccount,
+		Outcome:     internal.FundingOutcomeSuccess,
+	}))
+
+	recorder := internal.NewHistoryRecorder(store, 10, 24*time.Hour)
+	require.NoError(t, recorder.RecordFunding(ctx, internal.FundingRecord{
+		CreatedAt:   time.Now(),
+		Address:     address,
+		AddressType: internal.AddressTypeAccount,
+		Outcome:     internal.FundingOutcomeFailure,
+		Problem:     "funding_paused",
+	}))
+
+	// The attempts waiting to be recorded are recorded on close, and the
+	// attempts older than the retention are removed.
+	require.NoError(t, recorder.Close())
+	store, err = NewSQLiteStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+	records, err := store.FundingsByAddress(ctx, address, internal.HistoryPageQuery{Order: "asc", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "funding_paused", records[0].Problem)
+}
@@ -88,16 +88,21 @@ func (h *NetworkClient) URL() string {
 }
 
 // SubmitTransaction submits a transaction using the underlying horizon client.
-func (h *NetworkClient) SubmitTransaction(ctx context.Context, txXDR string) error {
+func (h *NetworkClient) SubmitTransaction(ctx context.Context, txXDR string) (*internal.SubmitTransactionResult, error) {
 	_ = ctx // Horizon client doesn't support context propagation
-	_, err := h.client.SubmitTransactionXDR(txXDR)
+	tx, err := h.client.SubmitTransactionXDR(txXDR)
 	if err != nil {
 		if hErr, ok := err.(*horizonclient.Error); ok {
-			return NewNetworkError(hErr)
+			return nil, NewNetworkError(hErr)
 		}
-		return err
+		return nil, err
 	}
-	return nil
+	return &internal.SubmitTransactionResult{
+		Ledger:          uint32(tx.Ledger),
+		LedgerCloseTime: tx.LedgerCloseTime,
+		ResultXDR:       tx.ResultXdr,
+		FeeCharged:      tx.FeeCharged,
+	}, nil
 }
 
 // GetAccountDetails retrieves account details using the underlying horizon client.
@@ -126,9 +131,16 @@ func (h *NetworkClient) GetAccountDetails(ctx context.Context, address string) (
 		}
 	}
 
+	signers := make(map[string]int32, len(account.Signers))
+	for _, signer := range account.Signers {
+		signers[signer.Key] = signer.Weight
+	}
+
 	return &internal.AccountDetails{
-		Sequence: account.Sequence,
-		Balance:  nativeBalance,
+		Sequence:        account.Sequence,
+		Balance:         nativeBalance,
+		Signers:         signers,
+		MediumThreshold: int32(account.Thresholds.MedThreshold),
 	}, nil
 }
 
@@ -143,3 +155,20 @@ func (h *NetworkClient) SimulateTransaction(ctx context.Context, txXDR string) (
 func (h *NetworkClient) SupportsContractAddresses() bool {
 	return false
 }
+
+// NetworkInfo returns the network passphrase and current protocol version
+// reported by the Horizon root endpoint.
+func (h *NetworkClient) NetworkInfo(ctx context.Context) (*internal.NetworkInfo, error) {
+	_ = ctx // Horizon client doesn't support context propagation
+	root, err := h.client.Root()
+	if err != nil {
+		if hErr, ok := err.(*horizonclient.Error); ok {
+			return nil, NewNetworkError(hErr)
+		}
+		return nil, err
+	}
+	return &internal.NetworkInfo{
+		Passphrase:      root.NetworkPassphrase,
+		ProtocolVersion: uint32(root.CurrentProtocolVersion),
+	}, nil
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"net/http"
 	"testing"
+	"time"
 
+	"github.com/stellar/friendbot/internal"
 	"github.com/stellar/go-stellar-sdk/clients/horizonclient"
 	"github.com/stellar/go-stellar-sdk/protocols/horizon"
 	"github.com/stellar/go-stellar-sdk/support/render/problem"
@@ -133,12 +135,24 @@ func TestNetworkError_Unwrap(t *testing.T) {
 func TestNetworkClient_SubmitTransaction_Success(t *testing.T) {
 	mockClient := &horizonclient.MockClient{}
 
-	mockClient.On("SubmitTransactionXDR", "test-xdr").Return(horizon.Transaction{}, nil)
+	closeTime := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	mockClient.On("SubmitTransactionXDR", "test-xdr").Return(horizon.Transaction{
+		Ledger:          42,
+		LedgerCloseTime: closeTime,
+		ResultXdr:       "AAAAAAAAAGQAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAA=",
+		FeeCharged:      100,
+	}, nil)
 
 	client := NewNetworkClient(mockClient)
-	err := client.SubmitTransaction(context.Background(), "test-xdr")
+	result, err := client.SubmitTransaction(context.Background(), "test-xdr")
 
 	assert.NoError(t, err)
+	assert.Equal(t, &internal.SubmitTransactionResult{
+		Ledger:          42,
+		LedgerCloseTime: closeTime,
+		ResultXDR:       "AAAAAAAAAGQAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAA=",
+		FeeCharged:      100,
+	}, result)
 	mockClient.AssertExpectations(t)
 }
 
@@ -155,7 +169,7 @@ func TestNetworkClient_SubmitTransaction_HorizonError(t *testing.T) {
 	mockClient.On("SubmitTransactionXDR", "test-xdr").Return(horizon.Transaction{}, horizonErr)
 
 	client := NewNetworkClient(mockClient)
-	err := client.SubmitTransaction(context.Background(), "test-xdr")
+	_, err := client.SubmitTransaction(context.Background(), "test-xdr")
 
 	assert.Error(t, err)
 
@@ -173,7 +187,7 @@ func TestNetworkClient_SubmitTransaction_GenericError(t *testing.T) {
 	mockClient.On("SubmitTransactionXDR", "test-xdr").Return(horizon.Transaction{}, genericErr)
 
 	client := NewNetworkClient(mockClient)
-	err := client.SubmitTransaction(context.Background(), "test-xdr")
+	_, err := client.SubmitTransaction(context.Background(), "test-xdr")
 
 	assert.Error(t, err)
 	assert.Equal(t, genericErr, err)
@@ -195,9 +209,14 @@ func TestNetworkClient_GetAccountDetails_Success(t *testing.T) {
 	balances[1].Type = "credit_alphanum4"
 
 	account := horizon.Account{
-		AccountID: accountID,
-		Sequence:  expectedSequence,
-		Balances:  balances,
+		AccountID:  accountID,
+		Sequence:   expectedSequence,
+		Balances:   balances,
+		Thresholds: horizon.AccountThresholds{MedThreshold: 2},
+		Signers: []horizon.Signer{
+			{Key: accountID, Weight: 1, Type: "ed25519_public_key"},
+			{Key: "GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H", Weight: 1, Type: "ed25519_public_key"},
+		},
 	}
 
 	mockClient.On("AccountDetail", horizonclient.AccountRequest{AccountID: accountID}).Return(account, nil)
@@ -209,6 +228,11 @@ func TestNetworkClient_GetAccountDetails_Success(t *testing.T) {
 	assert.NotNil(t, result)
 	assert.Equal(t, expectedSequence, result.Sequence)
 	assert.Equal(t, "100.0000000", result.Balance)
+	assert.Equal(t, map[string]int32{
+		accountID: 1,
+		"GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H": 1,
+	}, result.Signers)
+	assert.Equal(t, int32(2), result.MediumThreshold)
 
 	mockClient.AssertExpectations(t)
 }
@@ -284,3 +308,19 @@ func TestNetworkClient_GetAccountDetails_GenericError(t *testing.T) {
 
 	mockClient.AssertExpectations(t)
 }
+
+func TestNetworkClient_NetworkInfo(t *testing.T) {
+	mockClient := &horizonclient.MockClient{}
+	mockClient.On("Root").Return(horizon.Root{
+		NetworkPassphrase:      "Test SDF Network ; September 2015",
+		CurrentProtocolVersion: 22,
+	}, nil)
+
+	client := NewNetworkClient(mockClient)
+	info, err := client.NetworkInfo(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, &internal.NetworkInfo{Passphrase: "Test SDF Network ; September 2015", ProtocolVersion: 22}, info)
+
+	mockClient.AssertExpectations(t)
+}
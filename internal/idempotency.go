@@ -0,0 +1,326 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/stellar/go-stellar-sdk/support/errors"
+)
+
+// IdempotencyKeyHeader is the request header clients use to make retries of a
+// funding request safe.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength is the maximum accepted length of an idempotency key.
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyRecord is the response stored for a request made with an
+// idempotency key, along with the address the request was made for and the
+// version of the response it asked for.
+type IdempotencyRecord struct {
+	Address     string    `json:"addr"`
+	Version     int       `json:"version"`
+	StatusCode  int       `json:"status"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"body"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// IdempotencyStore stores responses to requests made with an idempotency key.
+type IdempotencyStore interface {
+	// Get returns the unexpired record stored for key, or nil if there is none.
+	Get(ctx context.Context, key string) (*IdempotencyRecord, error)
+	// Put stores the record for key. The store sets the record's expiry.
+	Put(ctx context.Context, key string, record IdempotencyRecord) error
+}
+
+// idempotencyRecords holds unexpired records up to a maximum number, in the
+// order they were stored. It is not safe for concurrent use.
+type idempotencyRecords struct {
+	maxRecords int
+	records    map[string]IdempotencyRecord
+	order      []string
+}
+
+func newIdempotencyRecords(maxRecords int) *idempotencyRecords {
+	return &idempotencyRecords{maxRecords: maxRecords, records: map[string]IdempotencyRecord{}}
+}
+
+// get returns the record stored for key, if it has not expired by now.
+func (r *idempotencyRecords) get(key string, now time.Time) (IdempotencyRecord, bool) {
+	r.expire(now)
+	record, ok := r.records[key]
+	return record, ok
+}
+
+// add stores record, whose expiry must be set, for key. The oldest records
+// are evicted once there are more than maxRecords.
+func (r *idempotencyRecords) add(key string, record IdempotencyRecord, now time.Time) {
+	r.expire(now)
+	if !now.Before(record.ExpiresAt) {
+		return
+	}
+	if _, ok := r.records[key]; !ok {
+		r.order = append(r.order, key)
+	}
+	r.records[key] = record
+	for r.maxRecords > 0 && len(r.order) > r.maxRecords {
+		delete(r.records, r.order[0])
+		r.order = r.order[1:]
+	}
+}
+
+// expire removes records whose ttl has passed, in the order they were stored.
+func (r *idempotencyRecords) expire(now time.Time) {
+	i := 0
+	for ; i < len(r.order); i++ {
+		if now.Before(r.records[r.order[i]].ExpiresAt) {
+			break
+		}
+		delete(r.records, r.order[i])
+	}
+	r.order = r.order[i:]
+}
+
+// MemoryIdempotencyStore is an IdempotencyStore that keeps records in memory.
+// Records are lost when friendbot restarts.
+type MemoryIdempotencyStore struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	records *idempotencyRecords
+}
+
+// Ensure MemoryIdempotencyStore implements the IdempotencyStore interface.
+var _ IdempotencyStore = (*MemoryIdempotencyStore)(nil)
+
+// NewMemoryIdempotencyStore returns an in-memory store keeping records for
+// ttl. Once it holds maxRecords, the oldest records are evicted to make room
+// for new ones. A maxRecords of 0 means no limit.
+func NewMemoryIdempotencyStore(ttl time.Duration, maxRecords int) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		ttl:     ttl,
+		records: newIdempotencyRecords(maxRecords),
+	}
+}
+
+// Get returns the unexpired record stored for key, or nil if there is none.
+func (s *MemoryIdempotencyStore) Get(ctx context.Context, key string) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records.get(key, time.Now())
+	if !ok {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// Put stores the record for key.
+func (s *MemoryIdempotencyStore) Put(ctx context.Context, key string, record IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	record.ExpiresAt = now.Add(s.ttl)
+	s.records.add(key, record, now)
+	return nil
+}
+
+// minCompactEntries is the number of entries the log of a
+// FileIdempotencyStore may hold before it is compacted.
+const minCompactEntries = 1000
+
+// idempotencyLogEntry is a line of the log of a FileIdempotencyStore.
+type idempotencyLogEntry struct {
+	Key    string            `json:"key"`
+	Record IdempotencyRecord `json:"record"`
+}
+
+// FileIdempotencyStore is an IdempotencyStore that persists records so that
+// they survive restarts. Each Put appends the record to a log file of JSON
+// lines, which is rewritten with only the unexpired records once most of its
+// entries are stale. It is intended for a single friendbot instance; the file
+// must not be shared between instances.
+type FileIdempotencyStore struct {
+	path string
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	records *idempotencyRecords
+	log     *os.File
+	// entries is the number of entries in the log.
+	entries int
+}
+
+// Ensure FileIdempotencyStore implements the IdempotencyStore interface.
+var _ IdempotencyStore = (*FileIdempotencyStore)(nil)
+
+// NewFileIdempotencyStore returns a store persisting records to the file at
+// path, keeping them for ttl and evicting the oldest beyond maxRecords, as
+// NewMemoryIdempotencyStore does. The file is read on first use.
+func NewFileIdempotencyStore(path string, ttl time.Duration, maxRecords int) *FileIdempotencyStore {
+	return &FileIdempotencyStore{path: path, ttl: ttl, records: newIdempotencyRecords(maxRecords)}
+}
+
+// Get returns the unexpired record stored for key, or nil if there is none.
+func (s *FileIdempotencyStore) Get(ctx context.Context, key string) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	record, ok := s.records.get(key, time.Now())
+	if !ok {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// Put stores the record for key and appends it to the log.
+func (s *FileIdempotencyStore) Put(ctx context.Context, key string, record IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.open(); err != nil {
+		return err
+	}
+	now := time.Now()
+	record.ExpiresAt = now.Add(s.ttl)
+	s.records.add(key, record, now)
+
+	if s.entries >= minCompactEntries && s.entries >= 2*len(s.records.order) {
+		return s.compact()
+	}
+	line, err := json.Marshal(idempotencyLogEntry{Key: key, Record: record})
+	if err != nil {
+		return errors.Wrap(err, "encoding idempotency record")
+	}
+	if _, err := s.log.Write(append(line, '\n')); err != nil {
+		return errors.Wrap(err, "writing idempotency store")
+	}
+	s.entries++
+	return nil
+}
+
+// Close closes the log file.
+func (s *FileIdempotencyStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.log == nil {
+		return nil
+	}
+	err := s.log.Close()
+	s.log = nil
+	return err
+}
+
+// open reads the records from the log, if it exists, and opens it for
+// appending, unless that was already done. The caller must hold s.mu.
+func (s *FileIdempotencyStore) open() error {
+	if s.log != nil {
+		return nil
+	}
+	s.records = newIdempotencyRecords(s.records.maxRecords)
+	s.entries = 0
+	f, err := os.Open(s.path)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "reading idempotency store")
+	}
+	if err == nil {
+		defer f.Close()
+		now := time.Now()
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			var entry idempotencyLogEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				return errors.Wrap(err, "parsing idempotency store")
+			}
+			s.records.add(entry.Key, entry.Record, now)
+			s.entries++
+		}
+		if err := scanner.Err(); err != nil {
+			return errors.Wrap(err, "reading idempotency store")
+		}
+	}
+	s.log, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.Wrap(err, "opening idempotency store")
+	}
+	return nil
+}
+
+// compact atomically replaces the log with the unexpired records, and reopens
+// it for appending. The caller must hold s.mu.
+func (s *FileIdempotencyStore) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "creating idempotency store")
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, key := range s.records.order {
+		if err := enc.Encode(idempotencyLogEntry{Key: key, Record: s.records.records[key]}); err != nil {
+			tmp.Close()
+			return errors.Wrap(err, "writing idempotency store")
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "writing idempotency store")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "writing idempotency store")
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return errors.Wrap(err, "replacing idempotency store")
+	}
+	s.log.Close()
+	s.entries = len(s.records.order)
+	s.log, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return errors.Wrap(err, "opening idempotency store")
+	}
+	return nil
+}
+
+// keyLocks serializes requests that share an idempotency key, so that a retry
+// arriving while the original request is still in progress waits for and then
+// replays its response.
+type keyLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func (l *keyLocks) lock(key string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = map[string]*keyLock{}
+	}
+	kl, ok := l.locks[key]
+	if !ok {
+		kl = &keyLock{}
+		l.locks[key] = kl
+	}
+	kl.refs++
+	l.mu.Unlock()
+
+	kl.mu.Lock()
+	return func() {
+		kl.mu.Unlock()
+		l.mu.Lock()
+		kl.refs--
+		if kl.refs == 0 {
+			delete(l.locks, key)
+		}
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,159 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryIdempotencyStore(time.Minute, 0)
+
+	record, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	err = store.Put(ctx, "key", IdempotencyRecord{Address: "addr", StatusCode: 200, Body: []byte("{}")})
+	require.NoError(t, err)
+
+	record, err = store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, "addr", record.Address)
+	assert.Equal(t, 200, record.StatusCode)
+	assert.Equal(t, []byte("{}"), record.Body)
+}
+
+func TestMemoryIdempotencyStore_Expiry(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryIdempotencyStore(time.Millisecond, 0)
+
+	err := store.Put(ctx, "key", IdempotencyRecord{Address: "addr", StatusCode: 200})
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	record, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Nil(t, record)
+}
+
+func TestMemoryIdempotencyStore_EvictsOldest(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryIdempotencyStore(time.Minute, 2)
+
+	for _, key := range []string{"a", "b", "c"} {
+		require.NoError(t, store.Put(ctx, key, IdempotencyRecord{Address: key, StatusCode: 200}))
+	}
+
+	record, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Nil(t, record)
+	for _, key := range []string{"b", "c"} {
+		record, err := store.Get(ctx, key)
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.Equal(t, key, record.Address)
+	}
+}
+
+func TestFileIdempotencyStore_PersistsAcrossInstances(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "idempotency.json")
+
+	store := NewFileIdempotencyStore(path, time.Minute, 0)
+	record, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	err = store.Put(ctx, "key", IdempotencyRecord{Address: "addr", StatusCode: 400, ContentType: "application/problem+json", Body: []byte("{}")})
+	require.NoError(t, err)
+
+	reopened := NewFileIdempotencyStore(path, time.Minute, 0)
+	record, err = reopened.Get(ctx, "key")
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, "addr", record.Address)
+	assert.Equal(t, 400, record.StatusCode)
+	assert.Equal(t, "application/problem+json", record.ContentType)
+	assert.Equal(t, []byte("{}"), record.Body)
+}
+
+func TestFileIdempotencyStore_Expiry(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "idempotency.json")
+
+	store := NewFileIdempotencyStore(path, time.Millisecond, 0)
+	err := store.Put(ctx, "key", IdempotencyRecord{Address: "addr", StatusCode: 200})
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	record, err := NewFileIdempotencyStore(path, time.Millisecond, 0).Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Nil(t, record)
+}
+
+func TestFileIdempotencyStore_CompactsLog(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "idempotency.json")
+
+	store := NewFileIdempotencyStore(path, time.Minute, 10)
+	for i := 0; i < 2*minCompactEntries; i++ {
+		err := store.Put(ctx, fmt.Sprintf("key-%d", i), IdempotencyRecord{Address: "addr", StatusCode: 200})
+		require.NoError(t, err)
+	}
+	require.NoError(t, store.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Less(t, bytes.Count(data, []byte("\n")), minCompactEntries+10)
+
+	reopened := NewFileIdempotencyStore(path, time.Minute, 10)
+	record, err := reopened.Get(ctx, fmt.Sprintf("key-%d", 2*minCompactEntries-1))
+	require.NoError(t, err)
+	assert.NotNil(t, record)
+	record, err = reopened.Get(ctx, "key-0")
+	require.NoError(t, err)
+	assert.Nil(t, record)
+}
+
+func TestKeyLocks_SerializesSameKey(t *testing.T) {
+	var locks keyLocks
+	var (
+		mu      sync.Mutex
+		active  int
+		maxSeen int
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := locks.lock("key")
+			defer unlock()
+			mu.Lock()
+			active++
+			if active > maxSeen {
+				maxSeen = active
+			}
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			active--
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, maxSeen)
+	assert.Empty(t, locks.locks)
+}
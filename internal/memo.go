@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"encoding/hex"
+	"strconv"
+
+	"github.com/stellar/go-stellar-sdk/support/errors"
+	"github.com/stellar/go-stellar-sdk/txnbuild"
+)
+
+// Types of memo a funding transaction can carry.
+const (
+	MemoTypeText = "text"
+	MemoTypeID   = "id"
+	MemoTypeHash = "hash"
+)
+
+// maxMemoTextLength is the longest text memo, in bytes.
+const maxMemoTextLength = 28
+
+// errInvalidMemoType is returned by ParseMemo for unknown memo types.
+var errInvalidMemoType = errors.New("must be text, id or hash")
+
+// Memo is the memo of a funding transaction. The zero Memo is no memo.
+type Memo struct {
+	// Type is MemoTypeText, MemoTypeID or MemoTypeHash.
+	Type string `json:"type"`
+	// Value is the text of a text memo, the decimal number of an id memo, or
+	// the 32 bytes of a hash memo in hex.
+	Value string `json:"value"`
+}
+
+// ParseMemo returns the memo of memoType with value, once it is checked
+// against the limits of memos. memoType defaults to MemoTypeText, and an
+// empty value is no memo.
+func ParseMemo(memoType, value string) (Memo, error) {
+	if memoType == "" {
+		memoType = MemoTypeText
+	}
+	if memoType != MemoTypeText && memoType != MemoTypeID && memoType != MemoTypeHash {
+		return Memo{}, errInvalidMemoType
+	}
+	if value == "" {
+		return Memo{}, nil
+	}
+	switch memoType {
+	case MemoTypeText:
+		if len(value) > maxMemoTextLength {
+			return Memo{}, errors.Errorf("text memos must be at most %d bytes", maxMemoTextLength)
+		}
+	case MemoTypeID:
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return Memo{}, errors.New("id memos must be an unsigned 64-bit integer")
+		}
+		value = strconv.FormatUint(id, 10)
+	case MemoTypeHash:
+		hash, err := hex.DecodeString(value)
+		if err != nil || len(hash) != 32 {
+			return Memo{}, errors.New("hash memos must be 32 bytes in hex")
+		}
+		value = hex.EncodeToString(hash)
+	}
+	return Memo{Type: memoType, Value: value}, nil
+}
+
+// IsZero returns true if m is no memo.
+func (m Memo) IsZero() bool {
+	return m == Memo{}
+}
+
+// txnbuildMemo returns m as the memo of a transaction, or nil if m is no memo.
+// m must have been returned by ParseMemo.
+func (m Memo) txnbuildMemo() txnbuild.Memo {
+	switch m.Type {
+	case MemoTypeText:
+		return txnbuild.MemoText(m.Value)
+	case MemoTypeID:
+		id, _ := strconv.ParseUint(m.Value, 10, 64)
+		return txnbuild.MemoID(id)
+	case MemoTypeHash:
+		var hash txnbuild.MemoHash
+		hex.Decode(hash[:], []byte(m.Value))
+		return hash
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMemo(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		memoType string
+		value    string
+		memo     Memo
+		err      string
+	}{
+		{name: "no memo", memo: Memo{}},
+		{name: "no memo of a type", memoType: MemoTypeID, memo: Memo{}},
+		{name: "text by default", value: "testnet", memo: Memo{Type: MemoTypeText, Value: "testnet"}},
+		{name: "longest text", memoType: MemoTypeText, value: strings.Repeat("a", 28), memo: Memo{Type: MemoTypeText, Value: strings.Repeat("a", 28)}},
+		{name: "text too long", memoType: MemoTypeText, value: strings.Repeat("a", 29), err: "text memos must be at most 28 bytes"},
+		{name: "id", memoType: MemoTypeID, value: "18446744073709551615", memo: Memo{Type: MemoTypeID, Value: "18446744073709551615"}},
+		{name: "id normalized", memoType: MemoTypeID, value: "007", memo: Memo{Type: MemoTypeID, Value: "7"}},
+		{name: "id too large", memoType: MemoTypeID, value: "18446744073709551616", err: "id memos must be an unsigned 64-bit integer"},
+		{name: "id not a number", memoType: MemoTypeID, value: "seven", err: "id memos must be an unsigned 64-bit integer"},
+		{name: "hash", memoType: MemoTypeHash, value: strings.Repeat("AB", 32), memo: Memo{Type: MemoTypeHash, Value: strings.Repeat("ab", 32)}},
+		{name: "hash too short", memoType: MemoTypeHash, value: strings.Repeat("ab", 31), err: "hash memos must be 32 bytes in hex"},
+		{name: "hash not hex", memoType: MemoTypeHash, value: strings.Repeat("zz", 32), err: "hash memos must be 32 bytes in hex"},
+		{name: "unknown type", memoType: "return", value: "ab", err: "must be text, id or hash"},
+		{name: "unknown type without memo", memoType: "return", err: "must be text, id or hash"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			memo, err := ParseMemo(tc.memoType, tc.value)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.memo, memo)
+			assert.Equal(t, tc.value == "", memo.IsZero())
+		})
+	}
+}
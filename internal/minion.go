@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/stellar/go-stellar-sdk/amount"
 	"github.com/stellar/go-stellar-sdk/keypair"
@@ -23,14 +24,20 @@ var ErrAccountExists error = errors.New(fmt.Sprintf("createAccountAlreadyExist (
 
 var ErrAccountFunded error = errors.New("account already funded to starting balance")
 
+// ErrInsufficientMinionBalance is returned when a funding transaction fails
+// because the minion cannot pay its fee, or the bot account cannot pay the
+// starting balance.
+var ErrInsufficientMinionBalance = errors.New("insufficient balance to fund the address")
+
 var botTracer = otel.Tracer("stellar_friendbot_minion")
 
 // Minion contains a Stellar channel account and Go channels to communicate with friendbot.
 type Minion struct {
-	Account         Account
-	Keypair         *keypair.Full
-	BotAccount      txnbuild.Account
-	BotKeypair      *keypair.Full
+	Account    Account
+	Keypair    *keypair.Full
+	BotAccount txnbuild.Account
+	// BotSigner signs the operations sourced from the bot account.
+	BotSigner       Signer
 	NetworkClient   NetworkClient
 	Network         string
 	StartingBalance string
@@ -45,17 +52,24 @@ type Minion struct {
 	forceRefreshSequence bool
 }
 
-// Run reads a payment destination address and an output channel. It attempts
-// to pay that address and submits the result to the channel.
-func (minion *Minion) Run(ctx context.Context, destAddress string, resultChan chan SubmitResult) {
+// Run reads a payment destination address, the memo of the payment and an
+// output channel. It attempts to pay that address and submits the result to
+// the channel.
+func (minion *Minion) Run(ctx context.Context, destAddress string, memo Memo, resultChan chan SubmitResult) {
 	ctx, span := botTracer.Start(ctx, "minion.run.pay_minion")
 	defer span.End()
 	span.SetAttributes(attribute.String("minion.account_id", minion.Account.AccountID))
+	if !memo.IsZero() {
+		span.SetAttributes(
+			attribute.String("funding.memo_type", memo.Type),
+			attribute.String("funding.memo", memo.Value),
+		)
+	}
 	err := minion.CheckSequenceRefresh(ctx, minion, minion.NetworkClient)
 	if err != nil {
 		resultChan <- SubmitResult{
 			maybeTransactionSuccess: nil,
-			maybeErr:                errors.Wrap(err, "checking minion seq"),
+			maybeErr:                errors.Wrap(upstreamError(err), "checking minion seq"),
 		}
 		return
 	}
@@ -67,7 +81,7 @@ func (minion *Minion) Run(ctx context.Context, destAddress string, resultChan ch
 	if err != nil {
 		resultChan <- SubmitResult{
 			maybeTransactionSuccess: nil,
-			maybeErr:                errors.Wrap(err, "checking destination"),
+			maybeErr:                errors.Wrap(upstreamError(err), "checking destination"),
 		}
 		return
 	}
@@ -87,26 +101,28 @@ func (minion *Minion) Run(ctx context.Context, destAddress string, resultChan ch
 		return
 	}
 
-	txHash, txStr, err := minion.makeTx(ctx, destAddress, exists)
-	if err != nil {
-		resultChan <- SubmitResult{
-			maybeTransactionSuccess: nil,
-			maybeErr:                errors.Wrap(err, "making payment tx"),
-		}
-		return
-	}
-	_, err = minion.Account.IncrementSequenceNumber()
+	txHash, txStr, err := minion.makeTx(ctx, destAddress, exists, memo)
 	if err != nil {
 		resultChan <- SubmitResult{
 			maybeTransactionSuccess: nil,
-			maybeErr:                errors.Wrap(err, "incrementing submitters sequence number"),
+			maybeErr:                errors.Wrap(upstreamError(err), "making payment tx"),
 		}
 		return
 	}
+	// The transaction uses the next sequence number, which the bot keeps for
+	// the minion's next payment if this one succeeds.
+	minion.Account.Sequence++
 	succ, err := minion.SubmitTransaction(ctx, minion, minion.NetworkClient, txHash, txStr)
+	if succ != nil {
+		succ.Destination = destAddress
+		succ.Amount = normalizeAmount(minion.StartingBalance)
+		succ.Created = !exists && !strkey.IsValidContractAddress(destAddress)
+		succ.Memo = memo
+	}
 	resultChan <- SubmitResult{
 		maybeTransactionSuccess: succ,
 		maybeErr:                errors.Wrapf(err, "submitting tx to minion %x", txHash),
+		outcomeUnknown:          errors.Cause(err) == ErrUpstreamTimeout,
 	}
 	if succ != nil {
 		span.SetAttributes(attribute.Bool("minion.tx_success_status", succ.Successful))
@@ -119,6 +135,19 @@ type TransactionResult struct {
 	Successful  bool   `json:"successful"`
 	Hash        string `json:"hash"`
 	EnvelopeXdr string `json:"envelope_xdr"`
+
+	// The fields below are only returned in the version 2 response, see
+	// FundingResponse.
+	Destination string `json:"-"`
+	Amount      string `json:"-"`
+	Created     bool   `json:"-"`
+	Memo        Memo   `json:"-"`
+	// Ledger is the sequence of the ledger that included the transaction,
+	// which callers can wait for before querying the funded account.
+	Ledger          uint32    `json:"-"`
+	LedgerCloseTime time.Time `json:"-"`
+	ResultXdr       string    `json:"-"`
+	FeeCharged      int64     `json:"-"`
 }
 
 // SubmitTransaction should be passed to the Minion.
@@ -126,7 +155,7 @@ func SubmitTransaction(ctx context.Context, minion *Minion, networkClient Networ
 	_, span := botTracer.Start(ctx, "minion.submit_transaction")
 	defer span.End()
 
-	err := networkClient.SubmitTransaction(ctx, tx)
+	submitted, err := networkClient.SubmitTransaction(ctx, tx)
 	if err != nil {
 		errStr := "submitting tx"
 		switch e := err.(type) {
@@ -151,19 +180,38 @@ func SubmitTransaction(ctx context.Context, minion *Minion, networkClient Networ
 			}
 			span.SetStatus(codes.Error, errStr)
 			span.AddEvent("transaction submission failed")
+			switch {
+			case e.IsTimeout():
+				return nil, errors.Wrap(ErrUpstreamTimeout, errStr)
+			case resErr == nil && isInsufficientBalance(resStr):
+				return nil, errors.Wrap(ErrInsufficientMinionBalance, errStr)
+			}
 			return nil, errors.New(errStr)
 		}
 		span.SetStatus(codes.Error, err.Error())
 		span.AddEvent("transaction submission failed")
-		return nil, errors.Wrap(err, errStr)
+		return nil, errors.Wrap(upstreamError(err), errStr)
+	}
+	if submitted == nil {
+		// Network clients that don't know the outcome of the transaction
+		// beyond its success return no details.
+		submitted = &SubmitTransactionResult{}
 	}
 	// Construct the final transaction result with hash and envelope XDR
 	result := &TransactionResult{
-		Successful:  true,
-		Hash:        hex.EncodeToString(txHash[:]),
-		EnvelopeXdr: tx,
+		Successful:      true,
+		Hash:            hex.EncodeToString(txHash[:]),
+		EnvelopeXdr:     tx,
+		Ledger:          submitted.Ledger,
+		LedgerCloseTime: submitted.LedgerCloseTime,
+		ResultXdr:       submitted.ResultXDR,
+		FeeCharged:      submitted.FeeCharged,
 	}
-	span.SetAttributes(attribute.String("minion.tx_hash", result.Hash))
+	span.SetAttributes(
+		attribute.String("minion.tx_hash", result.Hash),
+		attribute.Int64("tx.ledger", int64(result.Ledger)),
+		attribute.Int64("tx.fee_charged", result.FeeCharged),
+	)
 	span.AddEvent("transaction submission success")
 	span.SetStatus(codes.Ok, codes.Ok.String())
 	return result, nil
@@ -199,6 +247,32 @@ func CheckAccountExists(ctx context.Context, minion *Minion, networkClient Netwo
 	return false, "0", err
 }
 
+// isInsufficientBalance returns true if the transaction result shows that the
+// minion could not pay the fee, or the bot account the starting balance.
+func isInsufficientBalance(resultXDR string) bool {
+	var result xdr.TransactionResult
+	if err := xdr.SafeUnmarshalBase64(resultXDR, &result); err != nil {
+		return false
+	}
+	if result.Result.Code == xdr.TransactionResultCodeTxInsufficientBalance {
+		return true
+	}
+	opResults, _ := result.OperationResults()
+	for _, opResult := range opResults {
+		tr, ok := opResult.GetTr()
+		if !ok {
+			continue
+		}
+		if r, ok := tr.GetCreateAccountResult(); ok && r.Code == xdr.CreateAccountResultCodeCreateAccountUnderfunded {
+			return true
+		}
+		if r, ok := tr.GetPaymentResult(); ok && r.Code == xdr.PaymentResultCodePaymentUnderfunded {
+			return true
+		}
+	}
+	return false
+}
+
 func (minion *Minion) checkHandleBadSequence(err NetworkError) {
 	isTxBadSeqCode := err.IsBadSequence()
 	if !isTxBadSeqCode {
@@ -222,21 +296,28 @@ func (minion *Minion) checkBalance(balance string) error {
 	return nil
 }
 
-func (minion *Minion) makeTx(ctx context.Context, destAddress string, exists bool) ([32]byte, string, error) {
-	// Check if the destination is a contract address (C address)
+// sign signs tx with the minion's keypair, as the transaction source, and the
+// bot signer, for the operation sourced from the bot account.
+func (minion *Minion) sign(ctx context.Context, tx *txnbuild.Transaction) (*txnbuild.Transaction, error) {
+	return signTransaction(ctx, tx, minion.Network, minion.BotSigner, minion.Keypair)
+}
+
+func (minion *Minion) makeTx(ctx context.Context, destAddress string, exists bool, memo Memo) ([32]byte, string, error) {
+	// Check if the destination is a contract address (C address). Contract
+	// payments invoke a host function, so they never carry a memo.
 	if strkey.IsValidContractAddress(destAddress) {
 		return minion.makeContractPaymentTx(ctx, destAddress)
 	}
 
 	// For regular accounts (G addresses), use the existing logic
 	if exists {
-		return minion.makePaymentTx(destAddress)
+		return minion.makePaymentTx(ctx, destAddress, memo)
 	} else {
-		return minion.makeCreateTx(destAddress)
+		return minion.makeCreateTx(ctx, destAddress, memo)
 	}
 }
 
-func (minion *Minion) makeCreateTx(destAddress string) ([32]byte, string, error) {
+func (minion *Minion) makeCreateTx(ctx context.Context, destAddress string, memo Memo) ([32]byte, string, error) {
 	createAccountOp := txnbuild.CreateAccount{
 		Destination:   destAddress,
 		SourceAccount: minion.BotAccount.GetAccountID(),
@@ -248,6 +329,7 @@ func (minion *Minion) makeCreateTx(destAddress string) ([32]byte, string, error)
 			IncrementSequenceNum: true,
 			Operations:           []txnbuild.Operation{&createAccountOp},
 			BaseFee:              minion.BaseFee,
+			Memo:                 memo.txnbuildMemo(),
 			Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewInfiniteTimeout()},
 		},
 	)
@@ -255,7 +337,7 @@ func (minion *Minion) makeCreateTx(destAddress string) ([32]byte, string, error)
 		return [32]byte{}, "", errors.Wrap(err, "unable to build tx")
 	}
 
-	tx, err = tx.Sign(minion.Network, minion.Keypair, minion.BotKeypair)
+	tx, err = minion.sign(ctx, tx)
 	if err != nil {
 		return [32]byte{}, "", errors.Wrap(err, "unable to sign tx")
 	}
@@ -272,7 +354,7 @@ func (minion *Minion) makeCreateTx(destAddress string) ([32]byte, string, error)
 	return txh, txe, err
 }
 
-func (minion *Minion) makePaymentTx(destAddress string) ([32]byte, string, error) {
+func (minion *Minion) makePaymentTx(ctx context.Context, destAddress string, memo Memo) ([32]byte, string, error) {
 	paymentOp := txnbuild.Payment{
 		SourceAccount: minion.BotAccount.GetAccountID(),
 		Destination:   destAddress,
@@ -285,6 +367,7 @@ func (minion *Minion) makePaymentTx(destAddress string) ([32]byte, string, error
 			IncrementSequenceNum: true,
 			Operations:           []txnbuild.Operation{&paymentOp},
 			BaseFee:              minion.BaseFee,
+			Memo:                 memo.txnbuildMemo(),
 			Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewInfiniteTimeout()},
 		},
 	)
@@ -292,7 +375,7 @@ func (minion *Minion) makePaymentTx(destAddress string) ([32]byte, string, error
 		return [32]byte{}, "", errors.Wrap(err, "unable to build tx")
 	}
 
-	tx, err = tx.Sign(minion.Network, minion.Keypair, minion.BotKeypair)
+	tx, err = minion.sign(ctx, tx)
 	if err != nil {
 		return [32]byte{}, "", errors.Wrap(err, "unable to sign tx")
 	}
@@ -397,7 +480,7 @@ func (minion *Minion) makeContractPaymentTx(ctx context.Context, destContractAdd
 	}
 
 	// Sign the transaction
-	tx, err = tx.Sign(minion.Network, minion.Keypair, minion.BotKeypair)
+	tx, err = minion.sign(ctx, tx)
 	if err != nil {
 		span.SetStatus(codes.Error, err.Error())
 		return [32]byte{}, "", errors.Wrap(err, "unable to sign tx")
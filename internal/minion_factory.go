@@ -0,0 +1,404 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/stellar/go-stellar-sdk/keypair"
+	"github.com/stellar/go-stellar-sdk/support/errors"
+	"github.com/stellar/go-stellar-sdk/txnbuild"
+	"golang.org/x/sync/errgroup"
+)
+
+// MinionFactory creates minion accounts funded by the bot account, and merges
+// them back into it once they are no longer needed.
+type MinionFactory struct {
+	BotAccount Account
+	// BotSigner signs the operations sourced from the bot account.
+	BotSigner     Signer
+	NetworkClient NetworkClient
+	Network       string
+	// StartingBalance is the amount the created minions fund addresses with.
+	// It and BaseFee must only be changed with SetFunding once the factory
+	// is in use.
+	StartingBalance string
+	// MinionBalance is the amount each minion account is created with.
+	MinionBalance          string
+	BatchSize              int
+	SubmitTxRetriesAllowed int
+	BaseFee                int64
+	// Concurrency is the maximum number of batches submitted at once. Only
+	// one batch at a time uses the bot account as its transaction source;
+	// the others use minions created earlier in the same call as channel
+	// accounts. Values below 1 are treated as 1.
+	Concurrency int
+	// Store, if set, persists the created minions so that Resume can reuse
+	// them after a restart.
+	Store MinionStore
+
+	// fundingMux guards StartingBalance and BaseFee.
+	fundingMux sync.Mutex
+}
+
+// createBatch is a single CreateAccount transaction submitted by Create.
+type createBatch struct {
+	// channel, if set, is the minion used as the transaction source instead
+	// of the bot account.
+	channel *Minion
+	size    int
+	minions []Minion
+	err     error
+}
+
+// Create creates numMinions new minion accounts. If an error occurs, the
+// minions created before it are returned along with the error.
+func (f *MinionFactory) Create(ctx context.Context, numMinions int) ([]Minion, error) {
+	var minions []Minion
+	// Allow retries to account for testnet congestion
+	currentSubmitTxRetry := 0
+
+	for len(minions) < numMinions {
+		batches := f.planBatches(minions, numMinions-len(minions))
+		var wg sync.WaitGroup
+		for i := range batches {
+			wg.Add(1)
+			go func(batch *createBatch) {
+				defer wg.Done()
+				batch.minions, batch.err = f.createBatch(ctx, batch.channel, batch.size)
+			}(&batches[i])
+		}
+		wg.Wait()
+
+		var retryErr error
+		for _, batch := range batches {
+			if batch.err == nil {
+				minions = append(minions, batch.minions...)
+				log.Printf("Submitted create accounts tx for %d minions successfully", batch.size)
+				continue
+			}
+			// If we hit an error here due to network congestion, or a bad seq on
+			// the source account, try again until we hit max # of retries allowed
+			if e, ok := errors.Cause(batch.err).(NetworkError); ok && (e.IsTimeout() || e.IsBadSequence()) {
+				retryErr = batch.err
+				continue
+			}
+			return minions, batch.err
+		}
+		if retryErr == nil {
+			currentSubmitTxRetry = 0
+			continue
+		}
+		if currentSubmitTxRetry >= f.SubmitTxRetriesAllowed {
+			return minions, errors.Wrap(retryErr, fmt.Sprintf("after retrying %d times", currentSubmitTxRetry))
+		}
+		log.Println(retryErr)
+		log.Println("trying again to submit create accounts tx")
+		currentSubmitTxRetry += 1
+	}
+	return minions, nil
+}
+
+// planBatches splits up to Concurrency batches of the remaining minions
+// between the bot account and the already created minions.
+func (f *MinionFactory) planBatches(created []Minion, remaining int) []createBatch {
+	concurrency := max(f.Concurrency, 1)
+	var batches []createBatch
+	for remaining > 0 && len(batches) < concurrency {
+		batch := createBatch{size: min(remaining, f.BatchSize)}
+		if channel := len(batches) - 1; channel >= 0 {
+			if channel >= len(created) {
+				break
+			}
+			batch.channel = &created[channel]
+		}
+		batches = append(batches, batch)
+		remaining -= batch.size
+	}
+	return batches
+}
+
+// createBatch creates numMinions minion accounts in a single transaction,
+// sourced from channel if it is set, or the bot account otherwise.
+func (f *MinionFactory) createBatch(ctx context.Context, channel *Minion, numMinions int) ([]Minion, error) {
+	var (
+		source   = f.BotAccount
+		keypairs []*keypair.Full
+		opSrc    string
+	)
+	if channel != nil {
+		source = Account{AccountID: channel.Account.AccountID}
+		keypairs = append(keypairs, channel.Keypair)
+		opSrc = f.BotAccount.AccountID
+	}
+	// Refresh the sequence number before submitting a new transaction.
+	if err := source.RefreshSequenceNumber(ctx, f.NetworkClient); err != nil {
+		if channel != nil {
+			return nil, errors.Wrap(err, "refreshing channel seqnum")
+		}
+		return nil, errors.Wrap(err, "refreshing bot seqnum")
+	}
+
+	log.Printf("Creating %d new minion accounts", numMinions)
+	var (
+		minions []Minion
+		ops     []txnbuild.Operation
+		pending = map[string]StoredMinion{}
+	)
+	for i := 0; i < numMinions; i++ {
+		minionKeypair, err := keypair.Random()
+		if err != nil {
+			return nil, errors.Wrap(err, "making keypair")
+		}
+		minions = append(minions, f.newMinion(minionKeypair))
+		pending[minionKeypair.Address()] = StoredMinion{Seed: minionKeypair.Seed()}
+
+		ops = append(ops, &txnbuild.CreateAccount{
+			Destination:   minionKeypair.Address(),
+			Amount:        f.MinionBalance,
+			SourceAccount: opSrc,
+		})
+	}
+
+	// Build and submit batched account creation tx.
+	tx, err := txnbuild.NewTransaction(
+		txnbuild.TransactionParams{
+			SourceAccount:        source,
+			IncrementSequenceNum: true,
+			Operations:           ops,
+			BaseFee:              txnbuild.MinBaseFee,
+			Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to build tx")
+	}
+
+	tx, err = signTransaction(ctx, tx, f.Network, f.BotSigner, keypairs...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to sign tx")
+	}
+
+	txe, err := tx.Base64()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to serialize tx")
+	}
+
+	// Record the minions before submitting, so that they are not lost if
+	// friendbot stops before the result is known.
+	if f.Store != nil {
+		if err := f.Store.Put(ctx, pending); err != nil {
+			return nil, errors.Wrap(err, "storing pending minions")
+		}
+	}
+
+	_, err = f.NetworkClient.SubmitTransaction(ctx, txe)
+	if err != nil {
+		// A timed out transaction may still be applied, so its minions are
+		// left pending for Resume to check.
+		if e, ok := err.(NetworkError); !ok || !e.IsTimeout() {
+			f.forget(ctx, pending)
+		}
+		return nil, errors.Wrap(err, "submitting create accounts tx")
+	}
+
+	if f.Store != nil {
+		for address, minion := range pending {
+			minion.Created = true
+			pending[address] = minion
+		}
+		if err := f.Store.Put(ctx, pending); err != nil {
+			log.Printf("Failed to store created minions: %v", err)
+		}
+	}
+	return minions, nil
+}
+
+// forget removes minions that were not created from the store.
+func (f *MinionFactory) forget(ctx context.Context, minions map[string]StoredMinion) {
+	if f.Store == nil {
+		return
+	}
+	addresses := make([]string, 0, len(minions))
+	for address := range minions {
+		addresses = append(addresses, address)
+	}
+	if err := f.Store.Remove(ctx, addresses); err != nil {
+		log.Printf("Failed to remove minions from the store: %v", err)
+	}
+}
+
+// Resume returns the minions previously created and persisted in Store.
+// Every minion is checked on the network, and dropped if its account does not
+// exist, whether its creation was still in flight or it has since been merged
+// or removed from the network, such as by a testnet reset.
+func (f *MinionFactory) Resume(ctx context.Context) ([]Minion, error) {
+	if f.Store == nil {
+		return nil, nil
+	}
+	stored, err := f.Store.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	type storedAccount struct {
+		address string
+		minion  StoredMinion
+		keypair *keypair.Full
+		exists  bool
+	}
+	accounts := make([]storedAccount, 0, len(stored))
+	for address, storedMinion := range stored {
+		minionKeypair, err := keypair.ParseFull(storedMinion.Seed)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing stored minion %s", address)
+		}
+		accounts = append(accounts, storedAccount{address: address, minion: storedMinion, keypair: minionKeypair})
+	}
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(maxConcurrentMinionLookups)
+	for i := range accounts {
+		account := &accounts[i]
+		group.Go(func() error {
+			_, err := f.NetworkClient.GetAccountDetails(groupCtx, account.address)
+			if e, ok := err.(NetworkError); ok && e.IsNotFound() {
+				return nil
+			}
+			if err != nil {
+				return errors.Wrapf(err, "checking stored minion %s", account.address)
+			}
+			account.exists = true
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	var (
+		minions   []Minion
+		confirmed = map[string]StoredMinion{}
+		missing   []string
+	)
+	for _, account := range accounts {
+		if !account.exists {
+			missing = append(missing, account.address)
+			continue
+		}
+		if !account.minion.Created {
+			account.minion.Created = true
+			confirmed[account.address] = account.minion
+		}
+		minions = append(minions, f.newMinion(account.keypair))
+	}
+
+	if len(missing) > 0 {
+		if err := f.Store.Remove(ctx, missing); err != nil {
+			return nil, errors.Wrap(err, "removing missing minions")
+		}
+	}
+	if len(confirmed) > 0 {
+		if err := f.Store.Put(ctx, confirmed); err != nil {
+			return nil, errors.Wrap(err, "storing confirmed minions")
+		}
+	}
+	if len(minions) > 0 {
+		log.Printf("Resumed %d minions from the minion store", len(minions))
+	}
+	return minions, nil
+}
+
+// SetFunding changes the starting balance and base fee of the minions
+// created from now on.
+func (f *MinionFactory) SetFunding(startingBalance string, baseFee int64) {
+	f.fundingMux.Lock()
+	defer f.fundingMux.Unlock()
+	f.StartingBalance = startingBalance
+	f.BaseFee = baseFee
+}
+
+// funding returns the starting balance and base fee of new minions.
+func (f *MinionFactory) funding() (string, int64) {
+	f.fundingMux.Lock()
+	defer f.fundingMux.Unlock()
+	return f.StartingBalance, f.BaseFee
+}
+
+// newMinion returns the Minion for the account of minionKeypair.
+func (f *MinionFactory) newMinion(minionKeypair *keypair.Full) Minion {
+	startingBalance, baseFee := f.funding()
+	return Minion{
+		Account:              Account{AccountID: minionKeypair.Address()},
+		Keypair:              minionKeypair,
+		BotAccount:           f.BotAccount,
+		BotSigner:            f.BotSigner,
+		NetworkClient:        f.NetworkClient,
+		Network:              f.Network,
+		StartingBalance:      startingBalance,
+		SubmitTransaction:    SubmitTransaction,
+		CheckSequenceRefresh: CheckSequenceRefresh,
+		CheckAccountExists:   CheckAccountExists,
+		BaseFee:              baseFee,
+	}
+}
+
+// Merge merges the accounts of minions back into the bot account, in batches
+// of AccountMerge operations. If an error occurs, the number of minions merged
+// before it is returned along with the error.
+func (f *MinionFactory) Merge(ctx context.Context, minions []Minion) (int, error) {
+	botAccount := f.BotAccount
+	merged := 0
+	for merged < len(minions) {
+		batch := minions[merged:min(merged+f.BatchSize, len(minions))]
+		if err := botAccount.RefreshSequenceNumber(ctx, f.NetworkClient); err != nil {
+			return merged, errors.Wrap(err, "refreshing bot seqnum")
+		}
+
+		ops := make([]txnbuild.Operation, 0, len(batch))
+		keypairs := make([]*keypair.Full, 0, len(batch))
+		for _, minion := range batch {
+			ops = append(ops, &txnbuild.AccountMerge{
+				Destination:   botAccount.AccountID,
+				SourceAccount: minion.Account.AccountID,
+			})
+			keypairs = append(keypairs, minion.Keypair)
+		}
+		log.Printf("Merging %d minion accounts into the bot account", len(batch))
+
+		tx, err := txnbuild.NewTransaction(
+			txnbuild.TransactionParams{
+				SourceAccount:        botAccount,
+				IncrementSequenceNum: true,
+				Operations:           ops,
+				BaseFee:              txnbuild.MinBaseFee,
+				Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+			},
+		)
+		if err != nil {
+			return merged, errors.Wrap(err, "unable to build tx")
+		}
+		tx, err = signTransaction(ctx, tx, f.Network, f.BotSigner, keypairs...)
+		if err != nil {
+			return merged, errors.Wrap(err, "unable to sign tx")
+		}
+		txe, err := tx.Base64()
+		if err != nil {
+			return merged, errors.Wrap(err, "unable to serialize tx")
+		}
+		if _, err := f.NetworkClient.SubmitTransaction(ctx, txe); err != nil {
+			return merged, errors.Wrap(err, "submitting merge accounts tx")
+		}
+		merged += len(batch)
+		if f.Store != nil {
+			addresses := make([]string, 0, len(batch))
+			for _, minion := range batch {
+				addresses = append(addresses, minion.Account.AccountID)
+			}
+			if err := f.Store.Remove(ctx, addresses); err != nil {
+				log.Printf("Failed to remove merged minions from the store: %v", err)
+			}
+		}
+	}
+	return merged, nil
+}
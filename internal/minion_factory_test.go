@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stellar/go-stellar-sdk/keypair"
+	"github.com/stellar/go-stellar-sdk/txnbuild"
+	"github.com/stellar/go-stellar-sdk/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// notFoundError is a NetworkError reporting that an account does not exist.
+type notFoundError struct{}
+
+func (notFoundError) Error() string                    { return "not found" }
+func (notFoundError) IsNotFound() bool                 { return true }
+func (notFoundError) IsBadSequence() bool              { return false }
+func (notFoundError) IsTimeout() bool                  { return false }
+func (notFoundError) ResultString() (string, error)    { return "", nil }
+func (notFoundError) DiagnosticEventStrings() []string { return nil }
+
+// factoryNetworkClient is a NetworkClient recording the source account of
+// every submitted transaction, and reporting the accounts in missing as not
+// existing.
+type factoryNetworkClient struct {
+	mu      sync.Mutex
+	sources []string
+	missing map[string]bool
+}
+
+func (c *factoryNetworkClient) SubmitTransaction(ctx context.Context, txXDR string) (*SubmitTransactionResult, error) {
+	var envelope xdr.TransactionEnvelope
+	if err := xdr.SafeUnmarshalBase64(txXDR, &envelope); err != nil {
+		return nil, err
+	}
+	source := envelope.SourceAccount().ToAccountId()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sources = append(c.sources, source.Address())
+	return &SubmitTransactionResult{}, nil
+}
+
+func (c *factoryNetworkClient) GetAccountDetails(ctx context.Context, accountID string) (*AccountDetails, error) {
+	if c.missing[accountID] {
+		return nil, notFoundError{}
+	}
+	return &AccountDetails{Sequence: 1, Balance: "101.0000000"}, nil
+}
+
+func (c *factoryNetworkClient) SimulateTransaction(ctx context.Context, txXDR string) (*SimulateTransactionResult, error) {
+	return nil, nil
+}
+
+func (c *factoryNetworkClient) SupportsContractAddresses() bool {
+	return false
+}
+
+func (c *factoryNetworkClient) NetworkInfo(ctx context.Context) (*NetworkInfo, error) {
+	return &NetworkInfo{Passphrase: "Test SDF Network ; September 2015", ProtocolVersion: 22}, nil
+}
+
+func newTestMinionFactory(t *testing.T, networkClient NetworkClient) *MinionFactory {
+	botKeypair, err := keypair.Random()
+	require.NoError(t, err)
+	return &MinionFactory{
+		BotAccount:      Account{AccountID: botKeypair.Address()},
+		BotSigner:       NewKeypairSigner(botKeypair),
+		NetworkClient:   networkClient,
+		Network:         "Test SDF Network ; September 2015",
+		StartingBalance: "10000.00",
+		MinionBalance:   "101.00",
+		BatchSize:       2,
+		BaseFee:         txnbuild.MinBaseFee,
+		Concurrency:     3,
+	}
+}
+
+func TestMinionFactory_Create_usesChannelAccounts(t *testing.T) {
+	networkClient := &factoryNetworkClient{}
+	factory := newTestMinionFactory(t, networkClient)
+	factory.Store = NewFileMinionStore(filepath.Join(t.TempDir(), "minions.json"), factory.BotAccount.AccountID, factory.Network)
+
+	minions, err := factory.Create(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Len(t, minions, 10)
+
+	// The first batch has no channel accounts to use, the next round uses two
+	// of its minions alongside the bot account, and the last batch fits in
+	// the bot account's transaction.
+	bot := factory.BotAccount.AccountID
+	require.Len(t, networkClient.sources, 5)
+	assert.Equal(t, bot, networkClient.sources[0])
+	assert.ElementsMatch(t, []string{bot, minions[0].Account.AccountID, minions[1].Account.AccountID}, networkClient.sources[1:4])
+	assert.Equal(t, bot, networkClient.sources[4])
+
+	stored, err := factory.Store.Load(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, stored, 10)
+	for _, minion := range minions {
+		assert.True(t, stored[minion.Account.AccountID].Created)
+	}
+}
+
+func TestMinionFactory_Resume(t *testing.T) {
+	ctx := context.Background()
+	networkClient := &factoryNetworkClient{missing: map[string]bool{}}
+	factory := newTestMinionFactory(t, networkClient)
+	factory.Store = NewFileMinionStore(filepath.Join(t.TempDir(), "minions.json"), factory.BotAccount.AccountID, factory.Network)
+
+	var keypairs []*keypair.Full
+	for i := 0; i < 4; i++ {
+		kp, err := keypair.Random()
+		require.NoError(t, err)
+		keypairs = append(keypairs, kp)
+	}
+	networkClient.missing[keypairs[2].Address()] = true
+	networkClient.missing[keypairs[3].Address()] = true
+	require.NoError(t, factory.Store.Put(ctx, map[string]StoredMinion{
+		keypairs[0].Address(): {Seed: keypairs[0].Seed(), Created: true},
+		keypairs[1].Address(): {Seed: keypairs[1].Seed()},
+		keypairs[2].Address(): {Seed: keypairs[2].Seed()},
+		keypairs[3].Address(): {Seed: keypairs[3].Seed(), Created: true},
+	}))
+
+	minions, err := factory.Resume(ctx)
+	require.NoError(t, err)
+	var addresses []string
+	for _, minion := range minions {
+		addresses = append(addresses, minion.Account.AccountID)
+	}
+	assert.ElementsMatch(t, []string{keypairs[0].Address(), keypairs[1].Address()}, addresses)
+
+	// The pending minion that exists is confirmed, and the missing ones are
+	// forgotten, even if they were created.
+	stored, err := factory.Store.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]StoredMinion{
+		keypairs[0].Address(): {Seed: keypairs[0].Seed(), Created: true},
+		keypairs[1].Address(): {Seed: keypairs[1].Seed(), Created: true},
+	}, stored)
+}
@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/stellar/go-stellar-sdk/support/errors"
+)
+
+// StoredMinion is a minion account persisted by a MinionStore.
+type StoredMinion struct {
+	Seed string `json:"seed"`
+	// Created is false while the transaction creating the account is in
+	// flight, or if its outcome is unknown.
+	Created bool `json:"created"`
+}
+
+// MinionStore persists the minion accounts created by a MinionFactory, so that
+// they are reused rather than created again when friendbot restarts.
+type MinionStore interface {
+	// Load returns the stored minions.
+	Load(ctx context.Context) (map[string]StoredMinion, error)
+	// Put stores minions, keyed by address.
+	Put(ctx context.Context, minions map[string]StoredMinion) error
+	// Remove deletes the minions with the given addresses.
+	Remove(ctx context.Context, addresses []string) error
+}
+
+// FileMinionStore is a MinionStore that keeps minions in a JSON file, which
+// holds the minions' secret seeds and is written with owner-only permissions.
+// The file records the bot account and network it belongs to, and refuses to
+// load for a different one.
+type FileMinionStore struct {
+	path string
+
+	mu      sync.Mutex
+	loaded  bool
+	content minionStoreFile
+}
+
+// Ensure FileMinionStore implements the MinionStore interface.
+var _ MinionStore = (*FileMinionStore)(nil)
+
+type minionStoreFile struct {
+	BotAccountID string                  `json:"bot_account_id"`
+	Network      string                  `json:"network_passphrase"`
+	Minions      map[string]StoredMinion `json:"minions"`
+}
+
+// NewFileMinionStore returns a store persisting the minions of botAccountID
+// on network to the file at path. The file is read on first use.
+func NewFileMinionStore(path, botAccountID, network string) *FileMinionStore {
+	return &FileMinionStore{
+		path: path,
+		content: minionStoreFile{
+			BotAccountID: botAccountID,
+			Network:      network,
+			Minions:      map[string]StoredMinion{},
+		},
+	}
+}
+
+// Load returns the stored minions.
+func (s *FileMinionStore) Load(ctx context.Context) (map[string]StoredMinion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	minions := make(map[string]StoredMinion, len(s.content.Minions))
+	for address, minion := range s.content.Minions {
+		minions[address] = minion
+	}
+	return minions, nil
+}
+
+// Put stores minions, keyed by address.
+func (s *FileMinionStore) Put(ctx context.Context, minions map[string]StoredMinion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	for address, minion := range minions {
+		s.content.Minions[address] = minion
+	}
+	return s.save()
+}
+
+// Remove deletes the minions with the given addresses.
+func (s *FileMinionStore) Remove(ctx context.Context, addresses []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	for _, address := range addresses {
+		delete(s.content.Minions, address)
+	}
+	return s.save()
+}
+
+// load reads the minions from the file, if it exists and has not already been
+// read. The caller must hold s.mu.
+func (s *FileMinionStore) load() error {
+	if s.loaded {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		s.loaded = true
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "reading minion store")
+	}
+	var content minionStoreFile
+	if err := json.Unmarshal(data, &content); err != nil {
+		return errors.Wrap(err, "parsing minion store")
+	}
+	if content.BotAccountID != s.content.BotAccountID || content.Network != s.content.Network {
+		return errors.Errorf("minion store %s belongs to bot account %s on network %q; move it aside to create new minions",
+			s.path, content.BotAccountID, content.Network)
+	}
+	if content.Minions != nil {
+		s.content.Minions = content.Minions
+	}
+	s.loaded = true
+	return nil
+}
+
+// save atomically replaces the file with the current minions. The caller must
+// hold s.mu.
+func (s *FileMinionStore) save() error {
+	data, err := json.Marshal(s.content)
+	if err != nil {
+		return errors.Wrap(err, "encoding minion store")
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "creating minion store")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "writing minion store")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "writing minion store")
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return errors.Wrap(err, "replacing minion store")
+	}
+	return nil
+}
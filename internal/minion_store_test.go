@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMinionStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "minions.json")
+	network := "Test SDF Network ; September 2015"
+	botAccountID := "GD25B4QI6KWVDWXDW25CIM7EKR6A6PBSWE2RCNSAC4NJQDQJXZJYMMKR"
+	minion := StoredMinion{Seed: "SDTNSEERJPJFUE2LSDNYBFHYGVTPIWY7TU2IOJZQQGLWO2THTGB7NU5A", Created: true}
+	minionAddress := "GD4AGPPDFFHKK3Z2X4XZDRXX6GZQKP4FMLVQ5T55NDEYGG3GIP7BQUHM"
+
+	store := NewFileMinionStore(path, botAccountID, network)
+	require.NoError(t, store.Put(ctx, map[string]StoredMinion{minionAddress: minion}))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// A new store reads the minions back from the file.
+	stored, err := NewFileMinionStore(path, botAccountID, network).Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]StoredMinion{minionAddress: minion}, stored)
+
+	// The file cannot be used for a different bot account.
+	_, err = NewFileMinionStore(path, "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z", network).Load(ctx)
+	assert.ErrorContains(t, err, "belongs to bot account "+botAccountID)
+
+	require.NoError(t, store.Remove(ctx, []string{minionAddress}))
+	stored, err = NewFileMinionStore(path, botAccountID, network).Load(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, stored)
+}
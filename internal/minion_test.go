@@ -2,13 +2,18 @@ package internal
 
 import (
 	"context"
+	"net"
 	"sync"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stellar/go-stellar-sdk/keypair"
 	"github.com/stellar/go-stellar-sdk/support/errors"
 	"github.com/stellar/go-stellar-sdk/txnbuild"
+	"github.com/stellar/go-stellar-sdk/xdr"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // This test aims to reproduce the issue found on https://github.com/stellar/go/issues/2271
@@ -51,7 +56,7 @@ func TestMinion_NoChannelErrors(t *testing.T) {
 		},
 		Keypair:              minionKeypair.(*keypair.Full),
 		BotAccount:           botAccount,
-		BotKeypair:           botKeypair.(*keypair.Full),
+		BotSigner:            NewKeypairSigner(botKeypair.(*keypair.Full)),
 		Network:              "Test SDF Network ; September 2015",
 		StartingBalance:      "10000.00",
 		SubmitTransaction:    mockSubmitTransaction,
@@ -123,7 +128,7 @@ func TestMinion_CorrectNumberOfTxSubmissions(t *testing.T) {
 		},
 		Keypair:              minionKeypair.(*keypair.Full),
 		BotAccount:           botAccount,
-		BotKeypair:           botKeypair.(*keypair.Full),
+		BotSigner:            NewKeypairSigner(botKeypair.(*keypair.Full)),
 		Network:              "Test SDF Network ; September 2015",
 		StartingBalance:      "10000.00",
 		SubmitTransaction:    mockSubmitTransaction,
@@ -133,18 +138,109 @@ func TestMinion_CorrectNumberOfTxSubmissions(t *testing.T) {
 	}
 	fb := &Bot{Minions: []Minion{minion}}
 
-	recipientAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
-
 	numTests := 1000
 	var wg sync.WaitGroup
 	wg.Add(numTests)
 
 	for i := 0; i < numTests; i++ {
+		// Use a distinct recipient per payment, since concurrent payments to
+		// the same recipient are coalesced into a single submission.
+		recipientKeypair, err := keypair.Random()
+		if !assert.NoError(t, err) {
+			return
+		}
 		go func() {
-			fb.Pay(ctx, recipientAddress)
+			fb.Pay(ctx, recipientKeypair.Address())
 			wg.Done()
 		}()
 	}
 	wg.Wait()
 	assert.Equal(t, numTests, numTxSubmits)
 }
+
+// submitNetworkClient is a NetworkClient that accepts every transaction,
+// returning result.
+type submitNetworkClient struct {
+	poolNetworkClient
+	result *SubmitTransactionResult
+	err    error
+}
+
+func (c *submitNetworkClient) SubmitTransaction(ctx context.Context, txXDR string) (*SubmitTransactionResult, error) {
+	return c.result, c.err
+}
+
+// submitError is a NetworkError returned for a failed submission.
+type submitError struct {
+	timeout   bool
+	resultXDR string
+}
+
+func (e submitError) Error() string                    { return "submission failed" }
+func (e submitError) IsNotFound() bool                 { return false }
+func (e submitError) IsBadSequence() bool              { return false }
+func (e submitError) IsTimeout() bool                  { return e.timeout }
+func (e submitError) ResultString() (string, error)    { return e.resultXDR, nil }
+func (e submitError) DiagnosticEventStrings() []string { return nil }
+
+func TestSubmitTransaction_ReturnsLedgerDetails(t *testing.T) {
+	closeTime := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	networkClient := &submitNetworkClient{result: &SubmitTransactionResult{
+		Ledger:          1001,
+		LedgerCloseTime: closeTime,
+		ResultXDR:       "AAAAAAAAAGQAAAAAAAAAAAAAAAA=",
+		FeeCharged:      100,
+	}}
+
+	result, err := SubmitTransaction(context.Background(), &Minion{}, networkClient, [32]byte{1}, "AAAA")
+	require.NoError(t, err)
+	assert.Equal(t, &TransactionResult{
+		Successful:      true,
+		Hash:            "0100000000000000000000000000000000000000000000000000000000000000",
+		EnvelopeXdr:     "AAAA",
+		Ledger:          1001,
+		LedgerCloseTime: closeTime,
+		ResultXdr:       "AAAAAAAAAGQAAAAAAAAAAAAAAAA=",
+		FeeCharged:      100,
+	}, result)
+}
+
+func TestSubmitTransaction_ClassifiesErrors(t *testing.T) {
+	insufficientBalance, err := xdr.MarshalBase64(xdr.TransactionResult{
+		FeeCharged: 100,
+		Result:     xdr.TransactionResultResult{Code: xdr.TransactionResultCodeTxInsufficientBalance},
+	})
+	require.NoError(t, err)
+	paymentUnderfunded, err := xdr.MarshalBase64(xdr.TransactionResult{
+		FeeCharged: 100,
+		Result: xdr.TransactionResultResult{
+			Code: xdr.TransactionResultCodeTxFailed,
+			Results: &[]xdr.OperationResult{{
+				Code: xdr.OperationResultCodeOpInner,
+				Tr: &xdr.OperationResultTr{
+					Type:          xdr.OperationTypePayment,
+					PaymentResult: &xdr.PaymentResult{Code: xdr.PaymentResultCodePaymentUnderfunded},
+				},
+			}},
+		},
+	})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"network timeout", submitError{timeout: true}, ErrUpstreamTimeout},
+		{"deadline exceeded", errors.Wrap(context.DeadlineExceeded, "polling transaction"), ErrUpstreamTimeout},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, ErrUpstreamUnavailable},
+		{"minion cannot pay fee", submitError{resultXDR: insufficientBalance}, ErrInsufficientMinionBalance},
+		{"bot account underfunded", submitError{resultXDR: paymentUnderfunded}, ErrInsufficientMinionBalance},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			networkClient := &submitNetworkClient{err: tc.err}
+			_, err := SubmitTransaction(context.Background(), &Minion{}, networkClient, [32]byte{1}, "AAAA")
+			assert.Equal(t, tc.expected, errors.Cause(err))
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package internal
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // NetworkError represents a network operation error with abstracted checking methods.
 type NetworkError interface {
@@ -22,7 +25,8 @@ type NetworkError interface {
 // implementations (Horizon, RPC, etc.) to be used interchangeably.
 type NetworkClient interface {
 	// SubmitTransaction submits a transaction and blocks until it can return a result.
-	SubmitTransaction(ctx context.Context, txXDR string) error
+	// On success it returns the details of the ledger that included the transaction.
+	SubmitTransaction(ctx context.Context, txXDR string) (*SubmitTransactionResult, error)
 
 	// GetAccountDetails retrieves account information for the given account ID.
 	GetAccountDetails(ctx context.Context, accountID string) (*AccountDetails, error)
@@ -35,12 +39,51 @@ type NetworkClient interface {
 	// SupportsContractAddresses returns true if this network client can fund
 	// contract addresses (C addresses). RPC supports this, Horizon does not.
 	SupportsContractAddresses() bool
+
+	// NetworkInfo returns the passphrase and current protocol version of the
+	// network behind the client.
+	NetworkInfo(ctx context.Context) (*NetworkInfo, error)
+}
+
+// MinContractProtocolVersion is the first protocol version supporting smart
+// contracts, which funding contract addresses requires.
+const MinContractProtocolVersion = 20
+
+// NetworkInfo describes the network behind a NetworkClient.
+type NetworkInfo struct {
+	Passphrase      string
+	ProtocolVersion uint32
+}
+
+// SupportsContracts returns true if the network's protocol version supports
+// smart contracts.
+func (info NetworkInfo) SupportsContracts() bool {
+	return info.ProtocolVersion >= MinContractProtocolVersion
 }
 
 // AccountDetails contains the minimal information needed about an account.
 type AccountDetails struct {
 	Sequence int64
 	Balance  string
+	// Signers maps the key of each of the account's signers, including its
+	// master key, to the signer's weight. It is not set for contract addresses.
+	Signers map[string]int32
+	// MediumThreshold is the signing weight required by payments and account
+	// creations sourced from the account.
+	MediumThreshold int32
+}
+
+// SubmitTransactionResult describes a submitted transaction that was included
+// in a ledger.
+type SubmitTransactionResult struct {
+	// Ledger is the sequence of the ledger that included the transaction,
+	// and LedgerCloseTime the time the ledger closed.
+	Ledger          uint32
+	LedgerCloseTime time.Time
+	// ResultXDR is the TransactionResult XDR in base64.
+	ResultXDR string
+	// FeeCharged is the fee charged for the transaction, in stroops.
+	FeeCharged int64
 }
 
 // SimulateTransactionResult contains the result of simulating a transaction.
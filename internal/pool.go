@@ -0,0 +1,437 @@
+package internal
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/stellar/go-stellar-sdk/support/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+// ErrFundingPaused is returned when funding has been paused by an operator.
+var ErrFundingPaused = errors.New("funding is paused")
+
+// ErrMinionNotFound is returned when an operator refers to a minion that is
+// not in the pool.
+var ErrMinionNotFound = errors.New("minion not found")
+
+// ErrNoAvailableMinions is returned when every minion in the pool has been
+// quarantined.
+var ErrNoAvailableMinions = errors.New("no minions are available")
+
+// minionErrorWindow is how far back errors are counted towards a minion's
+// recent error count.
+const minionErrorWindow = time.Hour
+
+// maxConcurrentMinionLookups bounds the account lookups made when listing the
+// minions in the pool, or checking the stored minions when resuming them.
+const maxConcurrentMinionLookups = 10
+
+// minionState is the bot's bookkeeping for a minion in the pool.
+type minionState struct {
+	// active is the number of payments the minion is currently making.
+	active int
+	// quarantined minions are not handed out for new payments.
+	quarantined bool
+	// parked is set once a quarantined minion has been withheld from the
+	// queue, and so must be returned to it when it is released.
+	parked bool
+	// forceRefresh makes the next payment refresh the minion's sequence.
+	forceRefresh bool
+	// retired minions have been merged back into the bot account, or are
+	// being merged, and are no longer part of the pool.
+	retired bool
+	errors  []time.Time
+}
+
+// MinionStatus describes a minion in the pool.
+type MinionStatus struct {
+	Address string `json:"address"`
+	// Sequence and Balance are looked up from the network, and are empty if
+	// that lookup fails.
+	Sequence     string `json:"sequence,omitempty"`
+	Balance      string `json:"balance,omitempty"`
+	InUse        bool   `json:"in_use"`
+	Quarantined  bool   `json:"quarantined"`
+	RecentErrors int    `json:"recent_errors"`
+}
+
+// acquireMinion selects the minion to use for a payment, and returns a func
+// that must be called with the minion, as the payment left it, and the
+// payment's error once the minion is no longer in use.
+func (bot *Bot) acquireMinion(ctx context.Context) (Minion, func(Minion, error), error) {
+	if bot.Queue == nil {
+		bot.indexMux.Lock()
+		defer bot.indexMux.Unlock()
+		for range bot.Minions {
+			index := bot.nextMinionIndex
+			bot.nextMinionIndex = (bot.nextMinionIndex + 1) % len(bot.Minions)
+			if state := bot.stateLocked(index); state.quarantined || state.retired {
+				continue
+			}
+			log.Printf("Selecting minion at index %d of max length %d", index, len(bot.Minions))
+			return bot.checkoutLocked(index), func(minion Minion, err error) { bot.checkin(index, minion, err) }, nil
+		}
+		return Minion{}, nil, ErrNoAvailableMinions
+	}
+
+	for {
+		index, err := bot.Queue.Acquire(ctx, clientFromContext(ctx))
+		if err != nil {
+			return Minion{}, nil, err
+		}
+		bot.indexMux.Lock()
+		if state := bot.stateLocked(index); state.quarantined {
+			// The minion was quarantined while idle, keep it out of the queue.
+			state.parked = true
+			bot.indexMux.Unlock()
+			continue
+		}
+		log.Printf("Selecting minion at index %d of max length %d", index, len(bot.Minions))
+		minion := bot.checkoutLocked(index)
+		bot.indexMux.Unlock()
+		return minion, func(minion Minion, err error) { bot.checkin(index, minion, err) }, nil
+	}
+}
+
+// checkoutLocked marks the minion at index as in use and returns a copy of it
+// for a payment. The caller must hold bot.indexMux.
+func (bot *Bot) checkoutLocked(index int) Minion {
+	state := bot.stateLocked(index)
+	state.active++
+	minion := bot.Minions[index]
+	if state.forceRefresh {
+		minion.forceRefreshSequence = true
+		state.forceRefresh = false
+	}
+	return minion
+}
+
+// checkin records the outcome of a payment made by minion, the minion at
+// index, and returns it to the queue unless it has been quarantined.
+func (bot *Bot) checkin(index int, minion Minion, err error) {
+	bot.indexMux.Lock()
+	state := bot.stateLocked(index)
+	state.active--
+	// Keep the sequence number the payment left the minion with for its next
+	// payment. If the payment failed otherwise, the transaction may or may
+	// not have used it, and if another payment is using the minion, the
+	// numbers may be out of order, so it is refreshed from the network.
+	switch errors.Cause(err) {
+	case nil, ErrAccountExists, ErrAccountFunded:
+		if state.active == 0 {
+			bot.Minions[index].Account.Sequence = minion.Account.Sequence
+			break
+		}
+		state.forceRefresh = true
+	default:
+		state.forceRefresh = true
+	}
+	if isMinionError(err) {
+		state.errors = append(state.errors, time.Now())
+	}
+	if bot.Queue == nil {
+		bot.indexMux.Unlock()
+		return
+	}
+	if state.quarantined {
+		state.parked = true
+		bot.indexMux.Unlock()
+		return
+	}
+	bot.indexMux.Unlock()
+	bot.Queue.Release(index)
+}
+
+// isMinionError returns whether err is a failure of the minion, rather than
+// a rejection of the request.
+func isMinionError(err error) bool {
+	switch errors.Cause(err) {
+	case nil, ErrAccountExists, ErrAccountFunded, context.Canceled:
+		return false
+	}
+	return true
+}
+
+// stateLocked returns the state of the minion at index. The caller must hold
+// bot.indexMux.
+func (bot *Bot) stateLocked(index int) *minionState {
+	for len(bot.minionStates) < len(bot.Minions) {
+		bot.minionStates = append(bot.minionStates, minionState{})
+	}
+	return &bot.minionStates[index]
+}
+
+// indexLocked returns the index of the minion with the given address. The
+// caller must hold bot.indexMux.
+func (bot *Bot) indexLocked(address string) (int, error) {
+	for i := range bot.Minions {
+		if bot.Minions[i].Account.AccountID == address && !bot.stateLocked(i).retired {
+			return i, nil
+		}
+	}
+	return 0, ErrMinionNotFound
+}
+
+// statusLocked returns the status of the minion at index, without the details
+// looked up from the network. The caller must hold bot.indexMux.
+func (bot *Bot) statusLocked(index int) MinionStatus {
+	state := bot.stateLocked(index)
+	i := 0
+	for i < len(state.errors) && time.Since(state.errors[i]) >= minionErrorWindow {
+		i++
+	}
+	state.errors = state.errors[i:]
+	return MinionStatus{
+		Address:      bot.Minions[index].Account.AccountID,
+		InUse:        state.active > 0,
+		Quarantined:  state.quarantined,
+		RecentErrors: len(state.errors),
+	}
+}
+
+// MinionStatuses returns the status of every minion in the pool, including
+// its sequence number and balance on the network.
+func (bot *Bot) MinionStatuses(ctx context.Context) []MinionStatus {
+	bot.indexMux.Lock()
+	statuses := make([]MinionStatus, 0, len(bot.Minions))
+	for i := range bot.Minions {
+		if !bot.stateLocked(i).retired {
+			statuses = append(statuses, bot.statusLocked(i))
+		}
+	}
+	bot.indexMux.Unlock()
+
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(maxConcurrentMinionLookups)
+	for i := range statuses {
+		status := &statuses[i]
+		group.Go(func() error {
+			details, err := bot.NetworkClient.GetAccountDetails(ctx, status.Address)
+			if err != nil {
+				log.Printf("Failed to look up minion %s: %v", status.Address, err)
+				return nil
+			}
+			status.Sequence = strconv.FormatInt(details.Sequence, 10)
+			status.Balance = details.Balance
+			return nil
+		})
+	}
+	_ = group.Wait()
+	return statuses
+}
+
+// NumMinions returns the number of minions in the pool, including quarantined
+// minions.
+func (bot *Bot) NumMinions() int {
+	bot.indexMux.Lock()
+	defer bot.indexMux.Unlock()
+	n := 0
+	for i := range bot.Minions {
+		if !bot.stateLocked(i).retired {
+			n++
+		}
+	}
+	return n
+}
+
+// QuarantineMinion stops the minion with the given address from being used
+// for new payments. A payment it is already making is allowed to finish.
+func (bot *Bot) QuarantineMinion(address string) (MinionStatus, error) {
+	bot.indexMux.Lock()
+	defer bot.indexMux.Unlock()
+	index, err := bot.indexLocked(address)
+	if err != nil {
+		return MinionStatus{}, err
+	}
+	state := bot.stateLocked(index)
+	if !state.quarantined {
+		state.quarantined = true
+		if bot.Queue != nil && bot.Queue.Take(index) {
+			state.parked = true
+		}
+		log.Printf("Quarantined minion %s", address)
+	}
+	return bot.statusLocked(index), nil
+}
+
+// ReleaseMinion returns a quarantined minion with the given address to the
+// pool.
+func (bot *Bot) ReleaseMinion(address string) (MinionStatus, error) {
+	bot.indexMux.Lock()
+	index, err := bot.indexLocked(address)
+	if err != nil {
+		bot.indexMux.Unlock()
+		return MinionStatus{}, err
+	}
+	state := bot.stateLocked(index)
+	state.quarantined = false
+	parked := state.parked
+	state.parked = false
+	status := bot.statusLocked(index)
+	bot.indexMux.Unlock()
+
+	if parked {
+		bot.Queue.Release(index)
+	}
+	log.Printf("Released minion %s from quarantine", address)
+	return status, nil
+}
+
+// RefreshMinionSequence makes the next payment by the minion with the given
+// address refresh its sequence number from the network first.
+func (bot *Bot) RefreshMinionSequence(address string) (MinionStatus, error) {
+	bot.indexMux.Lock()
+	defer bot.indexMux.Unlock()
+	index, err := bot.indexLocked(address)
+	if err != nil {
+		return MinionStatus{}, err
+	}
+	bot.stateLocked(index).forceRefresh = true
+	return bot.statusLocked(index), nil
+}
+
+// AddMinions creates numMinions new minion accounts and adds them to the
+// pool, in the slots of retired minions where there are any, returning how
+// many were added. If an error occurs, the minions created before it are
+// still added.
+func (bot *Bot) AddMinions(ctx context.Context, numMinions int) (int, error) {
+	if bot.MinionFactory == nil {
+		return 0, errors.New("adding minions is not supported without a minion factory")
+	}
+	// Creations are serialized since they all spend the bot account's
+	// sequence numbers.
+	bot.growMux.Lock()
+	defer bot.growMux.Unlock()
+
+	minions, err := bot.MinionFactory.Create(ctx, numMinions)
+
+	bot.indexMux.Lock()
+	// The funding settings may have been reconfigured while the minions
+	// were being created.
+	startingBalance, baseFee := bot.MinionFactory.funding()
+	for i := range minions {
+		minions[i].StartingBalance = startingBalance
+		minions[i].BaseFee = baseFee
+	}
+	// The new minions take the slots of retired minions first. No minion is
+	// being retired while growMux is held, so every retired slot is free.
+	indexes := make([]int, 0, len(minions))
+	for i := range bot.Minions {
+		if len(indexes) < len(minions) && bot.stateLocked(i).retired {
+			indexes = append(indexes, i)
+		}
+	}
+	for i, minion := range minions {
+		if i < len(indexes) {
+			bot.Minions[indexes[i]] = minion
+			bot.minionStates[indexes[i]] = minionState{}
+			continue
+		}
+		indexes = append(indexes, len(bot.Minions))
+		bot.Minions = append(bot.Minions, minion)
+	}
+	bot.indexMux.Unlock()
+
+	if bot.Queue != nil {
+		for _, i := range indexes {
+			bot.Queue.Release(i)
+		}
+	}
+	if len(minions) > 0 {
+		log.Printf("Added %d minions to the pool", len(minions))
+	}
+	if err != nil {
+		return len(minions), errors.Wrap(err, "creating minion accounts")
+	}
+	return len(minions), nil
+}
+
+// FillMinions adds minions until the pool has numMinions, retrying with
+// backoff after failures, until ctx is done.
+func (bot *Bot) FillMinions(ctx context.Context, numMinions int) {
+	delay := time.Second
+	for {
+		missing := numMinions - bot.NumMinions()
+		if missing <= 0 {
+			log.Printf("Minion pool is full with %d minions", numMinions)
+			return
+		}
+		_, err := bot.AddMinions(ctx, missing)
+		if err == nil {
+			delay = time.Second
+			continue
+		}
+		log.Printf("Failed to fill the minion pool, %d of %d minions are ready, retrying in %s: %v", bot.NumMinions(), numMinions, delay, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, time.Minute)
+	}
+}
+
+// RetireMinions merges up to numMinions idle minions back into the bot
+// account, removing them from the pool, and returns how many were retired.
+// Quarantined and busy minions are never retired.
+func (bot *Bot) RetireMinions(ctx context.Context, numMinions int) (int, error) {
+	if bot.MinionFactory == nil || bot.Queue == nil {
+		return 0, errors.New("retiring minions is not supported without a minion factory and queue")
+	}
+	bot.growMux.Lock()
+	defer bot.growMux.Unlock()
+
+	var (
+		indexes []int
+		minions []Minion
+	)
+	bot.indexMux.Lock()
+	for i := len(bot.Minions) - 1; i >= 0 && len(indexes) < numMinions; i-- {
+		state := bot.stateLocked(i)
+		if state.retired || state.quarantined || !bot.Queue.Take(i) {
+			continue
+		}
+		state.retired = true
+		indexes = append(indexes, i)
+		minions = append(minions, bot.Minions[i])
+	}
+	bot.indexMux.Unlock()
+
+	merged, err := bot.MinionFactory.Merge(ctx, minions)
+
+	// Return the minions that could not be merged to the pool.
+	bot.indexMux.Lock()
+	for _, i := range indexes[merged:] {
+		bot.stateLocked(i).retired = false
+	}
+	bot.indexMux.Unlock()
+	for _, i := range indexes[merged:] {
+		bot.Queue.Release(i)
+	}
+	if merged > 0 {
+		log.Printf("Retired %d minions from the pool", merged)
+	}
+	if err != nil {
+		return merged, errors.Wrap(err, "merging minion accounts")
+	}
+	return merged, nil
+}
+
+// SetPaused pauses or resumes funding. While paused, requests fail with
+// ErrFundingPaused.
+func (bot *Bot) SetPaused(paused bool) {
+	bot.paused.Store(paused)
+	if paused {
+		log.Printf("Funding paused")
+	} else {
+		log.Printf("Funding resumed")
+	}
+}
+
+// Paused returns whether funding is paused.
+func (bot *Bot) Paused() bool {
+	return bot.paused.Load()
+}
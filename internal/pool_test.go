@@ -0,0 +1,291 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stellar/go-stellar-sdk/keypair"
+	"github.com/stellar/go-stellar-sdk/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// poolNetworkClient is a NetworkClient that accepts every transaction and
+// reports every account as existing.
+type poolNetworkClient struct {
+	mu        sync.Mutex
+	submitted int
+}
+
+func (c *poolNetworkClient) SubmitTransaction(ctx context.Context, txXDR string) (*SubmitTransactionResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.submitted++
+	return &SubmitTransactionResult{}, nil
+}
+
+func (c *poolNetworkClient) GetAccountDetails(ctx context.Context, accountID string) (*AccountDetails, error) {
+	return &AccountDetails{Sequence: 7, Balance: "101.0000000"}, nil
+}
+
+func (c *poolNetworkClient) SimulateTransaction(ctx context.Context, txXDR string) (*SimulateTransactionResult, error) {
+	return nil, nil
+}
+
+func (c *poolNetworkClient) SupportsContractAddresses() bool {
+	return false
+}
+
+func (c *poolNetworkClient) NetworkInfo(ctx context.Context) (*NetworkInfo, error) {
+	return &NetworkInfo{Passphrase: "Test SDF Network ; September 2015", ProtocolVersion: 22}, nil
+}
+
+// newTestPoolBot returns a bot with numMinions minions, recording the address
+// of the minion used for each payment to used.
+func newTestPoolBot(t *testing.T, numMinions int, submitErr error) (*Bot, chan string) {
+	used := make(chan string, 100)
+	mockSubmitTransaction := func(ctx context.Context, minion *Minion, networkClient NetworkClient, txHash [32]byte, tx string) (*TransactionResult, error) {
+		used <- minion.Account.AccountID
+		if submitErr != nil {
+			return nil, submitErr
+		}
+		return &TransactionResult{Successful: true}, nil
+	}
+	mockCheckAccountExists := func(ctx context.Context, minion *Minion, networkClient NetworkClient, destAddress string) (bool, string, error) {
+		return false, "0", nil
+	}
+
+	botKeypair, err := keypair.Random()
+	require.NoError(t, err)
+	var minions []Minion
+	for i := 0; i < numMinions; i++ {
+		minionKeypair, err := keypair.Random()
+		require.NoError(t, err)
+		minions = append(minions, Minion{
+			Account:              Account{AccountID: minionKeypair.Address(), Sequence: 1},
+			Keypair:              minionKeypair,
+			BotAccount:           Account{AccountID: botKeypair.Address()},
+			BotSigner:            NewKeypairSigner(botKeypair),
+			Network:              "Test SDF Network ; September 2015",
+			StartingBalance:      "10000.00",
+			SubmitTransaction:    mockSubmitTransaction,
+			CheckSequenceRefresh: CheckSequenceRefresh,
+			CheckAccountExists:   mockCheckAccountExists,
+			BaseFee:              txnbuild.MinBaseFee,
+		})
+	}
+	return &Bot{
+		Minions:       minions,
+		NetworkClient: &poolNetworkClient{},
+		Queue:         NewMinionQueue(numMinions, 10, time.Second),
+	}, used
+}
+
+func TestBot_QuarantineMinion(t *testing.T) {
+	ctx := context.Background()
+	fb, used := newTestPoolBot(t, 2, nil)
+	quarantined := fb.Minions[0].Account.AccountID
+
+	status, err := fb.QuarantineMinion(quarantined)
+	require.NoError(t, err)
+	assert.True(t, status.Quarantined)
+
+	recipientAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+	for i := 0; i < 5; i++ {
+		_, err := fb.Pay(ctx, recipientAddress)
+		require.NoError(t, err)
+		assert.NotEqual(t, quarantined, <-used)
+	}
+
+	// Once released, the minion is handed out again.
+	_, err = fb.ReleaseMinion(quarantined)
+	require.NoError(t, err)
+	assert.Equal(t, 2, fb.Queue.Idle())
+
+	_, err = fb.QuarantineMinion("GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z")
+	assert.ErrorIs(t, err, ErrMinionNotFound)
+}
+
+func TestBot_QuarantineMinion_inUse(t *testing.T) {
+	ctx := context.Background()
+	fb, _ := newTestPoolBot(t, 1, nil)
+	address := fb.Minions[0].Account.AccountID
+
+	minion, release, err := fb.acquireMinion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, address, minion.Account.AccountID)
+
+	status, err := fb.QuarantineMinion(address)
+	require.NoError(t, err)
+	assert.True(t, status.InUse)
+
+	// The minion finishes its payment, but is not returned to the queue.
+	release(minion, nil)
+	assert.Equal(t, 0, fb.Queue.Idle())
+
+	_, err = fb.ReleaseMinion(address)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fb.Queue.Idle())
+}
+
+func TestBot_MinionStatuses(t *testing.T) {
+	ctx := context.Background()
+	fb, used := newTestPoolBot(t, 1, errors.New("tx_failed"))
+
+	_, err := fb.Pay(ctx, "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z")
+	assert.Error(t, err)
+	<-used
+
+	assert.Equal(t, []MinionStatus{{
+		Address:      fb.Minions[0].Account.AccountID,
+		Sequence:     "7",
+		Balance:      "101.0000000",
+		RecentErrors: 1,
+	}}, fb.MinionStatuses(ctx))
+}
+
+func TestBot_RefreshMinionSequence(t *testing.T) {
+	ctx := context.Background()
+	fb, _ := newTestPoolBot(t, 1, nil)
+	var refreshed []bool
+	fb.Minions[0].CheckSequenceRefresh = func(ctx context.Context, minion *Minion, networkClient NetworkClient) error {
+		refreshed = append(refreshed, minion.forceRefreshSequence)
+		return nil
+	}
+
+	_, err := fb.RefreshMinionSequence(fb.Minions[0].Account.AccountID)
+	require.NoError(t, err)
+
+	recipientAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+	for i := 0; i < 2; i++ {
+		_, err = fb.Pay(ctx, recipientAddress)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []bool{true, false}, refreshed)
+}
+
+func TestBot_RefreshMinionSequence_keepsSequence(t *testing.T) {
+	ctx := context.Background()
+	fb, _ := newTestPoolBot(t, 1, nil)
+	fb.Minions[0].NetworkClient = fb.NetworkClient
+	address := fb.Minions[0].Account.AccountID
+	recipientAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+
+	// Each payment uses the next sequence number, without refreshing it.
+	for i := 0; i < 2; i++ {
+		_, err := fb.Pay(ctx, recipientAddress)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(3), fb.Minions[0].Account.Sequence)
+
+	// A forced refresh takes the sequence number from the network.
+	_, err := fb.RefreshMinionSequence(address)
+	require.NoError(t, err)
+	_, err = fb.Pay(ctx, recipientAddress)
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), fb.Minions[0].Account.Sequence)
+}
+
+func TestBot_RefreshMinionSequence_afterFailure(t *testing.T) {
+	ctx := context.Background()
+	fb, _ := newTestPoolBot(t, 1, errors.New("submit failed"))
+	fb.Minions[0].NetworkClient = fb.NetworkClient
+	recipientAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+
+	// A failed payment may or may not have used its sequence number, so the
+	// next payment refreshes it.
+	_, err := fb.Pay(ctx, recipientAddress)
+	require.Error(t, err)
+	assert.Equal(t, int64(1), fb.Minions[0].Account.Sequence)
+	_, err = fb.Pay(ctx, recipientAddress)
+	require.Error(t, err)
+	assert.Equal(t, int64(1), fb.Minions[0].Account.Sequence)
+	assert.True(t, fb.minionStates[0].forceRefresh)
+}
+
+func TestBot_SetPaused(t *testing.T) {
+	ctx := context.Background()
+	fb, _ := newTestPoolBot(t, 1, nil)
+	recipientAddress := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+
+	fb.SetPaused(true)
+	_, err := fb.Pay(ctx, recipientAddress)
+	assert.ErrorIs(t, err, ErrFundingPaused)
+
+	fb.SetPaused(false)
+	_, err = fb.Pay(ctx, recipientAddress)
+	assert.NoError(t, err)
+}
+
+func TestBot_AddMinions(t *testing.T) {
+	ctx := context.Background()
+	fb, _ := newTestPoolBot(t, 1, nil)
+	fb.MinionFactory = &MinionFactory{
+		BotAccount:      fb.Minions[0].BotAccount.(Account),
+		BotSigner:       fb.Minions[0].BotSigner,
+		NetworkClient:   fb.NetworkClient,
+		Network:         "Test SDF Network ; September 2015",
+		StartingBalance: "10000.00",
+		MinionBalance:   "101.00",
+		BatchSize:       2,
+		BaseFee:         txnbuild.MinBaseFee,
+	}
+
+	added, err := fb.AddMinions(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, added)
+	assert.Equal(t, 4, fb.NumMinions())
+	assert.Equal(t, 4, fb.Queue.Idle())
+	assert.Equal(t, 2, fb.NetworkClient.(*poolNetworkClient).submitted)
+}
+
+func TestBot_AddMinions_reusesRetiredSlots(t *testing.T) {
+	ctx := context.Background()
+	fb, _ := newTestPoolBot(t, 4, nil)
+	fb.MinionFactory = &MinionFactory{
+		BotAccount:      fb.Minions[0].BotAccount.(Account),
+		BotSigner:       fb.Minions[0].BotSigner,
+		NetworkClient:   fb.NetworkClient,
+		Network:         "Test SDF Network ; September 2015",
+		StartingBalance: "10000.00",
+		MinionBalance:   "101.00",
+		BatchSize:       50,
+		BaseFee:         txnbuild.MinBaseFee,
+	}
+	retiredAddress := fb.Minions[3].Account.AccountID
+
+	retired, err := fb.RetireMinions(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, retired)
+
+	added, err := fb.AddMinions(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, added)
+	assert.Len(t, fb.Minions, 5)
+	assert.Equal(t, 5, fb.NumMinions())
+	assert.Equal(t, 5, fb.Queue.Idle())
+	_, err = fb.QuarantineMinion(retiredAddress)
+	assert.ErrorIs(t, err, ErrMinionNotFound)
+}
+
+func TestBot_FillMinions(t *testing.T) {
+	fb, _ := newTestPoolBot(t, 1, nil)
+	fb.MinionFactory = &MinionFactory{
+		BotAccount:      fb.Minions[0].BotAccount.(Account),
+		BotSigner:       fb.Minions[0].BotSigner,
+		NetworkClient:   fb.NetworkClient,
+		Network:         "Test SDF Network ; September 2015",
+		StartingBalance: "10000.00",
+		MinionBalance:   "101.00",
+		BatchSize:       2,
+		BaseFee:         txnbuild.MinBaseFee,
+		Concurrency:     2,
+	}
+
+	fb.FillMinions(context.Background(), 6)
+	assert.Equal(t, 6, fb.NumMinions())
+	assert.Equal(t, 6, fb.Queue.Idle())
+}
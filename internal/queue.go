@@ -0,0 +1,294 @@
+package internal
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/stellar/go-stellar-sdk/support/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Meter name for friendbot service
+const meterName = "stellar-friendbot"
+
+// ErrQueueFull is returned when a request arrives while the request queue is
+// already at its maximum depth.
+var ErrQueueFull = errors.New("request queue is full")
+
+// ErrQueueTimeout is returned when a request waited longer than the maximum
+// wait time without a minion becoming available.
+var ErrQueueTimeout = errors.New("timed out waiting for an available minion")
+
+// MinionQueue is a bounded queue that sits between incoming funding requests
+// and the minion pool. Idle minions are handed out immediately, otherwise the
+// request waits in a per-client FIFO. Released minions are handed to waiting
+// clients in round-robin order so a single client cannot monopolize the pool.
+type MinionQueue struct {
+	mu       sync.Mutex
+	maxDepth int
+	maxWait  time.Duration
+	idle     []int
+	waiters  map[string][]*queueWaiter
+	clients  []string
+	next     int
+	depth    int
+
+	// The pressure on the queue since it was last sampled.
+	sampleMaxWait time.Duration
+	sampleMinIdle int
+
+	waitTime metric.Float64Histogram
+	// gauges reports the depth and idle minions gauges until Close.
+	gauges metric.Registration
+}
+
+// QueueSample describes the pressure on the queue since the previous sample.
+type QueueSample struct {
+	// Depth is the number of requests waiting when the sample was taken.
+	Depth int
+	// MaxWait is the longest time a request waited for a minion.
+	MaxWait time.Duration
+	// MinIdle is the fewest idle minions there were at any time.
+	MinIdle int
+}
+
+type queueWaiter struct {
+	minion chan int
+}
+
+// NewMinionQueue returns a queue handing out the minion indexes
+// [0, numMinions), holding at most maxDepth waiting requests for at most
+// maxWait each.
+func NewMinionQueue(numMinions, maxDepth int, maxWait time.Duration) *MinionQueue {
+	q := &MinionQueue{
+		maxDepth: maxDepth,
+		maxWait:  maxWait,
+		idle:     make([]int, 0, numMinions),
+		waiters:  map[string][]*queueWaiter{},
+	}
+	for i := 0; i < numMinions; i++ {
+		q.idle = append(q.idle, i)
+	}
+	q.sampleMinIdle = numMinions
+	q.registerMetrics(otel.Meter(meterName))
+	return q
+}
+
+func (q *MinionQueue) registerMetrics(meter metric.Meter) {
+	waitTime, err := meter.Float64Histogram(
+		"friendbot.queue.wait_time",
+		metric.WithDescription("Time requests spent waiting for an available minion."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.001, 0.01, 0.1, 0.5, 1, 2.5, 5, 10, 30),
+	)
+	if err != nil {
+		log.Printf("Failed to create queue wait time metric: %v", err)
+	}
+	q.waitTime = waitTime
+
+	depth, err := meter.Int64ObservableGauge(
+		"friendbot.queue.depth",
+		metric.WithDescription("Number of requests waiting for an available minion."),
+	)
+	if err != nil {
+		log.Printf("Failed to create queue depth metric: %v", err)
+		return
+	}
+
+	idle, err := meter.Int64ObservableGauge(
+		"friendbot.queue.idle_minions",
+		metric.WithDescription("Number of minions not currently processing a request."),
+	)
+	if err != nil {
+		log.Printf("Failed to create idle minions metric: %v", err)
+		return
+	}
+
+	q.gauges, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(depth, int64(q.Depth()))
+		o.ObserveInt64(idle, int64(q.Idle()))
+		return nil
+	}, depth, idle)
+	if err != nil {
+		log.Printf("Failed to register queue metrics: %v", err)
+	}
+}
+
+// Close stops reporting the queue's gauges, which otherwise keep the queue
+// referenced by the global meter provider.
+func (q *MinionQueue) Close() error {
+	if q.gauges == nil {
+		return nil
+	}
+	return q.gauges.Unregister()
+}
+
+// Acquire returns the index of an idle minion reserved for the caller, waiting
+// for one to be released if necessary. The client key is used to schedule
+// waiting requests fairly. The minion must be returned with Release.
+func (q *MinionQueue) Acquire(ctx context.Context, client string) (int, error) {
+	start := time.Now()
+	defer func() {
+		wait := time.Since(start)
+		q.mu.Lock()
+		q.sampleMaxWait = max(q.sampleMaxWait, wait)
+		q.mu.Unlock()
+		if q.waitTime != nil {
+			q.waitTime.Record(ctx, wait.Seconds())
+		}
+	}()
+
+	q.mu.Lock()
+	if len(q.idle) > 0 && q.depth == 0 {
+		index := q.idle[len(q.idle)-1]
+		q.idle = q.idle[:len(q.idle)-1]
+		q.sampleMinIdle = min(q.sampleMinIdle, len(q.idle))
+		q.mu.Unlock()
+		return index, nil
+	}
+	if q.depth >= q.maxDepth {
+		q.mu.Unlock()
+		return 0, ErrQueueFull
+	}
+	w := &queueWaiter{minion: make(chan int, 1)}
+	if len(q.waiters[client]) == 0 {
+		q.clients = append(q.clients, client)
+	}
+	q.waiters[client] = append(q.waiters[client], w)
+	q.depth++
+	maxWait := q.maxWait
+	q.mu.Unlock()
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+
+	select {
+	case index := <-w.minion:
+		return index, nil
+	case <-timer.C:
+		if index, ok := q.abandon(client, w); ok {
+			return index, nil
+		}
+		return 0, ErrQueueTimeout
+	case <-ctx.Done():
+		if index, ok := q.abandon(client, w); ok {
+			q.Release(index)
+		}
+		return 0, ctx.Err()
+	}
+}
+
+// abandon removes a waiter from the queue. If the waiter was handed a minion
+// before it could be removed, that minion index is returned.
+func (q *MinionQueue) abandon(client string, w *queueWaiter) (int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	waiters := q.waiters[client]
+	for i := range waiters {
+		if waiters[i] == w {
+			q.waiters[client] = append(waiters[:i], waiters[i+1:]...)
+			q.depth--
+			if len(q.waiters[client]) == 0 {
+				q.removeClient(client)
+			}
+			return 0, false
+		}
+	}
+	return <-w.minion, true
+}
+
+// SetLimits changes the maximum number of waiting requests and how long each
+// may wait. Requests already waiting keep their original deadline, and are not
+// rejected if the queue is now deeper than maxDepth.
+func (q *MinionQueue) SetLimits(maxDepth int, maxWait time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.maxDepth = maxDepth
+	q.maxWait = maxWait
+}
+
+// Take removes an idle minion from the pool so that it is no longer handed
+// out, returning false if the minion is not idle.
+func (q *MinionQueue) Take(index int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, idle := range q.idle {
+		if idle == index {
+			q.idle = append(q.idle[:i], q.idle[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Release returns a minion to the pool, or adds a new one, handing it directly
+// to the next waiting client if there is one.
+func (q *MinionQueue) Release(index int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.clients) == 0 {
+		q.idle = append(q.idle, index)
+		return
+	}
+
+	if q.next >= len(q.clients) {
+		q.next = 0
+	}
+	client := q.clients[q.next]
+	waiters := q.waiters[client]
+	w := waiters[0]
+	q.waiters[client] = waiters[1:]
+	q.depth--
+	if len(q.waiters[client]) == 0 {
+		q.removeClient(client)
+	} else {
+		q.next++
+	}
+	w.minion <- index
+}
+
+// removeClient drops a client with no remaining waiters from the round-robin
+// order. The caller must hold q.mu.
+func (q *MinionQueue) removeClient(client string) {
+	delete(q.waiters, client)
+	for i, c := range q.clients {
+		if c == client {
+			q.clients = append(q.clients[:i], q.clients[i+1:]...)
+			if i < q.next {
+				q.next--
+			}
+			return
+		}
+	}
+}
+
+// Sample returns the pressure on the queue since the previous call to Sample.
+func (q *MinionQueue) Sample() QueueSample {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	sample := QueueSample{
+		Depth:   q.depth,
+		MaxWait: q.sampleMaxWait,
+		MinIdle: q.sampleMinIdle,
+	}
+	q.sampleMaxWait = 0
+	q.sampleMinIdle = len(q.idle)
+	return sample
+}
+
+// Depth returns the number of requests currently waiting for a minion.
+func (q *MinionQueue) Depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.depth
+}
+
+// Idle returns the number of minions not currently reserved by a request.
+func (q *MinionQueue) Idle() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.idle)
+}
@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// newTestMeter returns a meter whose metrics are read by the returned reader.
+func newTestMeter() (metric.Meter, *sdkmetric.ManualReader) {
+	reader := sdkmetric.NewManualReader()
+	return sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter(meterName), reader
+}
+
+// reportedMetrics returns the names of the metrics with data points in reader.
+func reportedMetrics(t *testing.T, reader *sdkmetric.ManualReader) []string {
+	var data metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &data))
+	var names []string
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			switch d := m.Data.(type) {
+			case metricdata.Gauge[int64]:
+				if len(d.DataPoints) == 0 {
+					continue
+				}
+			case metricdata.Gauge[float64]:
+				if len(d.DataPoints) == 0 {
+					continue
+				}
+			}
+			names = append(names, m.Name)
+		}
+	}
+	return names
+}
+
+func TestMinionQueue_AcquireIdle(t *testing.T) {
+	ctx := context.Background()
+	q := NewMinionQueue(2, 10, time.Second)
+
+	first, err := q.Acquire(ctx, "client")
+	require.NoError(t, err)
+	second, err := q.Acquire(ctx, "client")
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, 0, q.Idle())
+
+	q.Release(first)
+	q.Release(second)
+	assert.Equal(t, 2, q.Idle())
+}
+
+func TestMinionQueue_Full(t *testing.T) {
+	ctx := context.Background()
+	q := NewMinionQueue(1, 0, time.Second)
+
+	_, err := q.Acquire(ctx, "client")
+	require.NoError(t, err)
+
+	_, err = q.Acquire(ctx, "client")
+	assert.ErrorIs(t, err, ErrQueueFull)
+}
+
+func TestMinionQueue_Timeout(t *testing.T) {
+	ctx := context.Background()
+	q := NewMinionQueue(1, 1, 10*time.Millisecond)
+
+	_, err := q.Acquire(ctx, "client")
+	require.NoError(t, err)
+
+	_, err = q.Acquire(ctx, "client")
+	assert.ErrorIs(t, err, ErrQueueTimeout)
+	assert.Equal(t, 0, q.Depth())
+}
+
+func TestMinionQueue_ContextCanceled(t *testing.T) {
+	q := NewMinionQueue(1, 1, time.Second)
+
+	index, err := q.Acquire(context.Background(), "client")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = q.Acquire(ctx, "client")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, q.Depth())
+
+	q.Release(index)
+	assert.Equal(t, 1, q.Idle())
+}
+
+func TestMinionQueue_FairAcrossClients(t *testing.T) {
+	ctx := context.Background()
+	q := NewMinionQueue(1, 10, time.Second)
+
+	index, err := q.Acquire(ctx, "noisy")
+	require.NoError(t, err)
+
+	served := make(chan string, 4)
+	enqueue := func(client string) {
+		depth := q.Depth()
+		go func() {
+			i, err := q.Acquire(ctx, client)
+			if assert.NoError(t, err) {
+				served <- client
+				q.Release(i)
+			}
+		}()
+		require.Eventually(t, func() bool { return q.Depth() == depth+1 }, time.Second, time.Millisecond)
+	}
+	// The noisy client queues several requests before the quiet client
+	// queues its only one.
+	enqueue("noisy")
+	enqueue("noisy")
+	enqueue("noisy")
+	enqueue("quiet")
+
+	q.Release(index)
+
+	var order []string
+	for i := 0; i < 4; i++ {
+		order = append(order, <-served)
+	}
+	assert.Equal(t, []string{"noisy", "quiet", "noisy", "noisy"}, order)
+}
+
+func TestMinionQueue_Close(t *testing.T) {
+	meter, reader := newTestMeter()
+	q := NewMinionQueue(2, 10, time.Second)
+	require.NoError(t, q.Close())
+	q.registerMetrics(meter)
+	assert.ElementsMatch(t, []string{"friendbot.queue.depth", "friendbot.queue.idle_minions"}, reportedMetrics(t, reader))
+
+	require.NoError(t, q.Close())
+	assert.Empty(t, reportedMetrics(t, reader))
+}
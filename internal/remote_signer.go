@@ -0,0 +1,239 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stellar/go-stellar-sdk/keypair"
+	"github.com/stellar/go-stellar-sdk/support/errors"
+	"github.com/stellar/go-stellar-sdk/txnbuild"
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// RemoteSigner is a Signer that asks a remote signing service to sign
+// transactions, so that the keys it signs with never enter this process.
+//
+// The service is expected to serve two endpoints under URL:
+//
+//	GET  /public_keys  responds with {"public_keys": ["G...", ...]}
+//	POST /sign         accepts {"network_passphrase", "envelope_xdr", "hash"}
+//	                   and responds with {"signatures": ["<base64 DecoratedSignature>", ...]}
+//
+// SigningServer is a reference implementation of the service.
+type RemoteSigner struct {
+	URL string
+	// Token, if set, is sent as a bearer token with every request.
+	Token  string
+	Client *http.Client
+}
+
+// Ensure RemoteSigner implements the Signer interface.
+var _ Signer = (*RemoteSigner)(nil)
+
+// NewRemoteSigner returns a signer using the signing service at url.
+func NewRemoteSigner(url, token string) *RemoteSigner {
+	return &RemoteSigner{
+		URL:    strings.TrimSuffix(url, "/"),
+		Token:  token,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type signRequest struct {
+	NetworkPassphrase string `json:"network_passphrase"`
+	EnvelopeXDR       string `json:"envelope_xdr"`
+	Hash              string `json:"hash"`
+}
+
+type signResponse struct {
+	Signatures []string `json:"signatures"`
+}
+
+type publicKeysResponse struct {
+	PublicKeys []string `json:"public_keys"`
+}
+
+type signingErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// PublicKeys returns the addresses of the keys the signing service signs with.
+func (s *RemoteSigner) PublicKeys(ctx context.Context) ([]string, error) {
+	var resp publicKeysResponse
+	if err := s.do(ctx, http.MethodGet, "/public_keys", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.PublicKeys, nil
+}
+
+// SignTransaction sends tx to the signing service and returns the signatures
+// it responds with.
+func (s *RemoteSigner) SignTransaction(ctx context.Context, network string, tx *txnbuild.Transaction) ([]xdr.DecoratedSignature, error) {
+	hash, err := tx.Hash(network)
+	if err != nil {
+		return nil, errors.Wrap(err, "hashing tx")
+	}
+	envelope, err := tx.Base64()
+	if err != nil {
+		return nil, errors.Wrap(err, "serializing tx")
+	}
+
+	var resp signResponse
+	err = s.do(ctx, http.MethodPost, "/sign", signRequest{
+		NetworkPassphrase: network,
+		EnvelopeXDR:       envelope,
+		Hash:              hex.EncodeToString(hash[:]),
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Signatures) == 0 {
+		return nil, errors.New("remote signer returned no signatures")
+	}
+
+	signatures := make([]xdr.DecoratedSignature, 0, len(resp.Signatures))
+	for _, encoded := range resp.Signatures {
+		var signature xdr.DecoratedSignature
+		if err := xdr.SafeUnmarshalBase64(encoded, &signature); err != nil {
+			return nil, errors.Wrap(err, "parsing remote signature")
+		}
+		signatures = append(signatures, signature)
+	}
+	return signatures, nil
+}
+
+// do sends a request to the signing service, and decodes its response into
+// result.
+func (s *RemoteSigner) do(ctx context.Context, method, path string, body, result any) error {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return errors.Wrap(err, "encoding remote signer request")
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.URL+path, &reqBody)
+	if err != nil {
+		return errors.Wrap(err, "creating remote signer request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "calling remote signer")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp signingErrorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&errResp)
+		return errors.Errorf("remote signer responded with status %d: %s", resp.StatusCode, errResp.Error)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return errors.Wrap(err, "decoding remote signer response")
+	}
+	return nil
+}
+
+// SigningServer is a reference implementation of the signing service used by
+// RemoteSigner. It signs every transaction it receives with all of its
+// keypairs, and is meant for tests and local development rather than for
+// guarding keys in production.
+type SigningServer struct {
+	Keypairs []*keypair.Full
+	// Token, if set, is the bearer token requests must carry.
+	Token string
+}
+
+// NewSigningServer returns a signing service signing with keypairs.
+func NewSigningServer(token string, keypairs ...*keypair.Full) *SigningServer {
+	return &SigningServer{Keypairs: keypairs, Token: token}
+}
+
+// ServeHTTP serves the public_keys and sign endpoints.
+func (s *SigningServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Token != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) != 1 {
+			writeSigningError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/public_keys":
+		resp := publicKeysResponse{PublicKeys: []string{}}
+		for _, kp := range s.Keypairs {
+			resp.PublicKeys = append(resp.PublicKeys, kp.Address())
+		}
+		writeSigningResponse(w, resp)
+	case r.Method == http.MethodPost && r.URL.Path == "/sign":
+		s.sign(w, r)
+	default:
+		writeSigningError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *SigningServer) sign(w http.ResponseWriter, r *http.Request) {
+	var req signRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeSigningError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	genericTx, err := txnbuild.TransactionFromXDR(req.EnvelopeXDR)
+	if err != nil {
+		writeSigningError(w, http.StatusBadRequest, "invalid envelope_xdr")
+		return
+	}
+	tx, ok := genericTx.Transaction()
+	if !ok {
+		writeSigningError(w, http.StatusBadRequest, "fee bump transactions are not supported")
+		return
+	}
+	hash, err := tx.Hash(req.NetworkPassphrase)
+	if err != nil {
+		writeSigningError(w, http.StatusBadRequest, "unable to hash transaction")
+		return
+	}
+	// The hash is checked against the envelope, so that the signer knows what
+	// it is signing.
+	if req.Hash != "" && req.Hash != hex.EncodeToString(hash[:]) {
+		writeSigningError(w, http.StatusBadRequest, "hash does not match envelope_xdr")
+		return
+	}
+
+	signatures, err := signHash(hash, s.Keypairs)
+	if err != nil {
+		writeSigningError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := signResponse{Signatures: make([]string, 0, len(signatures))}
+	for _, signature := range signatures {
+		encoded, err := xdr.MarshalBase64(signature)
+		if err != nil {
+			writeSigningError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp.Signatures = append(resp.Signatures, encoded)
+	}
+	writeSigningResponse(w, resp)
+}
+
+func writeSigningResponse(w http.ResponseWriter, resp any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func writeSigningError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(signingErrorResponse{Error: message})
+}
@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stellar/go-stellar-sdk/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteSigner(t *testing.T) {
+	ctx := context.Background()
+	signer1 := keypair.MustRandom()
+	signer2 := keypair.MustRandom()
+	server := httptest.NewServer(NewSigningServer("secret-token", signer1, signer2))
+	defer server.Close()
+
+	remote := NewRemoteSigner(server.URL+"/", "secret-token")
+
+	publicKeys, err := remote.PublicKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{signer1.Address(), signer2.Address()}, publicKeys)
+
+	tx := newTestSignerTx(t, signer1.Address())
+	tx, err = signTransaction(ctx, tx, testNetwork, remote)
+	require.NoError(t, err)
+
+	// The remote signatures are those the keypairs would have made locally.
+	hash, err := tx.Hash(testNetwork)
+	require.NoError(t, err)
+	signatures := tx.Signatures()
+	require.Len(t, signatures, 2)
+	assert.NoError(t, signer1.Verify(hash[:], signatures[0].Signature))
+	assert.NoError(t, signer2.Verify(hash[:], signatures[1].Signature))
+}
+
+func TestRemoteSigner_invalidToken(t *testing.T) {
+	server := httptest.NewServer(NewSigningServer("secret-token", keypair.MustRandom()))
+	defer server.Close()
+
+	_, err := NewRemoteSigner(server.URL, "wrong-token").PublicKeys(context.Background())
+	assert.ErrorContains(t, err, "remote signer responded with status 401: missing or invalid bearer token")
+}
+
+func TestSigningServer_networkMismatch(t *testing.T) {
+	signer := keypair.MustRandom()
+	server := httptest.NewServer(NewSigningServer("", signer))
+	defer server.Close()
+
+	// A transaction hashed for one network is refused when the envelope is
+	// sent for signing on another.
+	remote := NewRemoteSigner(server.URL, "")
+	tx := newTestSignerTx(t, signer.Address())
+	hash, err := tx.Hash(testNetwork)
+	require.NoError(t, err)
+	envelope, err := tx.Base64()
+	require.NoError(t, err)
+
+	var resp signResponse
+	err = remote.do(context.Background(), "POST", "/sign", signRequest{
+		NetworkPassphrase: "Public Global Stellar Network ; September 2015",
+		EnvelopeXDR:       envelope,
+		Hash:              hex.EncodeToString(hash[:]),
+	}, &resp)
+	assert.ErrorContains(t, err, "hash does not match envelope_xdr")
+}
@@ -129,7 +129,7 @@ func (r *NetworkClient) URL() string {
 
 // SubmitTransaction submits a transaction using the underlying RPC client.
 // It blocks until the transaction is finalized (SUCCESS or FAILED) or times out after 30 seconds.
-func (r *NetworkClient) SubmitTransaction(ctx context.Context, txXDR string) error {
+func (r *NetworkClient) SubmitTransaction(ctx context.Context, txXDR string) (*internal.SubmitTransactionResult, error) {
 	ctx, cancel := context.WithTimeout(ctx, submitTransactionTimeout)
 	defer cancel()
 
@@ -139,32 +139,54 @@ func (r *NetworkClient) SubmitTransaction(ctx context.Context, txXDR string) err
 
 	response, err := r.client.SendTransaction(ctx, request)
 	if err != nil {
-		return &NetworkError{err: err}
+		return nil, &NetworkError{err: err}
 	}
 
 	// If the transaction was rejected immediately, return the error
 	if response.Status == statusError {
-		return &NetworkError{
+		return nil, &NetworkError{
 			err:                 fmt.Errorf("transaction rejected"),
 			resultXDR:           response.ErrorResultXDR,
 			diagnosticEventsXDR: response.DiagnosticEventsXDR,
 		}
 	}
 
-	return r.pollTransactionStatus(ctx, response.Hash)
+	txResponse, err := r.pollTransactionStatus(ctx, response.Hash)
+	if err != nil {
+		return nil, err
+	}
+	return newSubmitTransactionResult(txResponse)
+}
+
+// newSubmitTransactionResult returns the result of the successful transaction
+// in txResponse.
+func newSubmitTransactionResult(txResponse protocol.GetTransactionResponse) (*internal.SubmitTransactionResult, error) {
+	var result xdr.TransactionResult
+	if err := xdr.SafeUnmarshalBase64(txResponse.ResultXDR, &result); err != nil {
+		return nil, &NetworkError{err: fmt.Errorf("parsing result of transaction %s: %w", txResponse.TransactionHash, err)}
+	}
+	return &internal.SubmitTransactionResult{
+		Ledger:          txResponse.Ledger,
+		LedgerCloseTime: time.Unix(txResponse.LedgerCloseTime, 0).UTC(),
+		ResultXDR:       txResponse.ResultXDR,
+		FeeCharged:      int64(result.FeeCharged),
+	}, nil
 }
 
 // pollTransactionStatus polls GetTransaction until the transaction is finalized
-// (SUCCESS or FAILED) or the context times out.
-func (r *NetworkClient) pollTransactionStatus(ctx context.Context, txHash string) error {
+// (SUCCESS or FAILED) or the context times out, and returns the response for
+// the successful transaction.
+func (r *NetworkClient) pollTransactionStatus(ctx context.Context, txHash string) (protocol.GetTransactionResponse, error) {
 	b := backoff.NewExponentialBackOff()
 	b.InitialInterval = backoffInitialInterval
 	b.MaxInterval = backoffMaxInterval
 	// Note: MaxElapsedTime is not set here because the context timeout
 	// (submitTransactionTimeout) already handles the overall timeout.
 
+	var txResponse protocol.GetTransactionResponse
 	err := backoff.Retry(func() error {
-		txResponse, err := r.client.GetTransaction(ctx, protocol.GetTransactionRequest{
+		var err error
+		txResponse, err = r.client.GetTransaction(ctx, protocol.GetTransactionRequest{
 			Hash: txHash,
 		})
 		if err != nil {
@@ -192,19 +214,19 @@ func (r *NetworkClient) pollTransactionStatus(ctx context.Context, txHash string
 		// Check if it's already a NetworkError (from Permanent)
 		var netErr *NetworkError
 		if errors.As(err, &netErr) {
-			return netErr
+			return txResponse, netErr
 		}
 		// Context timeout/cancellation
 		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
-			return &NetworkError{
+			return txResponse, &NetworkError{
 				err:     fmt.Errorf("timeout waiting for transaction %s to finalize", txHash),
 				timeout: true,
 			}
 		}
 		// Unexpected error
-		return &NetworkError{err: err}
+		return txResponse, &NetworkError{err: err}
 	}
-	return nil
+	return txResponse, nil
 }
 
 // SimulateTransaction simulates a transaction using the underlying RPC client.
@@ -296,9 +318,20 @@ func (r *NetworkClient) getAccountDetails(ctx context.Context, accountID string)
 	// Convert balance from stroops (int64) to XLM string format
 	balance := amount.StringFromInt64(int64(entry.Account.Balance))
 
+	signers := map[string]int32{accountID: int32(entry.Account.MasterKeyWeight())}
+	for _, signer := range entry.Account.Signers {
+		address, err := signer.Key.GetAddress()
+		if err != nil {
+			return nil, &NetworkError{err: err}
+		}
+		signers[address] = int32(signer.Weight)
+	}
+
 	return &internal.AccountDetails{
-		Sequence: int64(entry.Account.SeqNum),
-		Balance:  balance,
+		Sequence:        int64(entry.Account.SeqNum),
+		Balance:         balance,
+		Signers:         signers,
+		MediumThreshold: int32(entry.Account.ThresholdMedium()),
 	}, nil
 }
 
@@ -412,3 +445,18 @@ func (r *NetworkClient) getContractDetails(ctx context.Context, contractAddress
 func (r *NetworkClient) SupportsContractAddresses() bool {
 	return true
 }
+
+// NetworkInfo returns the network passphrase and current protocol version
+// reported by the RPC getNetwork method. The native SAC ID is derived from the
+// passphrase the client was created with, so callers should check that it
+// matches the one returned here.
+func (r *NetworkClient) NetworkInfo(ctx context.Context) (*internal.NetworkInfo, error) {
+	response, err := r.client.GetNetwork(ctx)
+	if err != nil {
+		return nil, &NetworkError{err: err}
+	}
+	return &internal.NetworkInfo{
+		Passphrase:      response.Passphrase,
+		ProtocolVersion: uint32(response.ProtocolVersion),
+	}, nil
+}
@@ -8,7 +8,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/stellar/friendbot/internal"
 	"github.com/stellar/go-stellar-sdk/keypair"
 	"github.com/stellar/go-stellar-sdk/xdr"
 	"github.com/stretchr/testify/assert"
@@ -143,6 +145,45 @@ func TestNetworkClient_GetAccountDetails_Success(t *testing.T) {
 	assert.Equal(t, "100.0000000", details.Balance)
 }
 
+func TestNetworkClient_GetAccountDetails_Signers(t *testing.T) {
+	testAccountID := keypair.MustRandom().Address()
+	signerID := keypair.MustRandom().Address()
+
+	accountIDObj, err := xdr.AddressToAccountId(testAccountID)
+	require.NoError(t, err)
+	entry := xdr.LedgerEntryData{
+		Type: xdr.LedgerEntryTypeAccount,
+		Account: &xdr.AccountEntry{
+			AccountId:  accountIDObj,
+			Balance:    100_0000000,
+			SeqNum:     1,
+			Thresholds: xdr.Thresholds{1, 0, 2, 3},
+			Signers: []xdr.Signer{
+				{Key: xdr.MustSigner(signerID), Weight: 1},
+			},
+		},
+	}
+	entryXDR, err := xdr.MarshalBase64(entry)
+	require.NoError(t, err)
+
+	server := newMockRPCServer(t, func(method string, params json.RawMessage) (any, error) {
+		return map[string]any{
+			"entries": []map[string]any{
+				{"xdr": entryXDR, "lastModifiedLedgerSeq": 1000},
+			},
+			"latestLedger": 1001,
+		}, nil
+	})
+	defer server.Close()
+
+	client := NewNetworkClient(server.URL, nil, testNetworkPassphrase)
+	details, err := client.GetAccountDetails(context.Background(), testAccountID)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int32{testAccountID: 1, signerID: 1}, details.Signers)
+	assert.Equal(t, int32(2), details.MediumThreshold)
+}
+
 func TestNetworkClient_GetAccountDetails_NotFound(t *testing.T) {
 	testAccountKP := keypair.MustRandom()
 	testAccountID := testAccountKP.Address()
@@ -185,6 +226,8 @@ func TestNetworkClient_GetAccountDetails_InvalidAccountID(t *testing.T) {
 func TestNetworkClient_SubmitTransaction_Success(t *testing.T) {
 	testTxXDR := "AAAAAgAAAA..."
 	testTxHash := "abc123def456789012345678901234567890123456789012345678901234abcd"
+	// This is a base64-encoded successful TransactionResult charging 100 stroops
+	testSuccessResultXDR := "AAAAAAAAAGQAAAAAAAAAAAAAAAA="
 
 	server := newMockRPCServer(t, func(method string, params json.RawMessage) (any, error) {
 		switch method {
@@ -198,6 +241,9 @@ func TestNetworkClient_SubmitTransaction_Success(t *testing.T) {
 			return map[string]any{
 				"status":       "SUCCESS",
 				"latestLedger": 1001,
+				"ledger":       1001,
+				"createdAt":    "1735787045",
+				"resultXdr":    testSuccessResultXDR,
 			}, nil
 		default:
 			t.Fatalf("unexpected method: %s", method)
@@ -207,9 +253,15 @@ func TestNetworkClient_SubmitTransaction_Success(t *testing.T) {
 	defer server.Close()
 
 	client := NewNetworkClient(server.URL, nil, testNetworkPassphrase)
-	err := client.SubmitTransaction(context.Background(), testTxXDR)
+	result, err := client.SubmitTransaction(context.Background(), testTxXDR)
 
-	assert.NoError(t, err)
+	require.NoError(t, err)
+	assert.Equal(t, &internal.SubmitTransactionResult{
+		Ledger:          1001,
+		LedgerCloseTime: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		ResultXDR:       testSuccessResultXDR,
+		FeeCharged:      100,
+	}, result)
 }
 
 func TestNetworkClient_SubmitTransaction_Rejected(t *testing.T) {
@@ -229,7 +281,7 @@ func TestNetworkClient_SubmitTransaction_Rejected(t *testing.T) {
 	defer server.Close()
 
 	client := NewNetworkClient(server.URL, nil, testNetworkPassphrase)
-	err := client.SubmitTransaction(context.Background(), testTxXDR)
+	_, err := client.SubmitTransaction(context.Background(), testTxXDR)
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "transaction rejected")
@@ -269,7 +321,7 @@ func TestNetworkClient_SubmitTransaction_Failed(t *testing.T) {
 	defer server.Close()
 
 	client := NewNetworkClient(server.URL, nil, testNetworkPassphrase)
-	err := client.SubmitTransaction(context.Background(), testTxXDR)
+	_, err := client.SubmitTransaction(context.Background(), testTxXDR)
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "transaction failed")
@@ -281,6 +333,23 @@ func TestNetworkClient_SubmitTransaction_Failed(t *testing.T) {
 	assert.Equal(t, testFailedResultXDR, resultXDR)
 }
 
+func TestNetworkClient_NetworkInfo(t *testing.T) {
+	server := newMockRPCServer(t, func(method string, params json.RawMessage) (any, error) {
+		require.Equal(t, "getNetwork", method)
+		return map[string]any{
+			"passphrase":      testNetworkPassphrase,
+			"protocolVersion": 22,
+		}, nil
+	})
+	defer server.Close()
+
+	client := NewNetworkClient(server.URL, nil, testNetworkPassphrase)
+	info, err := client.NetworkInfo(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, &internal.NetworkInfo{Passphrase: testNetworkPassphrase, ProtocolVersion: 22}, info)
+}
+
 // newMockRPCServer creates a test HTTP server that handles JSON-RPC 2.0 requests.
 // The handler function receives the method name and params and returns the result or error.
 func newMockRPCServer(t *testing.T, handler func(method string, params json.RawMessage) (any, error)) *httptest.Server {
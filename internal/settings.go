@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/stellar/go-stellar-sdk/amount"
+	"github.com/stellar/go-stellar-sdk/support/errors"
+	"github.com/stellar/go-stellar-sdk/txnbuild"
+)
+
+// Settings are the settings of a running Bot that can be changed without
+// restarting friendbot or re-creating its minions.
+type Settings struct {
+	// StartingBalance is the amount new accounts are funded with.
+	StartingBalance string
+	// BaseFee is the base fee of funding transactions, in stroops.
+	BaseFee               int64
+	FundContractAddresses bool
+	// HourlyBudget and DailyBudget cap the amount disbursed in stroops. A
+	// cap of 0 leaves that window unlimited.
+	HourlyBudget int64
+	DailyBudget  int64
+	// QueueMaxDepth and QueueMaxWait limit the requests waiting for a
+	// minion.
+	QueueMaxDepth int
+	QueueMaxWait  time.Duration
+}
+
+// Validate checks that the settings are well formed.
+func (s Settings) Validate() error {
+	if stroops, err := amount.ParseInt64(s.StartingBalance); err != nil || stroops <= 0 {
+		return errors.Errorf("invalid starting balance %q: must be a positive amount of XLM", s.StartingBalance)
+	}
+	if s.BaseFee < txnbuild.MinBaseFee {
+		return errors.Errorf("invalid base fee %d: must be at least %d stroops", s.BaseFee, txnbuild.MinBaseFee)
+	}
+	if s.HourlyBudget < 0 || s.DailyBudget < 0 {
+		return errors.New("budget caps must not be negative")
+	}
+	if s.QueueMaxDepth < 0 || s.QueueMaxWait < 0 {
+		return errors.New("queue limits must not be negative")
+	}
+	return nil
+}
+
+// Reconfigure validates settings and applies them to the bot and every one of
+// its minions at once, so that each payment made afterwards uses the new
+// settings throughout. Payments already in progress complete with the
+// previous settings.
+func (bot *Bot) Reconfigure(settings Settings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	if settings.FundContractAddresses && !bot.NetworkClient.SupportsContractAddresses() {
+		return errors.New("funding contract addresses is not supported by the network client")
+	}
+	if settings.FundContractAddresses && bot.ProtocolVersion != 0 && bot.ProtocolVersion < MinContractProtocolVersion {
+		return errors.Errorf("funding contract addresses requires protocol version %d, but the network is at protocol version %d",
+			MinContractProtocolVersion, bot.ProtocolVersion)
+	}
+
+	bot.indexMux.Lock()
+	defer bot.indexMux.Unlock()
+	for i := range bot.Minions {
+		bot.Minions[i].StartingBalance = settings.StartingBalance
+		bot.Minions[i].BaseFee = settings.BaseFee
+	}
+	if bot.MinionFactory != nil {
+		bot.MinionFactory.SetFunding(settings.StartingBalance, settings.BaseFee)
+	}
+	bot.FundContractAddresses = settings.FundContractAddresses
+	if bot.Budget != nil {
+		bot.Budget.SetCaps(settings.HourlyBudget, settings.DailyBudget)
+	}
+	if bot.Queue != nil {
+		bot.Queue.SetLimits(settings.QueueMaxDepth, settings.QueueMaxWait)
+	}
+	return nil
+}
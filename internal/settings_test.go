@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBot_Reconfigure(t *testing.T) {
+	fb, _ := newTestPoolBot(t, 2, nil)
+	fb.Budget = NewBudgetTracker(0, 0)
+	fb.MinionFactory = &MinionFactory{StartingBalance: "10000.00", BaseFee: 100}
+
+	err := fb.Reconfigure(Settings{
+		StartingBalance: "5000.00",
+		BaseFee:         200,
+		DailyBudget:     1000000000000,
+		QueueMaxDepth:   0,
+		QueueMaxWait:    time.Second,
+	})
+	require.NoError(t, err)
+
+	for _, minion := range fb.Minions {
+		assert.Equal(t, "5000.00", minion.StartingBalance)
+		assert.Equal(t, int64(200), minion.BaseFee)
+	}
+	startingBalance, baseFee := fb.MinionFactory.funding()
+	assert.Equal(t, "5000.00", startingBalance)
+	assert.Equal(t, int64(200), baseFee)
+	assert.Equal(t, "100000.0000000", fb.Budget.Status().Daily.Cap)
+
+	// A queue depth of 0 rejects requests whenever every minion is busy.
+	_, err = fb.Queue.Acquire(t.Context(), "client")
+	require.NoError(t, err)
+	_, err = fb.Queue.Acquire(t.Context(), "client")
+	require.NoError(t, err)
+	_, err = fb.Queue.Acquire(t.Context(), "client")
+	assert.ErrorIs(t, err, ErrQueueFull)
+}
+
+func TestBot_Reconfigure_invalid(t *testing.T) {
+	fb, _ := newTestPoolBot(t, 1, nil)
+	valid := Settings{StartingBalance: "5000.00", BaseFee: 100}
+
+	for _, tc := range []struct {
+		name     string
+		settings func(s *Settings)
+		err      string
+	}{
+		{"starting balance", func(s *Settings) { s.StartingBalance = "lots" }, `invalid starting balance "lots"`},
+		{"base fee", func(s *Settings) { s.BaseFee = 10 }, "invalid base fee 10"},
+		{"contract addresses", func(s *Settings) { s.FundContractAddresses = true }, "not supported by the network client"},
+		{"budget", func(s *Settings) { s.HourlyBudget = -1 }, "must not be negative"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			settings := valid
+			tc.settings(&settings)
+			assert.ErrorContains(t, fb.Reconfigure(settings), tc.err)
+			// Nothing is applied when the settings are invalid.
+			assert.Equal(t, "10000.00", fb.Minions[0].StartingBalance)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/stellar/go-stellar-sdk/keypair"
+	"github.com/stellar/go-stellar-sdk/support/errors"
+	"github.com/stellar/go-stellar-sdk/txnbuild"
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// Signer signs transactions for an account, without necessarily holding the
+// account's keys in process memory.
+type Signer interface {
+	// PublicKeys returns the addresses of the keys the signer signs with.
+	PublicKeys(ctx context.Context) ([]string, error)
+	// SignTransaction returns the signatures of tx on network.
+	SignTransaction(ctx context.Context, network string, tx *txnbuild.Transaction) ([]xdr.DecoratedSignature, error)
+}
+
+// KeypairSigner is a Signer holding its keypairs in process memory.
+type KeypairSigner struct {
+	Keypairs []*keypair.Full
+}
+
+// Ensure KeypairSigner implements the Signer interface.
+var _ Signer = (*KeypairSigner)(nil)
+
+// NewKeypairSigner returns a signer signing with keypairs.
+func NewKeypairSigner(keypairs ...*keypair.Full) *KeypairSigner {
+	return &KeypairSigner{Keypairs: keypairs}
+}
+
+// PublicKeys returns the addresses of the signer's keypairs.
+func (s *KeypairSigner) PublicKeys(ctx context.Context) ([]string, error) {
+	addresses := make([]string, 0, len(s.Keypairs))
+	for _, kp := range s.Keypairs {
+		addresses = append(addresses, kp.Address())
+	}
+	return addresses, nil
+}
+
+// SignTransaction returns the signatures of tx on network by each of the
+// signer's keypairs.
+func (s *KeypairSigner) SignTransaction(ctx context.Context, network string, tx *txnbuild.Transaction) ([]xdr.DecoratedSignature, error) {
+	hash, err := tx.Hash(network)
+	if err != nil {
+		return nil, errors.Wrap(err, "hashing tx")
+	}
+	return signHash(hash, s.Keypairs)
+}
+
+// signHash returns the signatures of a transaction hash by each of keypairs.
+func signHash(hash [32]byte, keypairs []*keypair.Full) ([]xdr.DecoratedSignature, error) {
+	signatures := make([]xdr.DecoratedSignature, 0, len(keypairs))
+	for _, kp := range keypairs {
+		signature, err := kp.SignDecorated(hash[:])
+		if err != nil {
+			return nil, errors.Wrapf(err, "signing with %s", kp.Address())
+		}
+		signatures = append(signatures, signature)
+	}
+	return signatures, nil
+}
+
+// signTransaction signs tx on network with keypairs, which are held locally,
+// and then with signer.
+func signTransaction(ctx context.Context, tx *txnbuild.Transaction, network string, signer Signer, keypairs ...*keypair.Full) (*txnbuild.Transaction, error) {
+	tx, err := tx.Sign(network, keypairs...)
+	if err != nil {
+		return nil, err
+	}
+	signatures, err := signer.SignTransaction(ctx, network, tx)
+	if err != nil {
+		return nil, err
+	}
+	return tx.AddSignatureDecorated(signatures...)
+}
@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stellar/go-stellar-sdk/keypair"
+	"github.com/stellar/go-stellar-sdk/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testNetwork = "Test SDF Network ; September 2015"
+
+func newTestSignerTx(t *testing.T, sourceAccountID string) *txnbuild.Transaction {
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount:        &txnbuild.SimpleAccount{AccountID: sourceAccountID, Sequence: 1},
+		IncrementSequenceNum: true,
+		Operations: []txnbuild.Operation{&txnbuild.BumpSequence{
+			BumpTo: 2,
+		}},
+		BaseFee:       txnbuild.MinBaseFee,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewInfiniteTimeout()},
+	})
+	require.NoError(t, err)
+	return tx
+}
+
+func TestKeypairSigner(t *testing.T) {
+	ctx := context.Background()
+	minionKeypair := keypair.MustRandom()
+	botKeypair := keypair.MustRandom()
+	signer := NewKeypairSigner(botKeypair)
+
+	publicKeys, err := signer.PublicKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{botKeypair.Address()}, publicKeys)
+
+	tx, err := signTransaction(ctx, newTestSignerTx(t, minionKeypair.Address()), testNetwork, signer, minionKeypair)
+	require.NoError(t, err)
+	hash, err := tx.Hash(testNetwork)
+	require.NoError(t, err)
+	signatures := tx.Signatures()
+	require.Len(t, signatures, 2)
+	assert.NoError(t, minionKeypair.Verify(hash[:], signatures[0].Signature))
+	assert.NoError(t, botKeypair.Verify(hash[:], signatures[1].Signature))
+}
@@ -1,10 +1,11 @@
 package testutil
 
 import (
-	"fmt"
-	"net/http"
+	"context"
 	"os"
 	"testing"
+
+	"github.com/stellar/friendbot/client"
 )
 
 // FundAccount uses the friendbot endpoint to fund an account
@@ -16,19 +17,6 @@ func FundAccount(t *testing.T, address string) error {
 		t.Skip("FRIENDBOT_URL environment variable not set, skipping test")
 	}
 
-	url := fmt.Sprintf("%s?addr=%s", friendbotURL, address)
-
-	// #nosec G107 - the url is from a trusted source configured in CI or local
-	//nolint:noctx
-	resp, err := http.Get(url)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("friendbot returned status %d", resp.StatusCode)
-	}
-
-	return nil
+	_, err := client.New(friendbotURL).Fund(context.Background(), address)
+	return err
 }
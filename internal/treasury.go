@@ -0,0 +1,264 @@
+package internal
+
+import (
+	"context"
+	stderrors "errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/stellar/go-stellar-sdk/amount"
+	"github.com/stellar/go-stellar-sdk/support/errors"
+	"github.com/stellar/go-stellar-sdk/txnbuild"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Treasury tops up the bot account from a separate treasury account whenever
+// the bot account's balance drops below a watermark.
+type Treasury struct {
+	Account       Account
+	Signer        Signer
+	BotAccountID  string
+	NetworkClient NetworkClient
+	Network       string
+	BaseFee       int64
+	// Watermark is the bot balance, in stroops, below which a refill is made.
+	Watermark int64
+	// Target is the bot balance, in stroops, that a refill tops up to.
+	Target int64
+	// MaxDailyRefill caps the total amount, in stroops, sent to the bot
+	// account within any rolling 24 hours.
+	MaxDailyRefill int64
+	// History, if set, persists the refills counted against the daily
+	// maximum, so that restarting friendbot does not reset it.
+	History HistoryStore
+
+	mu      sync.Mutex
+	refills []budgetSpend
+	// now returns the current time, and is replaced in tests.
+	now func() time.Time
+
+	refillCount  metric.Int64Counter
+	refillAmount metric.Float64Counter
+}
+
+// NewTreasury returns a treasury refilling botAccountID from treasuryAccountID,
+// whose transactions are signed by signer.
+func NewTreasury(treasuryAccountID string, signer Signer, botAccountID string, networkClient NetworkClient, network string, baseFee, watermark, target, maxDailyRefill int64) *Treasury {
+	t := &Treasury{
+		Account:        Account{AccountID: treasuryAccountID},
+		Signer:         signer,
+		BotAccountID:   botAccountID,
+		NetworkClient:  networkClient,
+		Network:        network,
+		BaseFee:        baseFee,
+		Watermark:      watermark,
+		Target:         target,
+		MaxDailyRefill: maxDailyRefill,
+		now:            time.Now,
+	}
+	t.registerMetrics()
+	return t
+}
+
+func (t *Treasury) registerMetrics() {
+	meter := otel.Meter(meterName)
+
+	refillCount, err := meter.Int64Counter(
+		"friendbot.treasury.refills",
+		metric.WithDescription("Number of attempts to refill the bot account from the treasury."),
+	)
+	if err != nil {
+		log.Printf("Failed to create treasury refills metric: %v", err)
+	}
+	t.refillCount = refillCount
+
+	refillAmount, err := meter.Float64Counter(
+		"friendbot.treasury.refilled",
+		metric.WithDescription("Amount of XLM sent from the treasury to the bot account."),
+	)
+	if err != nil {
+		log.Printf("Failed to create treasury refilled metric: %v", err)
+	}
+	t.refillAmount = refillAmount
+}
+
+// Restore counts the refills recorded in t.History within the last 24 hours
+// against the daily maximum.
+func (t *Treasury) Restore(ctx context.Context) error {
+	if t.History == nil {
+		return nil
+	}
+	t.mu.Lock()
+	since := t.now().Add(-24 * time.Hour)
+	t.mu.Unlock()
+	records, err := t.History.RefillsSince(ctx, since)
+	if err != nil {
+		return errors.Wrap(err, "loading treasury refills")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	refills := make([]budgetSpend, 0, len(records)+len(t.refills))
+	for _, record := range records {
+		refills = append(refills, budgetSpend{at: record.CreatedAt, amount: record.Amount})
+	}
+	t.refills = append(refills, t.refills...)
+	return nil
+}
+
+// Run checks the bot balance every interval, refilling it when needed, until
+// ctx is done.
+func (t *Treasury) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := t.Check(ctx); err != nil {
+			log.Printf("Treasury refill check failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check refills the bot account if its balance is below the watermark, and
+// returns the amount sent in stroops. The refill is limited to what remains of
+// the daily maximum.
+func (t *Treasury) Check(ctx context.Context) (int64, error) {
+	details, err := t.NetworkClient.GetAccountDetails(ctx, t.BotAccountID)
+	if err != nil {
+		return 0, errors.Wrap(err, "getting bot account details")
+	}
+	balance, err := amount.ParseInt64(details.Balance)
+	if err != nil {
+		return 0, errors.Wrap(err, "parsing bot account balance")
+	}
+	if balance >= t.Watermark {
+		return 0, nil
+	}
+
+	refill := t.Target - balance
+	if remaining := t.remainingDailyRefill(); refill > remaining {
+		refill = remaining
+	}
+	if refill <= 0 {
+		log.Printf("Bot account balance %s XLM is below the refill watermark, but the daily refill maximum of %s XLM has been reached",
+			details.Balance, amount.StringFromInt64(t.MaxDailyRefill))
+		return 0, nil
+	}
+
+	if submitted, err := t.refill(ctx, refill); err != nil {
+		if submitted && !refillRejected(err) {
+			// The refill may still have been applied, so it counts against
+			// the daily maximum to make sure that is never exceeded.
+			t.countRefill(ctx, refill)
+			log.Printf("Refill of %s XLM from treasury %s may have been applied: %v",
+				amount.StringFromInt64(refill), t.Account.AccountID, err)
+		}
+		t.recordRefill(ctx, 0, false)
+		return 0, errors.Wrap(err, "refilling bot account")
+	}
+	t.recordRefill(ctx, refill, true)
+	log.Printf("Refilled bot account %s with %s XLM from treasury %s (balance was %s XLM)",
+		t.BotAccountID, amount.StringFromInt64(refill), t.Account.AccountID, details.Balance)
+	return refill, nil
+}
+
+// refill sends stroops from the treasury account to the bot account, and
+// returns whether the payment was submitted to the network.
+func (t *Treasury) refill(ctx context.Context, stroops int64) (bool, error) {
+	if err := t.Account.RefreshSequenceNumber(ctx, t.NetworkClient); err != nil {
+		return false, errors.Wrap(err, "refreshing treasury seqnum")
+	}
+	tx, err := txnbuild.NewTransaction(
+		txnbuild.TransactionParams{
+			SourceAccount:        t.Account,
+			IncrementSequenceNum: true,
+			Operations: []txnbuild.Operation{&txnbuild.Payment{
+				Destination: t.BotAccountID,
+				Asset:       txnbuild.NativeAsset{},
+				Amount:      amount.StringFromInt64(stroops),
+			}},
+			BaseFee:       t.BaseFee,
+			Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+		},
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to build tx")
+	}
+	tx, err = signTransaction(ctx, tx, t.Network, t.Signer)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to sign tx")
+	}
+	txe, err := tx.Base64()
+	if err != nil {
+		return false, errors.Wrap(err, "unable to serialize tx")
+	}
+	if _, err := t.NetworkClient.SubmitTransaction(ctx, txe); err != nil {
+		return true, errors.Wrap(err, "submitting refill tx")
+	}
+	return true, nil
+}
+
+// refillRejected returns true if err, returned by submitting a refill, shows
+// that the network rejected it. Any other failure, such as a timeout, leaves
+// it unknown whether the refill was applied.
+func refillRejected(err error) bool {
+	var networkErr NetworkError
+	if stderrors.As(err, &networkErr) && !networkErr.IsTimeout() {
+		_, resErr := networkErr.ResultString()
+		return resErr == nil
+	}
+	return false
+}
+
+// remainingDailyRefill returns how much more may be refilled within the
+// current rolling 24 hours.
+func (t *Treasury) remainingDailyRefill() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	i := 0
+	for i < len(t.refills) && now.Sub(t.refills[i].at) >= 24*time.Hour {
+		i++
+	}
+	t.refills = t.refills[i:]
+
+	remaining := t.MaxDailyRefill
+	for _, r := range t.refills {
+		remaining -= r.amount
+	}
+	return remaining
+}
+
+// countRefill counts stroops against the daily maximum, recording the refill
+// in the history if there is one.
+func (t *Treasury) countRefill(ctx context.Context, stroops int64) {
+	t.mu.Lock()
+	record := RefillRecord{CreatedAt: t.now(), Amount: stroops}
+	t.refills = append(t.refills, budgetSpend{at: record.CreatedAt, amount: stroops})
+	t.mu.Unlock()
+
+	if t.History != nil {
+		if err := t.History.RecordRefill(ctx, record); err != nil {
+			log.Printf("Failed to record treasury refill of %s XLM: %v", amount.StringFromInt64(stroops), err)
+		}
+	}
+}
+
+func (t *Treasury) recordRefill(ctx context.Context, stroops int64, successful bool) {
+	if successful {
+		t.countRefill(ctx, stroops)
+	}
+	if t.refillCount != nil {
+		t.refillCount.Add(ctx, 1, metric.WithAttributes(attribute.Bool("successful", successful)))
+	}
+	if t.refillAmount != nil && successful {
+		t.refillAmount.Add(ctx, float64(stroops)/amount.One)
+	}
+}
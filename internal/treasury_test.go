@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stellar/go-stellar-sdk/amount"
+	"github.com/stellar/go-stellar-sdk/keypair"
+	"github.com/stellar/go-stellar-sdk/txnbuild"
+	"github.com/stellar/go-stellar-sdk/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// treasuryNetworkClient is a NetworkClient holding the bot account's balance,
+// which submitted refill payments are added to.
+type treasuryNetworkClient struct {
+	botAccountID string
+	botBalance   int64
+	submitted    []string
+	// submitErr, if set, is returned for submitted payments, which are only
+	// applied if it is a timeout.
+	submitErr error
+}
+
+func (c *treasuryNetworkClient) SubmitTransaction(ctx context.Context, txXDR string) (*SubmitTransactionResult, error) {
+	c.submitted = append(c.submitted, txXDR)
+	if c.submitErr != nil && !c.submitErr.(NetworkError).IsTimeout() {
+		return nil, c.submitErr
+	}
+	var envelope xdr.TransactionEnvelope
+	if err := xdr.SafeUnmarshalBase64(txXDR, &envelope); err != nil {
+		return nil, err
+	}
+	for _, op := range envelope.Operations() {
+		c.botBalance += int64(op.Body.MustPaymentOp().Amount)
+	}
+	if c.submitErr != nil {
+		return nil, c.submitErr
+	}
+	return &SubmitTransactionResult{}, nil
+}
+
+func (c *treasuryNetworkClient) GetAccountDetails(ctx context.Context, accountID string) (*AccountDetails, error) {
+	if accountID == c.botAccountID {
+		return &AccountDetails{Sequence: 1, Balance: amount.StringFromInt64(c.botBalance)}, nil
+	}
+	return &AccountDetails{Sequence: 1, Balance: "1000000.0000000"}, nil
+}
+
+func (c *treasuryNetworkClient) SimulateTransaction(ctx context.Context, txXDR string) (*SimulateTransactionResult, error) {
+	return nil, nil
+}
+
+func (c *treasuryNetworkClient) SupportsContractAddresses() bool {
+	return false
+}
+
+func (c *treasuryNetworkClient) NetworkInfo(ctx context.Context) (*NetworkInfo, error) {
+	return &NetworkInfo{Passphrase: "Test SDF Network ; September 2015", ProtocolVersion: 22}, nil
+}
+
+func newTestTreasury(t *testing.T, botBalance int64) (*Treasury, *treasuryNetworkClient) {
+	treasuryKeypair, err := keypair.Random()
+	require.NoError(t, err)
+	botAccountID := "GDJIN6W6PLTPKLLM57UW65ZH4BITUXUMYQHIMAZFYXF45PZVAWDBI77Z"
+	networkClient := &treasuryNetworkClient{botAccountID: botAccountID, botBalance: botBalance}
+	treasury := NewTreasury(treasuryKeypair.Address(), NewKeypairSigner(treasuryKeypair), botAccountID, networkClient, "Test SDF Network ; September 2015",
+		txnbuild.MinBaseFee, 100*amount.One, 1000*amount.One, 1500*amount.One)
+	return treasury, networkClient
+}
+
+func TestTreasury_Check_aboveWatermark(t *testing.T) {
+	treasury, networkClient := newTestTreasury(t, 100*amount.One)
+
+	refilled, err := treasury.Check(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, refilled)
+	assert.Empty(t, networkClient.submitted)
+}
+
+func TestTreasury_Check_refillsToTarget(t *testing.T) {
+	treasury, networkClient := newTestTreasury(t, 50*amount.One)
+
+	refilled, err := treasury.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(950*amount.One), refilled)
+	assert.Len(t, networkClient.submitted, 1)
+	assert.Equal(t, int64(1000*amount.One), networkClient.botBalance)
+}
+
+func TestTreasury_Check_maxDailyRefill(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	treasury, networkClient := newTestTreasury(t, 0)
+	treasury.now = func() time.Time { return now }
+
+	refilled, err := treasury.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000*amount.One), refilled)
+
+	// Only what remains of the daily maximum is sent.
+	networkClient.botBalance = 0
+	refilled, err = treasury.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(500*amount.One), refilled)
+
+	networkClient.botBalance = 0
+	refilled, err = treasury.Check(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, refilled)
+	assert.Len(t, networkClient.submitted, 2)
+
+	now = now.Add(24 * time.Hour)
+	refilled, err = treasury.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000*amount.One), refilled)
+}
+
+func TestTreasury_Check_countsTimedOutRefills(t *testing.T) {
+	treasury, networkClient := newTestTreasury(t, 0)
+
+	// A rejected refill does not count against the daily maximum.
+	networkClient.submitErr = submitError{resultXDR: "AAAAAAAAAGT////7AAAAAA=="}
+	_, err := treasury.Check(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, int64(1500*amount.One), treasury.remainingDailyRefill())
+
+	// A refill that timed out may have been applied, so it does.
+	networkClient.submitErr = submitError{timeout: true}
+	_, err = treasury.Check(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, int64(500*amount.One), treasury.remainingDailyRefill())
+
+	networkClient.submitErr = nil
+	networkClient.botBalance = 0
+	refilled, err := treasury.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(500*amount.One), refilled)
+}
+
+// refillHistory is a HistoryStore holding only treasury refills.
+type refillHistory struct {
+	HistoryStore
+	refills []RefillRecord
+}
+
+func (h *refillHistory) RecordRefill(_ context.Context, record RefillRecord) error {
+	h.refills = append(h.refills, record)
+	return nil
+}
+
+func (h *refillHistory) RefillsSince(_ context.Context, t time.Time) ([]RefillRecord, error) {
+	var records []RefillRecord
+	for _, record := range h.refills {
+		if !record.CreatedAt.Before(t) {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func TestTreasury_Restore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	history := &refillHistory{}
+	treasury, _ := newTestTreasury(t, 0)
+	treasury.now = func() time.Time { return now }
+	treasury.History = history
+
+	refilled, err := treasury.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000*amount.One), refilled)
+	assert.Equal(t, []RefillRecord{{CreatedAt: now, Amount: 1000 * amount.One}}, history.refills)
+
+	// A restarted treasury still counts the earlier refill.
+	history.refills = append([]RefillRecord{{CreatedAt: now.Add(-25 * time.Hour), Amount: 1500 * amount.One}}, history.refills...)
+	restarted, _ := newTestTreasury(t, 0)
+	restarted.now = func() time.Time { return now.Add(time.Hour) }
+	restarted.History = history
+	require.NoError(t, restarted.Restore(context.Background()))
+	assert.Equal(t, int64(500*amount.One), restarted.remainingDailyRefill())
+}
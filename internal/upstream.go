@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+
+	"github.com/stellar/go-stellar-sdk/support/errors"
+)
+
+// ErrUpstreamUnavailable is returned when the network (Horizon or RPC) could
+// not be reached.
+var ErrUpstreamUnavailable = errors.New("network is unavailable")
+
+// ErrUpstreamTimeout is returned when the network (Horizon or RPC) did not
+// respond in time.
+var ErrUpstreamTimeout = errors.New("timed out waiting for the network")
+
+// ProblemTypeUpstreamTimeout is the type of the problem ErrUpstreamTimeout is
+// rendered as.
+const ProblemTypeUpstreamTimeout = "upstream_timeout"
+
+// upstreamError returns err wrapped around ErrUpstreamTimeout or
+// ErrUpstreamUnavailable if it was caused by failing to reach the network, so
+// that it is rendered as the matching problem, and err otherwise.
+func upstreamError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var networkErr NetworkError
+	if stderrors.As(err, &networkErr) && networkErr.IsTimeout() {
+		return errors.Wrap(ErrUpstreamTimeout, err.Error())
+	}
+	if stderrors.Is(err, context.DeadlineExceeded) {
+		return errors.Wrap(ErrUpstreamTimeout, err.Error())
+	}
+	var netErr net.Error
+	if stderrors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return errors.Wrap(ErrUpstreamTimeout, err.Error())
+		}
+		return errors.Wrap(ErrUpstreamUnavailable, err.Error())
+	}
+	return err
+}
@@ -1,80 +1,214 @@
+// Command loadtest sends funding requests to a friendbot server at a rate set
+// by a load profile, and reports the throughput, latency percentiles and
+// errors of the requests.
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"crypto/rand"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
+	mathrand "math/rand/v2"
 	"net/http"
-	"net/url"
+	"os"
+	"os/signal"
+	"sync"
 	"time"
 
+	"github.com/stellar/friendbot/client"
 	"github.com/stellar/go-stellar-sdk/keypair"
-	"github.com/stellar/go-stellar-sdk/support/errors"
+	"github.com/stellar/go-stellar-sdk/strkey"
 )
 
-type maybeDuration struct {
-	maybeDuration time.Duration
-	maybeError    error
+// idleInterval is how often the rate is checked while a profile sends no
+// requests.
+const idleInterval = 10 * time.Millisecond
+
+// options configures a load test.
+type options struct {
+	URL           string
+	Profile       string
+	Requests      int
+	Concurrency   int
+	ContractRatio float64
+	profileOptions
 }
 
 func main() {
 	// Friendbot must be running as a local server. Get Friendbot URL from CL.
-	fbURL := flag.String("url", "http://0.0.0.0:8000/", "URL of friendbot")
-	numRequests := flag.Int("requests", 500, "number of requests")
+	opts := options{}
+	flag.StringVar(&opts.URL, "url", "http://0.0.0.0:8000/", "URL of friendbot")
+	flag.IntVar(&opts.Requests, "requests", 500, "number of requests to send, or 0 to send requests until -duration has elapsed")
+	flag.IntVar(&opts.Concurrency, "concurrency", 10, "maximum number of requests in flight at once")
+	flag.StringVar(&opts.Profile, "profile", "constant", "load profile: constant, ramp, step or spike")
+	flag.Float64Var(&opts.Rate, "rate", 2, "requests per second, or the peak rate of the ramp, step and spike profiles")
+	flag.Float64Var(&opts.StartRate, "start-rate", 0, "rate the ramp and step profiles start from, and the rate outside of a spike")
+	flag.DurationVar(&opts.Duration, "duration", 0, "length of the test, required by the ramp, step and spike profiles")
+	flag.IntVar(&opts.Steps, "steps", 5, "number of steps of the step profile")
+	flag.DurationVar(&opts.SpikeDuration, "spike-duration", 10*time.Second, "length of the spike, in the middle of the spike profile")
+	flag.Float64Var(&opts.ContractRatio, "contract-ratio", 0, "fraction of requests funding contract (C) addresses rather than accounts (G)")
+	timeout := flag.Duration("timeout", 30*time.Second, "timeout of each request")
+	jsonPath := flag.String("json", "", "file to write the report to as JSON, or - to write it to stdout instead of the text report")
 	flag.Parse()
-	durationChannel := make(chan maybeDuration, *numRequests)
-	for i := 0; i < *numRequests; i++ {
-		kp, err := keypair.Random()
-		if err != nil {
-			panic(err)
-		}
-		address := kp.Address()
-		go makeFriendbotRequest(address, *fbURL, durationChannel)
 
-		time.Sleep(time.Duration(500) * time.Millisecond)
+	rateProfile, err := newProfile(opts.Profile, opts.profileOptions)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if opts.Requests < 0 || (opts.Requests == 0 && opts.Duration <= 0) {
+		log.Fatal("one of -requests or -duration is required")
+	}
+	if opts.Concurrency <= 0 {
+		log.Fatal("-concurrency must be positive")
 	}
-	durations := []maybeDuration{}
-	for i := 0; i < *numRequests; i++ {
-		durations = append(durations, <-durationChannel)
+	if opts.ContractRatio < 0 || opts.ContractRatio > 1 {
+		log.Fatal("-contract-ratio must be between 0 and 1")
+	}
+
+	c := client.New(opts.URL)
+	c.HTTP = &http.Client{Timeout: *timeout}
+	// Retries would hide the errors and latency of the server under load.
+	c.MaxRetries = -1
+	c.UserAgent = "friendbot-loadtest"
+
+	// Stop sending requests on interrupt, and report those already sent.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	log.Printf("Sending requests to %s with the %s profile", opts.URL, opts.Profile)
+	start := time.Now()
+	results := run(ctx, c, opts, rateProfile)
+	r := newReport(opts.URL, opts.Profile, time.Since(start), results)
+
+	if *jsonPath != "-" {
+		if err := r.WriteText(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *jsonPath != "" {
+		if err := writeJSONReport(r, *jsonPath); err != nil {
+			log.Fatal(err)
+		}
 	}
-	close(durationChannel)
-	log.Printf("Got %d times with average %s", *numRequests, mean(durations))
 }
 
-func makeFriendbotRequest(address, fbURL string, durationChannel chan maybeDuration) {
+// run sends requests at the rate of rateProfile until opts.Requests have been
+// sent, opts.Duration has elapsed or ctx is done, and returns their results
+// once all of them have completed.
+func run(ctx context.Context, c *client.Client, opts options, rateProfile profile) []result {
+	var (
+		mu      sync.Mutex
+		results []result
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, opts.Concurrency)
+	)
 	start := time.Now()
-	formData := url.Values{
-		"addr": {address},
+	next := start
+	for sent := 0; opts.Requests == 0 || sent < opts.Requests; {
+		elapsed := time.Since(start)
+		if opts.Duration > 0 && elapsed >= opts.Duration {
+			break
+		}
+		rate := rateProfile(elapsed)
+		if rate <= 0 {
+			if !sleep(ctx, idleInterval) {
+				break
+			}
+			next = time.Now()
+			continue
+		}
+
+		// Requests are delayed while opts.Concurrency requests are in
+		// flight, so the rate is not reached if the server is too slow.
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			res := fund(c, opts.ContractRatio)
+			mu.Lock()
+			results = append(results, res)
+			mu.Unlock()
+		}()
+		sent++
+
+		next = next.Add(time.Duration(float64(time.Second) / rate))
+		if behind := time.Since(next); behind > time.Second {
+			// Don't send a burst of requests to catch up after falling
+			// behind.
+			next = time.Now()
+		}
+		if !sleep(ctx, time.Until(next)) {
+			break
+		}
 	}
-	resp, err := http.PostForm(fbURL, formData)
-	if err != nil {
-		log.Printf("Got post error: %s", err)
-		durationChannel <- maybeDuration{maybeError: errors.Wrap(err, "posting form")}
+	wg.Wait()
+	return results
+}
+
+// fund funds a new address, which is a contract with probability
+// contractRatio and an account otherwise.
+func fund(c *client.Client, contractRatio float64) result {
+	addressType, address := "account", keypair.MustRandom().Address()
+	if mathrand.Float64() < contractRatio {
+		addressType, address = "contract", randomContractAddress()
 	}
-	var result map[string]interface{}
-	err = json.NewDecoder(resp.Body).Decode(&result)
-	if err != nil {
-		log.Printf("Got decode error: %s", err)
-		durationChannel <- maybeDuration{maybeError: errors.Wrap(err, "decoding json")}
+
+	start := time.Now()
+	_, err := c.Fund(context.Background(), address)
+	res := result{AddressType: addressType, Latency: time.Since(start)}
+	var p *client.Problem
+	switch {
+	case err == nil:
+	case errors.As(err, &p):
+		res.ErrorType = p.Kind()
+	default:
+		res.ErrorType = "request_error"
+		log.Printf("Request for %s failed: %v", address, err)
 	}
-	timeTrack(start, "makeFriendbotRequest", durationChannel)
+	return res
 }
 
-func timeTrack(start time.Time, name string, durationChannel chan maybeDuration) {
-	elapsed := time.Since(start)
-	log.Printf("%s took %s", name, elapsed)
-	durationChannel <- maybeDuration{maybeDuration: elapsed}
+func randomContractAddress() string {
+	var id [32]byte
+	rand.Read(id[:])
+	return strkey.MustEncode(strkey.VersionByteContract, id[:])
 }
 
-func mean(durations []maybeDuration) time.Duration {
-	var total time.Duration
-	count := 0
-	for _, d := range durations {
-		if d.maybeError != nil {
-			continue
-		}
-		total += d.maybeDuration
-		count++
+// sleep waits for d, and returns false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func writeJSONReport(r *report, path string) error {
+	if path == "-" {
+		return r.WriteJSON(os.Stdout)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating JSON report: %w", err)
+	}
+	if err := r.WriteJSON(f); err != nil {
+		f.Close()
+		return fmt.Errorf("writing JSON report: %w", err)
 	}
-	return total / time.Duration(count)
+	return f.Close()
 }
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stellar/friendbot/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.FormValue("addr"), "C") {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]any{
+				"type": "https://stellar.org/friendbot-errors/bad_request", "title": "Bad Request", "status": 400,
+			})
+			return
+		}
+		w.Write([]byte(`{"successful": true}`))
+	}))
+	defer server.Close()
+
+	c := client.New(server.URL)
+	c.MaxRetries = -1
+	opts := options{Requests: 20, Concurrency: 4, ContractRatio: 0.5}
+	results := run(context.Background(), c, opts, func(elapsed time.Duration) float64 { return 1000 })
+
+	r := newReport(server.URL, "constant", time.Second, results)
+	assert.Equal(t, 20, r.Requests)
+	assert.Equal(t, r.AddressTypes["contract"].Requests, r.Errors["bad_request"])
+	assert.Equal(t, r.AddressTypes["account"].Requests, r.Successes)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// profile returns the rate, in requests per second, at which requests are
+// sent once elapsed has passed since the start of the test.
+type profile func(elapsed time.Duration) float64
+
+// profileOptions configures the rate of a profile.
+type profileOptions struct {
+	// Rate is the constant rate, or the peak rate of the other profiles.
+	Rate float64
+	// StartRate is the rate ramps and steps start from, and the rate outside
+	// of a spike.
+	StartRate float64
+	// Duration is the length of the test, over which profiles are spread.
+	Duration time.Duration
+	// Steps is the number of equal steps from StartRate to Rate.
+	Steps int
+	// SpikeDuration is the length of the spike, in the middle of the test.
+	SpikeDuration time.Duration
+}
+
+// profileNames are the names of the profiles newProfile accepts.
+var profileNames = []string{"constant", "ramp", "step", "spike"}
+
+// newProfile returns the profile with the given name.
+func newProfile(name string, opts profileOptions) (profile, error) {
+	if opts.Rate <= 0 {
+		return nil, errors.New("rate must be positive")
+	}
+	if opts.StartRate < 0 || opts.StartRate > opts.Rate {
+		return nil, errors.New("start rate must be between 0 and the rate")
+	}
+	if name != "constant" && slices.Contains(profileNames, name) && opts.Duration <= 0 {
+		return nil, fmt.Errorf("the %s profile requires a duration", name)
+	}
+
+	switch name {
+	case "constant":
+		return func(time.Duration) float64 { return opts.Rate }, nil
+	case "ramp":
+		return func(elapsed time.Duration) float64 {
+			progress := min(float64(elapsed)/float64(opts.Duration), 1)
+			return opts.StartRate + (opts.Rate-opts.StartRate)*progress
+		}, nil
+	case "step":
+		if opts.Steps <= 0 {
+			return nil, errors.New("the step profile requires a positive number of steps")
+		}
+		return func(elapsed time.Duration) float64 {
+			step := min(int(int64(elapsed)*int64(opts.Steps)/int64(opts.Duration)), opts.Steps-1)
+			return opts.StartRate + (opts.Rate-opts.StartRate)*float64(step+1)/float64(opts.Steps)
+		}, nil
+	case "spike":
+		if opts.SpikeDuration <= 0 || opts.SpikeDuration >= opts.Duration {
+			return nil, errors.New("the spike profile requires a spike duration shorter than the duration")
+		}
+		if opts.StartRate == 0 {
+			return nil, errors.New("the spike profile requires a start rate, which is sent at outside of the spike")
+		}
+		spikeStart := (opts.Duration - opts.SpikeDuration) / 2
+		spikeEnd := spikeStart + opts.SpikeDuration
+		return func(elapsed time.Duration) float64 {
+			if elapsed >= spikeStart && elapsed < spikeEnd {
+				return opts.Rate
+			}
+			return opts.StartRate
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown profile %q: must be one of %v", name, profileNames)
+	}
+}
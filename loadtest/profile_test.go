@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProfile(t *testing.T) {
+	opts := profileOptions{Rate: 10, StartRate: 2, Duration: 100 * time.Second, Steps: 4, SpikeDuration: 20 * time.Second}
+	for _, tc := range []struct {
+		name  string
+		rates map[time.Duration]float64
+	}{
+		{"constant", map[time.Duration]float64{0: 10, 50 * time.Second: 10, time.Hour: 10}},
+		{"ramp", map[time.Duration]float64{0: 2, 50 * time.Second: 6, 100 * time.Second: 10, time.Hour: 10}},
+		{"step", map[time.Duration]float64{0: 4, 24 * time.Second: 4, 25 * time.Second: 6, 75 * time.Second: 10, time.Hour: 10}},
+		{"spike", map[time.Duration]float64{0: 2, 39 * time.Second: 2, 40 * time.Second: 10, 59 * time.Second: 10, 60 * time.Second: 2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := newProfile(tc.name, opts)
+			require.NoError(t, err)
+			for elapsed, rate := range tc.rates {
+				assert.Equal(t, rate, p(elapsed), "rate after %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestNewProfile_errors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts profileOptions
+		err  string
+	}{
+		{"constant", profileOptions{}, "rate must be positive"},
+		{"constant", profileOptions{Rate: 1, StartRate: 2}, "start rate must be between 0 and the rate"},
+		{"ramp", profileOptions{Rate: 1}, "the ramp profile requires a duration"},
+		{"step", profileOptions{Rate: 1, Duration: time.Minute}, "the step profile requires a positive number of steps"},
+		{"spike", profileOptions{Rate: 10, StartRate: 1, Duration: time.Minute, SpikeDuration: time.Minute}, "the spike profile requires a spike duration shorter than the duration"},
+		{"spike", profileOptions{Rate: 10, Duration: time.Minute, SpikeDuration: time.Second}, "the spike profile requires a start rate, which is sent at outside of the spike"},
+		{"sine", profileOptions{Rate: 1}, `unknown profile "sine": must be one of [constant ramp step spike]`},
+	} {
+		_, err := newProfile(tc.name, tc.opts)
+		assert.EqualError(t, err, tc.err)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// result is the outcome of a single funding request.
+type result struct {
+	// AddressType is "account" or "contract".
+	AddressType string
+	Latency     time.Duration
+	// ErrorType is empty for successful requests, the kind of the problem
+	// returned by friendbot for failed ones, or "request_error" if there was
+	// no response.
+	ErrorType string
+}
+
+// report summarizes the results of a load test.
+type report struct {
+	URL             string                        `json:"url"`
+	Profile         string                        `json:"profile"`
+	DurationSeconds float64                       `json:"duration_seconds"`
+	Requests        int                           `json:"requests"`
+	Successes       int                           `json:"successes"`
+	Failures        int                           `json:"failures"`
+	ThroughputRPS   float64                       `json:"throughput_rps"`
+	LatencyMs       latencyStats                  `json:"latency_ms"`
+	Errors          map[string]int                `json:"errors"`
+	AddressTypes    map[string]*addressTypeReport `json:"address_types"`
+}
+
+// latencyStats are the latencies of successful requests, in milliseconds.
+type latencyStats struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// addressTypeReport counts the requests for one type of address.
+type addressTypeReport struct {
+	Requests int `json:"requests"`
+	Failures int `json:"failures"`
+}
+
+// newReport returns the report of results, from a test that ran for elapsed.
+func newReport(url, profileName string, elapsed time.Duration, results []result) *report {
+	r := &report{
+		URL:             url,
+		Profile:         profileName,
+		DurationSeconds: elapsed.Seconds(),
+		Requests:        len(results),
+		Errors:          map[string]int{},
+		AddressTypes:    map[string]*addressTypeReport{},
+	}
+	if elapsed > 0 {
+		r.ThroughputRPS = float64(len(results)) / elapsed.Seconds()
+	}
+
+	var latencies []time.Duration
+	for _, res := range results {
+		addressType := r.AddressTypes[res.AddressType]
+		if addressType == nil {
+			addressType = &addressTypeReport{}
+			r.AddressTypes[res.AddressType] = addressType
+		}
+		addressType.Requests++
+		if res.ErrorType != "" {
+			r.Failures++
+			addressType.Failures++
+			r.Errors[res.ErrorType]++
+			continue
+		}
+		r.Successes++
+		latencies = append(latencies, res.Latency)
+	}
+	r.LatencyMs = newLatencyStats(latencies)
+	return r
+}
+
+func newLatencyStats(latencies []time.Duration) latencyStats {
+	if len(latencies) == 0 {
+		return latencyStats{}
+	}
+	slices.Sort(latencies)
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+	return latencyStats{
+		Min:  milliseconds(latencies[0]),
+		Mean: milliseconds(total / time.Duration(len(latencies))),
+		P50:  milliseconds(percentile(latencies, 50)),
+		P95:  milliseconds(percentile(latencies, 95)),
+		P99:  milliseconds(percentile(latencies, 99)),
+		Max:  milliseconds(latencies[len(latencies)-1]),
+	}
+}
+
+// percentile returns the p-th percentile of sorted, using the nearest-rank
+// method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// WriteJSON writes the report as JSON, for tracking regressions in CI.
+func (r *report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteText writes the report in a human-readable form.
+func (r *report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "URL:\t%s\n", r.URL)
+	fmt.Fprintf(tw, "Profile:\t%s\n", r.Profile)
+	fmt.Fprintf(tw, "Duration:\t%s\n", time.Duration(r.DurationSeconds*float64(time.Second)).Round(time.Millisecond))
+	fmt.Fprintf(tw, "Requests:\t%d (%d succeeded, %d failed)\n", r.Requests, r.Successes, r.Failures)
+	fmt.Fprintf(tw, "Throughput:\t%.2f req/s\n", r.ThroughputRPS)
+	l := r.LatencyMs
+	fmt.Fprintf(tw, "Latency:\tmin %.1fms, mean %.1fms, p50 %.1fms, p95 %.1fms, p99 %.1fms, max %.1fms\n",
+		l.Min, l.Mean, l.P50, l.P95, l.P99, l.Max)
+
+	var addressTypes []string
+	for _, name := range sortedKeys(r.AddressTypes) {
+		a := r.AddressTypes[name]
+		addressTypes = append(addressTypes, fmt.Sprintf("%s %d (%d failed)", name, a.Requests, a.Failures))
+	}
+	fmt.Fprintf(tw, "Addresses:\t%s\n", strings.Join(addressTypes, ", "))
+
+	if len(r.Errors) > 0 {
+		fmt.Fprintf(tw, "Errors:\t\n")
+		for _, errorType := range sortedKeys(r.Errors) {
+			fmt.Fprintf(tw, "  %s\t%d\n", errorType, r.Errors[errorType])
+		}
+	}
+	return tw.Flush()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReport(t *testing.T) {
+	var results []result
+	for i := 1; i <= 100; i++ {
+		results = append(results, result{AddressType: "account", Latency: time.Duration(i) * time.Millisecond})
+	}
+	results = append(results,
+		result{AddressType: "contract", Latency: time.Second, ErrorType: "bad_request"},
+		result{AddressType: "account", Latency: time.Second, ErrorType: "queue_full"},
+		result{AddressType: "account", Latency: time.Second, ErrorType: "queue_full"},
+	)
+
+	r := newReport("http://localhost:8000/", "constant", 10*time.Second, results)
+	assert.Equal(t, 103, r.Requests)
+	assert.Equal(t, 100, r.Successes)
+	assert.Equal(t, 3, r.Failures)
+	assert.InDelta(t, 10.3, r.ThroughputRPS, 0.001)
+	assert.Equal(t, latencyStats{Min: 1, Mean: 50.5, P50: 50, P95: 95, P99: 99, Max: 100}, r.LatencyMs)
+	assert.Equal(t, map[string]int{"bad_request": 1, "queue_full": 2}, r.Errors)
+	assert.Equal(t, map[string]*addressTypeReport{
+		"account":  {Requests: 102, Failures: 2},
+		"contract": {Requests: 1, Failures: 1},
+	}, r.AddressTypes)
+
+	var text bytes.Buffer
+	require.NoError(t, r.WriteText(&text))
+	assert.Contains(t, text.String(), "p50 50.0ms, p95 95.0ms, p99 99.0ms")
+	assert.Contains(t, text.String(), "account 102 (2 failed), contract 1 (1 failed)")
+	assert.Contains(t, text.String(), "queue_full   2")
+
+	var encoded bytes.Buffer
+	require.NoError(t, r.WriteJSON(&encoded))
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(encoded.Bytes(), &decoded))
+	assert.Equal(t, 99.0, decoded["latency_ms"].(map[string]any)["p99"])
+	assert.Equal(t, 2.0, decoded["errors"].(map[string]any)["queue_full"])
+}
+
+func TestPercentile(t *testing.T) {
+	assert.Equal(t, time.Duration(1), percentile([]time.Duration{1}, 99))
+	assert.Equal(t, time.Duration(1), percentile([]time.Duration{1, 2}, 50))
+	assert.Equal(t, time.Duration(2), percentile([]time.Duration{1, 2}, 51))
+}